@@ -0,0 +1,9 @@
+// Package api holds the contracts of the public APIs: the OpenAPI 3 spec of
+// the REST API served under /api/v1 and the protobuf definitions of the
+// gRPC API.
+package api
+
+import _ "embed"
+
+//go:embed openapi.yaml
+var OpenAPI []byte
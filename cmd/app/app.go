@@ -2,52 +2,96 @@ package app
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
+	"time"
 
-	_ "github.com/Komilov31/delayed-notifier/docs"
+	"github.com/Komilov31/delayed-notifier/internal/cache/lru"
 	"github.com/Komilov31/delayed-notifier/internal/cache/redis"
+	"github.com/Komilov31/delayed-notifier/internal/cache/tiered"
 	"github.com/Komilov31/delayed-notifier/internal/config"
+	"github.com/Komilov31/delayed-notifier/internal/events"
+	"github.com/Komilov31/delayed-notifier/internal/grpcserver"
 	"github.com/Komilov31/delayed-notifier/internal/handler"
+	"github.com/Komilov31/delayed-notifier/internal/memory"
+	"github.com/Komilov31/delayed-notifier/internal/model"
 	"github.com/Komilov31/delayed-notifier/internal/rabbitmq"
 	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/repository/sqlite"
 	"github.com/Komilov31/delayed-notifier/internal/sender"
 	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
 
+	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
+	"google.golang.org/grpc"
 )
 
 func Run() error {
 	zlog.Init()
 
-	dbString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		config.Cfg.Postgres.Host,
-		config.Cfg.Postgres.Port,
-		config.Cfg.Postgres.User,
-		config.Cfg.Postgres.Password,
-		config.Cfg.Postgres.Name,
-	)
-	opts := &dbpg.Options{MaxOpenConns: 10, MaxIdleConns: 5}
-	db, err := dbpg.New(dbString, []string{}, opts)
-	if err != nil {
-		log.Fatal("could not init db: " + err.Error())
-	}
-	repository := repository.New(db)
-	cache := redis.New()
-	queue := rabbitmq.New()
-	sender := sender.New()
-	service := service.New(repository, cache, queue, sender)
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	storage := newStorage()
+	cache := newCache(ctx)
+	queue := newQueue()
+	events := newEvents(ctx)
+	sender := sender.New(config.Cfg.Telegram.APIURL)
+	if config.Cfg.Telegram.ReceiveUpdates {
+		sender.EnableSnooze()
+	}
+	pool := service.PoolConfig{
+		Workers:     config.Cfg.Consumer.Workers,
+		Concurrency: config.Cfg.Consumer.Concurrency,
+		Prefetch:    config.Cfg.Consumer.Prefetch,
+	}
+	if err := pool.Validate(); err != nil {
+		log.Fatal("invalid consumer config: ", err)
+	}
+	opts := append([]service.Option{
+		service.WithPoolConfig(pool),
+		service.WithReconcileInterval(time.Duration(config.Cfg.Cache.ReconcileInterval) * time.Second),
+		service.WithReconcileBatch(config.Cfg.Cache.ReconcileBatch),
+		service.WithEvents(events),
+	}, newChannelSenders()...)
+	if policy := config.Cfg.Collapse.Policy; policy != "" {
+		if !model.IsCollapsePolicy(policy) {
+			log.Fatal("unknown collapse policy: ", policy)
+		}
+		opts = append(opts, service.WithCollapsePolicy(policy))
+	}
+	if catchUp := config.Cfg.CatchUp; catchUp.Policy != "" {
+		if !model.IsCatchUpPolicy(catchUp.Policy) {
+			log.Fatal("unknown catch-up policy: ", catchUp.Policy)
+		}
+		opts = append(opts, service.WithCatchUp(service.CatchUpConfig{
+			Policy:       catchUp.Policy,
+			OverdueAfter: time.Duration(catchUp.OverdueAfter) * time.Second,
+			MaxAge:       time.Duration(catchUp.MaxAge) * time.Second,
+			Window:       time.Duration(catchUp.Window) * time.Second,
+		}))
+	}
+	if retention := config.Cfg.Retention; len(retention.Policies) > 0 {
+		opts = append(opts, service.WithRetention(newRetentionConfig(retention)))
+	}
+	if secret := config.Cfg.Unsubscribe.Secret; secret != "" {
+		opts = append(opts, service.WithUnsubscribeLinks(unsubscribe.NewSigner([]byte(secret)), config.Cfg.Unsubscribe.BaseURL))
+	}
+	service := service.New(storage, cache, queue, sender, opts...)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -69,30 +113,244 @@ func Run() error {
 		}
 	}()
 
-	handler := handler.New(service)
+	go func() {
+		if err := service.WarmupCache(ctx); err != nil {
+			zlog.Logger.Warn().Msg(err.Error())
+		}
+	}()
+	go service.ReconcileCache(ctx)
+	if len(config.Cfg.Retention.Policies) > 0 {
+		go service.EnforceRetention(ctx)
+	}
+
+	if config.Cfg.Telegram.ReceiveUpdates {
+		go func() {
+			if err := sender.RunBot(ctx, service); err != nil {
+				log.Fatal("could not run telegram bot: ", err)
+			}
+		}()
+	}
+
+	if config.Cfg.GrpcServer.Address != "" {
+		go func() {
+			if err := runGrpcServer(ctx, service); err != nil {
+				log.Fatal("could not run grpc server: ", err)
+			}
+		}()
+	}
 
 	router := ginext.New()
+	router.Use(handler.RequestID(), handler.Recovery())
+
+	handler := handler.New(service)
 	registerRoutes(router, handler)
 
+	if config.Cfg.AdminServer.Address != "" {
+		go func() {
+			if err := runAdminServer(ctx, handler); err != nil {
+				log.Fatal("could not run admin server: ", err)
+			}
+		}()
+	}
+
 	zlog.Logger.Info().Msg("succesfully started server on " + config.Cfg.HttpServer.Address)
 	return router.Run(config.Cfg.HttpServer.Address)
 }
 
+func runGrpcServer(ctx context.Context, service handler.NotifierService) error {
+	listener, err := net.Listen("tcp", config.Cfg.GrpcServer.Address)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	grpcserver.New(service).Register(server)
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	zlog.Logger.Info().Msg("succesfully started grpc server on " + config.Cfg.GrpcServer.Address)
+	return server.Serve(listener)
+}
+
+// runAdminServer serves the admin routes and the expvar metrics on their
+// own listener, which is not published outside the internal network.
+func runAdminServer(ctx context.Context, h *handler.Handler) error {
+	router := ginext.New()
+	router.Use(handler.RequestID(), handler.Recovery())
+	router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	h.RegisterAdminRoutes(router.Engine)
+	router.NoRoute(h.NotFound)
+
+	server := &http.Server{Addr: config.Cfg.AdminServer.Address, Handler: router}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	zlog.Logger.Info().Msg("succesfully started admin server on " + config.Cfg.AdminServer.Address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func newStorage() service.Storage {
+	switch config.Cfg.Backend.Storage {
+	case "memory":
+		zlog.Logger.Warn().Msg("using in-memory storage, notifications are lost on restart")
+		return memory.NewStorage()
+	case "sqlite":
+		repo, err := sqlite.New(config.Cfg.SQLite.Path)
+		if err != nil {
+			log.Fatal("could not init sqlite db: " + err.Error())
+		}
+		return repo
+	case "", "postgres":
+	default:
+		log.Fatal("unknown storage backend: ", config.Cfg.Backend.Storage)
+	}
+
+	dbString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		config.Cfg.Postgres.Host,
+		config.Cfg.Postgres.Port,
+		config.Cfg.Postgres.User,
+		config.Cfg.Postgres.Password,
+		config.Cfg.Postgres.Name,
+	)
+	opts := &dbpg.Options{MaxOpenConns: 10, MaxIdleConns: 5}
+	db, err := dbpg.New(dbString, []string{}, opts)
+	if err != nil {
+		log.Fatal("could not init db: " + err.Error())
+	}
+
+	return repository.New(db)
+}
+
+func newCache(ctx context.Context) service.Cache {
+	switch config.Cfg.Backend.Cache {
+	case "memory":
+		return memory.NewCache()
+	case "", "redis":
+		remote := redis.New()
+		if config.Cfg.Cache.LocalSize <= 0 {
+			return remote
+		}
+
+		local := lru.New(config.Cfg.Cache.LocalSize, time.Duration(config.Cfg.Cache.LocalTTL)*time.Second)
+		cache := tiered.New(remote, remote, local)
+		if err := cache.Start(ctx); err != nil {
+			log.Fatal("could not start local cache: ", err)
+		}
+		return cache
+	default:
+		log.Fatal("unknown cache backend: ", config.Cfg.Backend.Cache)
+		return nil
+	}
+}
+
+// newEvents shares events between replicas through redis unless the cache
+// runs in memory, i.e. there is a single replica.
+func newEvents(ctx context.Context) service.Events {
+	if config.Cfg.Backend.Cache == "memory" {
+		return events.NewBus()
+	}
+
+	fanout := events.NewFanout(redis.New())
+	if err := fanout.Start(ctx); err != nil {
+		log.Fatal("could not subscribe to events: ", err)
+	}
+	return fanout
+}
+
+// newChannelSenders returns options registering the senders of configured
+// channels besides telegram.
+func newChannelSenders() []service.Option {
+	channels := config.Cfg.Channels
+	var opts []service.Option
+
+	if email := channels.Email; email.SMTPAddress != "" {
+		opts = append(opts, service.WithChannelSender(model.ChannelEmail,
+			sender.NewEmailSender(email.SMTPAddress, email.From, email.Username, email.Password)))
+	}
+	if sms := channels.SMS; sms.GatewayURL != "" {
+		opts = append(opts, service.WithChannelSender(model.ChannelPhone,
+			sender.NewSMSSender(sms.GatewayURL, sms.Token, time.Duration(sms.Timeout)*time.Second)))
+	}
+	if webhook := channels.Webhook; webhook.Enabled {
+		opts = append(opts, service.WithChannelSender(model.ChannelWebhook,
+			sender.NewWebhookSender(time.Duration(webhook.Timeout)*time.Second)))
+	}
+
+	return opts
+}
+
+// newRetentionConfig converts the retention settings, an invalid tenant or
+// status, or a tenant with two policies, is fatal.
+func newRetentionConfig(retention config.RetentionConfig) service.RetentionConfig {
+	converted := service.RetentionConfig{
+		Interval:  time.Duration(retention.Interval) * time.Second,
+		BatchSize: retention.BatchSize,
+	}
+
+	seen := make(map[string]bool, len(retention.Policies))
+	for _, policy := range retention.Policies {
+		if policy.Tenant != "" && !model.IsTenant(policy.Tenant) {
+			log.Fatal("invalid retention policy tenant: ", policy.Tenant)
+		}
+		if seen[policy.Tenant] {
+			log.Fatal("duplicate retention policy for tenant: ", policy.Tenant)
+		}
+		seen[policy.Tenant] = true
+
+		for _, status := range policy.Statuses {
+			if !slices.Contains(model.FinishedStatuses, status) {
+				log.Fatal("retention policies apply to finished statuses only, got: ", status)
+			}
+		}
+
+		converted.Policies = append(converted.Policies, service.RetentionPolicy{
+			Tenant:         policy.Tenant,
+			Statuses:       policy.Statuses,
+			PurgeAfter:     time.Duration(policy.PurgeAfter) * time.Second,
+			AnonymizeAfter: time.Duration(policy.AnonymizeAfter) * time.Second,
+		})
+	}
+
+	return converted
+}
+
+func newQueue() service.Queue {
+	switch config.Cfg.Backend.Queue {
+	case "memory":
+		return memory.NewQueue()
+	case "", "rabbitmq":
+		return rabbitmq.New()
+	default:
+		log.Fatal("unknown queue backend: ", config.Cfg.Backend.Queue)
+		return nil
+	}
+}
+
 func registerRoutes(engine *ginext.Engine, handler *handler.Handler) {
 	// Register static files
 	engine.LoadHTMLFiles("/app/static/index.html")
 	engine.Static("/static", "/app/static")
 
-	// POST requests
-	engine.POST("/notify", handler.CreateNotification)
-
-	// GET requests
-	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	engine.GET("/", handler.GetMainPage)
-	engine.GET("/notify/:id", handler.GetNotificationStatus)
-	engine.GET("/notify", handler.GetAllNotifications)
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/api/v1/openapi.yaml")))
+
+	handler.RegisterRoutes(engine.Group("/api/v1").RouterGroup)
 
-	// DELETE request
-	engine.DELETE("notify/:id", handler.UpdateNotificationStatus)
+	// Deprecated unversioned routes, kept for existing clients
+	engine.POST("/notify", handler.CreateNotification)
+	engine.GET("/notify", handler.GetAllNotifications)
+	engine.GET("/notify/:id", handler.GetNotificationStatus)
+	engine.GET("/notify/stream", handler.StreamNotifications)
+	engine.DELETE("/notify/:id", handler.UpdateNotificationStatus)
+	engine.POST("/notify/:id/snooze", handler.SnoozeNotification)
+	engine.POST("/notify/:id/resend", handler.ResendNotification)
 
+	engine.NoRoute(handler.NotFound)
 }
@@ -0,0 +1,64 @@
+// Package apperr defines the errors reported to API clients. The code tells
+// the client what went wrong, the message is safe to show, and the cause is
+// kept for logs only.
+package apperr
+
+import "errors"
+
+type Code string
+
+const (
+	CodeNotFound         Code = "not_found"
+	CodeValidationFailed Code = "validation_failed"
+	CodeConflict         Code = "conflict"
+	CodeInternal         Code = "internal"
+)
+
+// FieldError describes an invalid field of a request payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+func Internal(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// From returns err as an *Error, errors of unknown kind become internal ones.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("internal error", err)
+}
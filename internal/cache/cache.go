@@ -0,0 +1,51 @@
+// Package cache holds what the notification cache backends share: the key
+// layout and the expiration policy.
+package cache
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+const (
+	keyPrefix = "notif:"
+
+	// MissingTTL is how long an unknown id is remembered as missing.
+	MissingTTL = time.Minute
+
+	// sendGrace keeps an active notification cached for a while after
+	// send_at, until the consumer has handled it.
+	sendGrace   = time.Minute
+	terminalTTL = time.Hour
+	minTTL      = time.Minute
+	maxTTL      = 24 * time.Hour
+)
+
+// Key returns the key the notification is cached under.
+func Key(id int) string {
+	return keyPrefix + strconv.Itoa(id)
+}
+
+// IsTerminal reports whether the status is final, i.e. the notification is
+// not going to be sent anymore unless it is rescheduled.
+func IsTerminal(status string) bool {
+	switch status {
+	case "completed", "canceled", "failed", "expired":
+		return true
+	}
+	return false
+}
+
+// TTL returns how long the notification stays cached. An active notification
+// changes its status right after send_at, so it lives until then; a terminal
+// one rarely changes and is read rarely, so it is kept for terminalTTL.
+func TTL(notification model.Notification, now time.Time) time.Duration {
+	if IsTerminal(notification.Status) {
+		return terminalTTL
+	}
+
+	ttl := time.UnixMilli(int64(notification.SendAt)).Add(sendGrace).Sub(now)
+	return min(max(ttl, minTTL), maxTTL)
+}
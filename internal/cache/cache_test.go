@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "notif:42", Key(42))
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Now()
+	sendAt := func(d time.Duration) int { return int(now.Add(d).UnixMilli()) }
+
+	tests := []struct {
+		name         string
+		notification model.Notification
+		want         time.Duration
+	}{
+		{"active due in an hour", model.Notification{Status: "active", SendAt: sendAt(time.Hour)}, time.Hour + sendGrace},
+		{"active overdue", model.Notification{Status: "active", SendAt: sendAt(-time.Hour)}, minTTL},
+		{"active in a week", model.Notification{Status: "active", SendAt: sendAt(7 * 24 * time.Hour)}, maxTTL},
+		{"completed", model.Notification{Status: "completed", SendAt: sendAt(-time.Hour)}, terminalTTL},
+		{"canceled", model.Notification{Status: "canceled", SendAt: sendAt(7 * 24 * time.Hour)}, terminalTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, TTL(tt.notification, now), float64(time.Millisecond))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package lru is a size bounded in-process cache of notifications with a
+// fixed time to live.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+type entry struct {
+	id           int
+	notification *model.Notification
+	expiresAt    time.Time
+}
+
+// Cache evicts the least recently used entry once it holds size entries.
+// A nil notification is stored as is, callers use it to remember missing
+// ids.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[int]*list.Element
+}
+
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[int]*list.Element),
+	}
+}
+
+// Get returns the cached notification and whether the id was found.
+func (c *Cache) Get(id int) (*model.Notification, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.notification, true
+}
+
+// Set stores the notification under id unless a newer version is cached.
+func (c *Cache) Set(id int, notification *model.Notification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+
+	if element, ok := c.entries[id]; ok {
+		e := element.Value.(*entry)
+		if e.notification != nil && notification != nil && e.notification.Version > notification.Version {
+			return
+		}
+
+		e.notification = notification
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(&entry{
+		id:           id,
+		notification: notification,
+		expiresAt:    expiresAt,
+	})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache) Delete(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove must be called with c.mu held.
+func (c *Cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).id)
+}
@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New(2, time.Minute)
+
+	cache.Set(1, &model.Notification{Id: 1})
+	cache.Set(2, &model.Notification{Id: 2})
+
+	_, ok := cache.Get(1)
+	require.True(t, ok)
+
+	cache.Set(3, &model.Notification{Id: 3})
+
+	_, ok = cache.Get(2)
+	assert.False(t, ok, "least recently used entry must be evicted")
+	_, ok = cache.Get(1)
+	assert.True(t, ok)
+	_, ok = cache.Get(3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestCache_Expires(t *testing.T) {
+	cache := New(10, 10*time.Millisecond)
+
+	cache.Set(1, &model.Notification{Id: 1})
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := cache.Get(1)
+	assert.False(t, ok)
+	assert.Zero(t, cache.Len())
+}
+
+func TestCache_KeepsNewerVersion(t *testing.T) {
+	cache := New(10, time.Minute)
+
+	cache.Set(1, &model.Notification{Id: 1, Status: "canceled", Version: 3})
+	cache.Set(1, &model.Notification{Id: 1, Status: "active", Version: 2})
+
+	notification, ok := cache.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "canceled", notification.Status)
+
+	cache.Delete(1)
+	_, ok = cache.Get(1)
+	assert.False(t, ok)
+}
+
+func TestCache_Missing(t *testing.T) {
+	cache := New(10, time.Minute)
+
+	cache.Set(1, nil)
+
+	notification, ok := cache.Get(1)
+	assert.True(t, ok)
+	assert.Nil(t, notification)
+}
@@ -2,16 +2,43 @@ package redis
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/cache"
 	"github.com/Komilov31/delayed-notifier/internal/config"
+	"github.com/Komilov31/delayed-notifier/internal/model"
 	"github.com/Komilov31/delayed-notifier/internal/repository"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/wb-go/wbf/redis"
 )
 
+const (
+	// missingValue marks an id that is not in the storage.
+	missingValue = "-"
+
+	invalidationChannel = "notif:invalidations"
+	eventChannel        = "notif:events"
+)
+
+// setScript stores the serialized notification ARGV[2] for ARGV[3]
+// milliseconds unless the key already holds a newer version ARGV[1]. A
+// missing marker never blocks the write.
+var setScript = goredis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	local ok, cached = pcall(cjson.decode, current)
+	if ok and type(cached) == 'table' and (tonumber(cached.version) or 0) > tonumber(ARGV[1]) then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
 type Redis struct {
 	client redis.Client
 }
@@ -30,26 +57,146 @@ func New() *Redis {
 	}
 }
 
-func (r *Redis) Get(key string) (string, error) {
-	return r.client.Get(context.Background(), key)
-}
+func (r *Redis) GetNotification(id int) (*model.Notification, error) {
+	value, err := r.client.Get(context.Background(), cache.Key(id))
+	if err != nil {
+		return nil, err
+	}
 
-func (r *Redis) Set(key int, value interface{}) error {
-	id := strconv.Itoa(key)
-	return r.client.SetEX(context.Background(), id, value, time.Hour*24).Err()
+	if value == missingValue {
+		return nil, repository.ErrNoSuchNotification
+	}
+
+	var notification model.Notification
+	if err := json.Unmarshal([]byte(value), &notification); err != nil {
+		return nil, fmt.Errorf("could not unmarshal cached notification: %w", err)
+	}
+
+	return &notification, nil
 }
 
-func (r *Redis) LoadNotifications(repo *repository.Repository) error {
-	notifications, err := repo.GetAllNotifications()
+func (r *Redis) SetNotification(notification model.Notification) error {
+	args, err := setArgs(notification)
 	if err != nil {
-		log.Fatal("could not get notifications from db: ", err)
+		return err
 	}
 
-	for _, notif := range notifications {
-		if err := r.Set(notif.Id, notif.Status); err != nil {
+	return setScript.Run(context.Background(), r.client.Client, []string{cache.Key(notification.Id)}, args...).Err()
+}
+
+// SetNotifications writes all notifications in a single pipeline.
+func (r *Redis) SetNotifications(notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := setScript.Load(ctx, r.client.Client).Err(); err != nil {
+		return fmt.Errorf("could not load cache script: %w", err)
+	}
+
+	pipe := r.client.Pipeline()
+	for _, notification := range notifications {
+		args, err := setArgs(notification)
+		if err != nil {
 			return err
 		}
+		setScript.EvalSha(ctx, pipe, []string{cache.Key(notification.Id)}, args...)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SetMissing does not replace a cached notification: it may have been
+// created after the caller looked it up.
+func (r *Redis) SetMissing(id int) error {
+	return r.client.SetNX(context.Background(), cache.Key(id), missingValue, cache.MissingTTL).Err()
+}
+
+func (r *Redis) DeleteNotification(id int) error {
+	return r.client.Del(context.Background(), cache.Key(id)).Err()
+}
+
+func (r *Redis) PublishInvalidation(id int) error {
+	return r.client.Publish(context.Background(), invalidationChannel, id).Err()
+}
+
+// SubscribeInvalidations calls handle with every published id until ctx is
+// done.
+func (r *Redis) SubscribeInvalidations(ctx context.Context, handle func(id int)) error {
+	err := r.subscribe(ctx, invalidationChannel, func(payload string) {
+		if id, err := strconv.Atoi(payload); err == nil {
+			handle(id)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("could not subscribe to cache invalidations: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Redis) PublishEvent(event model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	return r.client.Publish(context.Background(), eventChannel, body).Err()
+}
+
+// SubscribeEvents calls handle with every event published by any replica
+// until ctx is done.
+func (r *Redis) SubscribeEvents(ctx context.Context, handle func(model.Event)) error {
+	err := r.subscribe(ctx, eventChannel, func(payload string) {
+		var event model.Event
+		if err := json.Unmarshal([]byte(payload), &event); err == nil {
+			handle(event)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("could not subscribe to events: %w", err)
 	}
 
 	return nil
 }
+
+// subscribe returns once the subscription is confirmed, so no message
+// published after that is missed. The client resubscribes after reconnects.
+func (r *Redis) subscribe(ctx context.Context, channel string, handle func(payload string)) error {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				handle(msg.Payload)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func setArgs(notification model.Notification) ([]interface{}, error) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal notification: %w", err)
+	}
+
+	ttl := cache.TTL(notification, time.Now())
+	return []interface{}{notification.Version, string(body), ttl.Milliseconds()}, nil
+}
@@ -0,0 +1,123 @@
+// Package tiered puts a short lived in-process LRU in front of a shared
+// cache. Writes go to the shared cache and are announced to every replica,
+// which drops its local copy, so a replica serves a stale notification at
+// most for the local TTL even if an invalidation is lost.
+package tiered
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/cache/lru"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/go-redis/redis/v8"
+)
+
+// stats is published at /debug/vars as "cache".
+var stats = expvar.NewMap("cache")
+
+// Invalidator broadcasts ids of changed notifications to all replicas,
+// including the one that published them.
+type Invalidator interface {
+	PublishInvalidation(id int) error
+	SubscribeInvalidations(ctx context.Context, handle func(id int)) error
+}
+
+type Cache struct {
+	local       *lru.Cache
+	remote      service.Cache
+	invalidator Invalidator
+}
+
+func New(remote service.Cache, invalidator Invalidator, local *lru.Cache) *Cache {
+	return &Cache{
+		local:       local,
+		remote:      remote,
+		invalidator: invalidator,
+	}
+}
+
+// Start subscribes to invalidations from other replicas until ctx is done.
+func (c *Cache) Start(ctx context.Context) error {
+	return c.invalidator.SubscribeInvalidations(ctx, func(id int) {
+		stats.Add("invalidations", 1)
+		c.local.Delete(id)
+	})
+}
+
+func (c *Cache) GetNotification(id int) (*model.Notification, error) {
+	if notification, ok := c.local.Get(id); ok {
+		stats.Add("local_hits", 1)
+		if notification == nil {
+			return nil, repository.ErrNoSuchNotification
+		}
+
+		copied := *notification
+		return &copied, nil
+	}
+	stats.Add("local_misses", 1)
+
+	notification, err := c.remote.GetNotification(id)
+	switch {
+	case err == nil:
+		stats.Add("remote_hits", 1)
+		copied := *notification
+		c.local.Set(id, &copied)
+	case errors.Is(err, repository.ErrNoSuchNotification):
+		stats.Add("remote_hits", 1)
+		c.local.Set(id, nil)
+	case err == redis.Nil:
+		stats.Add("remote_misses", 1)
+	}
+
+	return notification, err
+}
+
+func (c *Cache) SetNotification(notification model.Notification) error {
+	if err := c.remote.SetNotification(notification); err != nil {
+		return err
+	}
+
+	return c.invalidate(notification.Id)
+}
+
+// SetNotifications is used for warmup and does not broadcast: the local
+// tiers of other replicas expire on their own soon enough.
+func (c *Cache) SetNotifications(notifications []model.Notification) error {
+	if err := c.remote.SetNotifications(notifications); err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		c.local.Delete(notification.Id)
+	}
+	return nil
+}
+
+// SetMissing only reaches the shared cache, which may keep a notification
+// created in the meantime; the local tier learns the result on the next read.
+func (c *Cache) SetMissing(id int) error {
+	return c.remote.SetMissing(id)
+}
+
+func (c *Cache) DeleteNotification(id int) error {
+	if err := c.remote.DeleteNotification(id); err != nil {
+		return err
+	}
+
+	return c.invalidate(id)
+}
+
+func (c *Cache) invalidate(id int) error {
+	c.local.Delete(id)
+
+	if err := c.invalidator.PublishInvalidation(id); err != nil {
+		return fmt.Errorf("could not publish cache invalidation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package tiered
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/cache/lru"
+	"github.com/Komilov31/delayed-notifier/internal/memory"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bus delivers invalidations to every subscriber synchronously, standing in
+// for redis pub/sub between replicas.
+type bus struct {
+	mu          sync.Mutex
+	subscribers []func(int)
+}
+
+func (b *bus) PublishInvalidation(id int) error {
+	b.mu.Lock()
+	subscribers := append([]func(int){}, b.subscribers...)
+	b.mu.Unlock()
+
+	for _, handle := range subscribers {
+		handle(id)
+	}
+	return nil
+}
+
+func (b *bus) SubscribeInvalidations(ctx context.Context, handle func(int)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handle)
+	return nil
+}
+
+func newReplica(t *testing.T, remote *memory.Cache, b *bus) *Cache {
+	t.Helper()
+
+	c := New(remote, b, lru.New(100, time.Minute))
+	require.NoError(t, c.Start(context.Background()))
+	return c
+}
+
+func stat(name string) int64 {
+	if v, ok := stats.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestCache_ReadsThroughTiers(t *testing.T) {
+	remote := memory.NewCache()
+	c := newReplica(t, remote, &bus{})
+
+	localHits, remoteHits, remoteMisses := stat("local_hits"), stat("remote_hits"), stat("remote_misses")
+
+	_, err := c.GetNotification(1)
+	assert.ErrorIs(t, err, redis.Nil)
+
+	require.NoError(t, remote.SetNotification(model.Notification{Id: 1, Status: "active", Version: 1}))
+
+	got, err := c.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "active", got.Status)
+
+	got, err = c.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "active", got.Status)
+
+	assert.Equal(t, int64(1), stat("remote_misses")-remoteMisses)
+	assert.Equal(t, int64(1), stat("remote_hits")-remoteHits)
+	assert.Equal(t, int64(1), stat("local_hits")-localHits)
+}
+
+func TestCache_InvalidatesOtherReplicas(t *testing.T) {
+	remote := memory.NewCache()
+	b := &bus{}
+	first := newReplica(t, remote, b)
+	second := newReplica(t, remote, b)
+
+	require.NoError(t, first.SetNotification(model.Notification{Id: 1, Status: "active", Version: 1}))
+
+	got, err := second.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "active", got.Status)
+
+	require.NoError(t, first.SetNotification(model.Notification{Id: 1, Status: "canceled", Version: 2}))
+
+	got, err = second.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", got.Status, "the local copy of another replica must be dropped")
+
+	require.NoError(t, first.DeleteNotification(1))
+	_, err = second.GetNotification(1)
+	assert.ErrorIs(t, err, redis.Nil)
+}
+
+func TestCache_Missing(t *testing.T) {
+	remote := memory.NewCache()
+	c := newReplica(t, remote, &bus{})
+
+	require.NoError(t, c.SetMissing(1))
+
+	_, err := c.GetNotification(1)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+
+	localHits := stat("local_hits")
+	_, err = c.GetNotification(1)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+	assert.Equal(t, int64(1), stat("local_hits")-localHits)
+}
+
+func TestCache_ReturnsCopies(t *testing.T) {
+	remote := memory.NewCache()
+	c := newReplica(t, remote, &bus{})
+
+	require.NoError(t, c.SetNotification(model.Notification{Id: 1, Status: "active", Version: 1}))
+
+	got, err := c.GetNotification(1)
+	require.NoError(t, err)
+	got.Status = "mutated"
+
+	got, err = c.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "active", got.Status)
+}
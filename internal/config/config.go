@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 
@@ -23,13 +25,18 @@ func initConfig(path string) *Config {
 		log.Fatal("could not parse config file: ", err)
 	}
 
+	// .env is optional, e.g. when running with in-memory backends.
 	err = godotenv.Load(".env")
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatal("could not load .env file: ", err)
 	}
 
 	value, _ := os.LookupEnv("DB_PASSWORD")
 	cfg.Postgres.Password = value
 
+	cfg.Channels.Email.Password = os.Getenv("SMTP_PASSWORD")
+	cfg.Channels.SMS.Token = os.Getenv("SMS_TOKEN")
+	cfg.Unsubscribe.Secret = os.Getenv("UNSUBSCRIBE_SECRET")
+
 	return &cfg
 }
@@ -1,10 +1,22 @@
 package config
 
 type Config struct {
-	Postgres   PostgresConfig   `mapstructure:"postgres"`
-	HttpServer HttpServerConfig `mapstructure:"http_server"`
-	Redis      RedisConfig      `mapstructure:"redis"`
-	RabbitMq   RabbitMqConfig   `mapstructure:"rabbitmq"`
+	Backend     BackendConfig     `mapstructure:"backend"`
+	Postgres    PostgresConfig    `mapstructure:"postgres"`
+	SQLite      SQLiteConfig      `mapstructure:"sqlite"`
+	HttpServer  HttpServerConfig  `mapstructure:"http_server"`
+	GrpcServer  GrpcServerConfig  `mapstructure:"grpc_server"`
+	AdminServer AdminServerConfig `mapstructure:"admin_server"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	RabbitMq    RabbitMqConfig    `mapstructure:"rabbitmq"`
+	Consumer    ConsumerConfig    `mapstructure:"consumer"`
+	Telegram    TelegramConfig    `mapstructure:"telegram"`
+	Channels    ChannelsConfig    `mapstructure:"channels"`
+	Unsubscribe UnsubscribeConfig `mapstructure:"unsubscribe"`
+	Collapse    CollapseConfig    `mapstructure:"collapse"`
+	CatchUp     CatchUpConfig     `mapstructure:"catchup"`
+	Retention   RetentionConfig   `mapstructure:"retention"`
 }
 
 type PostgresConfig struct {
@@ -15,19 +27,143 @@ type PostgresConfig struct {
 	Password string `mapstructure:"password"`
 }
 
+type SQLiteConfig struct {
+	Path string `mapstructure:"path"`
+}
+
 type HttpServerConfig struct {
 	Address     string `mapstructure:"address"`
 	Timeout     int    `mapstructure:"timeout"`
 	IdleTimeout int    `mapstructure:"idle_timeout"`
 }
 
+// GrpcServerConfig holds the address of the gRPC API, empty disables it.
+type GrpcServerConfig struct {
+	Address string `mapstructure:"address"`
+}
+
+// AdminServerConfig holds the address of the admin listener, which serves
+// the routes API clients must not reach, empty disables it. It is meant to
+// be reachable from the internal network only.
+type AdminServerConfig struct {
+	Address string `mapstructure:"address"`
+}
+
 type RedisConfig struct {
 	Host     string `mapstructure:"host"`
 	Port     string `mapstructure:"port"`
 	Password string `mapstructure:"password"`
 }
 
+// CacheConfig holds the notification cache settings. LocalSize entries are
+// kept in process for LocalTTL in front of redis, zero disables the local
+// tier. The reconciler compares ReconcileBatch notifications with the cache
+// every ReconcileInterval, the warmup reads as many at a time. Intervals are
+// in seconds.
+type CacheConfig struct {
+	ReconcileInterval int `mapstructure:"reconcile_interval"`
+	ReconcileBatch    int `mapstructure:"reconcile_batch"`
+	LocalSize         int `mapstructure:"local_size"`
+	LocalTTL          int `mapstructure:"local_ttl"`
+}
+
 type RabbitMqConfig struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
 }
+
+type ConsumerConfig struct {
+	Workers     int `mapstructure:"workers"`
+	Concurrency int `mapstructure:"concurrency"`
+	Prefetch    int `mapstructure:"prefetch"`
+}
+
+type TelegramConfig struct {
+	APIURL         string `mapstructure:"api_url"`
+	ReceiveUpdates bool   `mapstructure:"receive_updates"`
+}
+
+// ChannelsConfig configures the senders of contact channels other than
+// telegram, a channel without a sender is skipped in fallback chains.
+// Timeouts are in seconds.
+type ChannelsConfig struct {
+	Email   EmailConfig   `mapstructure:"email"`
+	SMS     SMSConfig     `mapstructure:"sms"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+}
+
+// EmailConfig enables email when SMTPAddress is set. The password is read
+// from SMTP_PASSWORD.
+type EmailConfig struct {
+	SMTPAddress string `mapstructure:"smtp_address"`
+	From        string `mapstructure:"from"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"-"`
+}
+
+// SMSConfig enables phone contacts when GatewayURL is set. The token is
+// read from SMS_TOKEN.
+type SMSConfig struct {
+	GatewayURL string `mapstructure:"gateway_url"`
+	Token      string `mapstructure:"-"`
+	Timeout    int    `mapstructure:"timeout"`
+}
+
+type WebhookConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Timeout int  `mapstructure:"timeout"`
+}
+
+// UnsubscribeConfig enables unsubscribe links when the secret is set. The
+// secret signs the links and is read from UNSUBSCRIBE_SECRET, BaseURL is the
+// public url of /api/v1/unsubscribe.
+type UnsubscribeConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+	Secret  string `mapstructure:"-"`
+}
+
+// CollapseConfig holds the policy applied to notifications created with the
+// collapse key of an active one: "keep_first", "keep_last" or "merge".
+type CollapseConfig struct {
+	Policy string `mapstructure:"policy"`
+}
+
+// CatchUpConfig holds the policy applied to notifications the scheduler
+// missed, e.g. during downtime: "send_all", "latest", "drop_older" or
+// "spread". A notification is missed when it is claimed more than
+// OverdueAfter after its send time. MaxAge applies to "drop_older" and
+// Window to "spread". Durations are in seconds.
+type CatchUpConfig struct {
+	Policy       string `mapstructure:"policy"`
+	OverdueAfter int    `mapstructure:"overdue_after"`
+	MaxAge       int    `mapstructure:"max_age"`
+	Window       int    `mapstructure:"window"`
+}
+
+// RetentionConfig configures the janitor that enforces the policies every
+// Interval seconds, BatchSize notifications at a time. Policies are matched
+// by tenant, the one without a tenant applies to the rest.
+type RetentionConfig struct {
+	Interval  int                     `mapstructure:"interval"`
+	BatchSize int                     `mapstructure:"batch_size"`
+	Policies  []RetentionPolicyConfig `mapstructure:"policies"`
+}
+
+// RetentionPolicyConfig deletes finished notifications PurgeAfter seconds
+// after their send time and clears their text AnonymizeAfter seconds after
+// it, zero keeps them. Statuses default to all finished ones.
+type RetentionPolicyConfig struct {
+	Tenant         string   `mapstructure:"tenant"`
+	Statuses       []string `mapstructure:"statuses"`
+	PurgeAfter     int      `mapstructure:"purge_after"`
+	AnonymizeAfter int      `mapstructure:"anonymize_after"`
+}
+
+// BackendConfig selects implementations of the storage ("postgres", "sqlite"
+// or "memory"), the cache ("redis" or "memory") and the queue ("rabbitmq" or
+// "memory").
+type BackendConfig struct {
+	Storage string `mapstructure:"storage"`
+	Cache   string `mapstructure:"cache"`
+	Queue   string `mapstructure:"queue"`
+}
@@ -1,17 +1,370 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
 
 type NotificationStatus struct {
 	Id     int    `json:"id"`
 	Status string `json:"status"`
 }
 
-type NotificationDTO struct {
-	Id         int       `json:"id"`
-	Text       string    `json:"text"`
-	Status     string    `json:"status"`
-	TelegramId int       `json:"telegram_id"`
-	SendAt     time.Time `json:"send_at"`
-	CreatedAt  time.Time `json:"created_at"`
+// CreateNotificationRequest is the payload of a new notification. The
+// recipient is either TelegramId, Username, RecipientId or GroupId.
+// Channels is the fallback chain of a recipient and CollapseKey collapses
+// repeated notifications, see model.Notification. ExpiresAt, or MaxLateness
+// seconds after SendAt, is when the notification is too late to be sent.
+type CreateNotificationRequest struct {
+	Text        string                 `json:"text"`
+	TelegramId  int                    `json:"telegram_id,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	RecipientId int                    `json:"recipient_id,omitempty"`
+	GroupId     int                    `json:"group_id,omitempty"`
+	Channels    []string               `json:"channels,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	CollapseKey string                 `json:"collapse_key,omitempty"`
+	SendAt      time.Time              `json:"send_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	MaxLateness int                    `json:"max_lateness,omitempty"`
+	Options     *model.TelegramOptions `json:"options,omitempty"`
+}
+
+// Expiry returns when the notification expires in unix millis, 0 if it
+// does not.
+func (n CreateNotificationRequest) Expiry() int {
+	switch {
+	case n.ExpiresAt != nil:
+		return int(n.ExpiresAt.UnixMilli())
+	case n.MaxLateness > 0:
+		return int(n.SendAt.Add(time.Duration(n.MaxLateness) * time.Second).UnixMilli())
+	}
+	return 0
+}
+
+// NotificationResponse is a notification as returned by the REST API, with
+// send_at as a timestamp instead of the stored milliseconds. Deliveries is
+// set for a single group notification only.
+type NotificationResponse struct {
+	Id          int                    `json:"id"`
+	Text        string                 `json:"text"`
+	Status      string                 `json:"status"`
+	TelegramId  int                    `json:"telegram_id"`
+	RecipientId int                    `json:"recipient_id,omitempty"`
+	GroupId     int                    `json:"group_id,omitempty"`
+	ParentId    int                    `json:"parent_id,omitempty"`
+	Channels    []string               `json:"channels,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	CollapseKey string                 `json:"collapse_key,omitempty"`
+	DigestId    int                    `json:"digest_id,omitempty"`
+	FollowUpOf  int                    `json:"follow_up_of,omitempty"`
+	SendAt      time.Time              `json:"send_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Options     *model.TelegramOptions `json:"options,omitempty"`
+	Version     int                    `json:"version"`
+	Deliveries  *model.GroupDeliveries `json:"deliveries,omitempty"`
+}
+
+func NewNotificationResponse(notification model.Notification) NotificationResponse {
+	response := NotificationResponse{
+		Id:          notification.Id,
+		Text:        notification.Text,
+		Status:      notification.Status,
+		TelegramId:  notification.TelegramId,
+		RecipientId: notification.RecipientId,
+		GroupId:     notification.GroupId,
+		ParentId:    notification.ParentId,
+		Channels:    notification.Channels,
+		Category:    notification.Category,
+		Tenant:      notification.Tenant,
+		CollapseKey: notification.CollapseKey,
+		DigestId:    notification.DigestId,
+		FollowUpOf:  notification.FollowUpOf,
+		SendAt:      time.UnixMilli(int64(notification.SendAt)).UTC(),
+		CreatedAt:   notification.CreatedAt,
+		Options:     notification.Options,
+		Version:     notification.Version,
+	}
+	if notification.ExpiresAt != 0 {
+		expiresAt := time.UnixMilli(int64(notification.ExpiresAt)).UTC()
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+// FollowUpRequest schedules a follow-up of a notification Delay seconds
+// from now or at SendAt.
+type FollowUpRequest struct {
+	Delay  int        `json:"delay,omitempty"`
+	SendAt *time.Time `json:"send_at,omitempty"`
+}
+
+// Time returns when the follow-up is sent, now if neither field is set.
+func (r FollowUpRequest) Time(now time.Time) time.Time {
+	if r.SendAt != nil {
+		return *r.SendAt
+	}
+	return now.Add(time.Duration(r.Delay) * time.Second)
+}
+
+type EventResponse struct {
+	Type         string               `json:"type"`
+	Notification NotificationResponse `json:"notification"`
+	Time         time.Time            `json:"time"`
+}
+
+func NewEventResponse(event model.Event) EventResponse {
+	return EventResponse{
+		Type:         event.Type,
+		Notification: NewNotificationResponse(event.Notification),
+		Time:         event.Time,
+	}
+}
+
+// ContactRequest is a contact to add to a recipient.
+type ContactRequest struct {
+	Channel string `json:"channel"`
+	Address string `json:"address"`
+}
+
+func (c ContactRequest) Model() model.Contact {
+	return model.Contact{Channel: c.Channel, Address: c.Address}
+}
+
+// CreateRecipientRequest is the payload of a new recipient, contacts are
+// listed most preferred first.
+type CreateRecipientRequest struct {
+	Name     string           `json:"name"`
+	Contacts []ContactRequest `json:"contacts,omitempty"`
+}
+
+func (r CreateRecipientRequest) Model() model.Recipient {
+	recipient := model.Recipient{Name: r.Name}
+	for _, contact := range r.Contacts {
+		recipient.Contacts = append(recipient.Contacts, contact.Model())
+	}
+	return recipient
+}
+
+type UpdateRecipientRequest struct {
+	Name string `json:"name"`
+}
+
+type VerifyContactRequest struct {
+	Code string `json:"code"`
+}
+
+// ReorderContactsRequest lists every contact of a recipient, most
+// preferred first.
+type ReorderContactsRequest struct {
+	ContactIds []int `json:"contact_ids"`
+}
+
+type RecipientResponse struct {
+	Id        int               `json:"id"`
+	Name      string            `json:"name"`
+	Contacts  []ContactResponse `json:"contacts"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func NewRecipientResponse(recipient model.Recipient) RecipientResponse {
+	contacts := make([]ContactResponse, 0, len(recipient.Contacts))
+	for _, contact := range recipient.Contacts {
+		contacts = append(contacts, NewContactResponse(contact))
+	}
+
+	return RecipientResponse{
+		Id:        recipient.Id,
+		Name:      recipient.Name,
+		Contacts:  contacts,
+		CreatedAt: recipient.CreatedAt,
+	}
+}
+
+// ContactResponse is a contact without its verification code.
+type ContactResponse struct {
+	Id        int       `json:"id"`
+	Channel   string    `json:"channel"`
+	Address   string    `json:"address"`
+	Position  int       `json:"position"`
+	Verified  bool      `json:"verified"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewContactResponse(contact model.Contact) ContactResponse {
+	return ContactResponse{
+		Id:        contact.Id,
+		Channel:   contact.Channel,
+		Address:   contact.Address,
+		Position:  contact.Position,
+		Verified:  contact.Verified,
+		CreatedAt: contact.CreatedAt,
+	}
+}
+
+// DeliveryAttemptResponse is an entry of the delivery history of a
+// notification.
+type DeliveryAttemptResponse struct {
+	Channel   string    `json:"channel"`
+	Address   string    `json:"address"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewDeliveryAttemptResponse(attempt model.DeliveryAttempt) DeliveryAttemptResponse {
+	return DeliveryAttemptResponse{
+		Channel:   attempt.Channel,
+		Address:   attempt.Address,
+		Status:    attempt.Status,
+		Error:     attempt.Error,
+		CreatedAt: attempt.CreatedAt,
+	}
+}
+
+// PreferencesRequest replaces the preferences of a recipient.
+type PreferencesRequest struct {
+	Unsubscribed     bool     `json:"unsubscribed"`
+	MutedCategories  []string `json:"muted_categories"`
+	OptedOutChannels []string `json:"opted_out_channels"`
+	DigestWindow     int      `json:"digest_window"`
+	DigestCategories []string `json:"digest_categories"`
+}
+
+func (p PreferencesRequest) Model(recipientId int) model.Preferences {
+	preferences := model.Preferences{
+		RecipientId:      recipientId,
+		Unsubscribed:     p.Unsubscribed,
+		MutedCategories:  p.MutedCategories,
+		OptedOutChannels: p.OptedOutChannels,
+		DigestWindow:     p.DigestWindow,
+		DigestCategories: p.DigestCategories,
+	}
+	if preferences.MutedCategories == nil {
+		preferences.MutedCategories = []string{}
+	}
+	if preferences.OptedOutChannels == nil {
+		preferences.OptedOutChannels = []string{}
+	}
+	if preferences.DigestCategories == nil {
+		preferences.DigestCategories = []string{}
+	}
+	return preferences
+}
+
+type PreferencesResponse struct {
+	RecipientId      int      `json:"recipient_id"`
+	Unsubscribed     bool     `json:"unsubscribed"`
+	MutedCategories  []string `json:"muted_categories"`
+	OptedOutChannels []string `json:"opted_out_channels"`
+	DigestWindow     int      `json:"digest_window"`
+	DigestCategories []string `json:"digest_categories"`
+}
+
+func NewPreferencesResponse(preferences model.Preferences) PreferencesResponse {
+	response := PreferencesResponse(preferences)
+	if response.MutedCategories == nil {
+		response.MutedCategories = []string{}
+	}
+	if response.OptedOutChannels == nil {
+		response.OptedOutChannels = []string{}
+	}
+	if response.DigestCategories == nil {
+		response.DigestCategories = []string{}
+	}
+	return response
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name"`
+}
+
+// AddGroupMemberRequest subscribes a recipient to a group.
+type AddGroupMemberRequest struct {
+	RecipientId int `json:"recipient_id"`
+}
+
+type GroupResponse struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Members   []int     `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewGroupResponse(group model.Group) GroupResponse {
+	members := group.Members
+	if members == nil {
+		members = []int{}
+	}
+
+	return GroupResponse{
+		Id:        group.Id,
+		Name:      group.Name,
+		Members:   members,
+		CreatedAt: group.CreatedAt,
+	}
+}
+
+// GroupDeliveriesResponse is the summary of the deliveries of a group
+// notification followed by the deliveries themselves.
+type GroupDeliveriesResponse struct {
+	model.GroupDeliveries
+	Deliveries []NotificationResponse `json:"deliveries"`
+}
+
+func NewGroupDeliveriesResponse(stats model.GroupDeliveries, deliveries []model.Notification) GroupDeliveriesResponse {
+	response := GroupDeliveriesResponse{
+		GroupDeliveries: stats,
+		Deliveries:      make([]NotificationResponse, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, NewNotificationResponse(delivery))
+	}
+	return response
+}
+
+type WorkerPoolStats struct {
+	Workers     int     `json:"workers"`
+	Concurrency int     `json:"concurrency"`
+	Prefetch    int     `json:"prefetch"`
+	Busy        int     `json:"busy"`
+	Utilization float64 `json:"utilization"`
+	Processed   uint64  `json:"processed"`
+}
+
+// CatchUpReport describes what the last catch-up of missed notifications
+// did, see model.CatchUpSendAll. OldestDueAt is the send time of the most
+// overdue one.
+type CatchUpReport struct {
+	Policy      string    `json:"policy"`
+	StartedAt   time.Time `json:"started_at"`
+	OldestDueAt time.Time `json:"oldest_due_at"`
+	Missed      int       `json:"missed"`
+	Sent        int       `json:"sent"`
+	Expired     int       `json:"expired"`
+	Rescheduled int       `json:"rescheduled"`
+}
+
+type WorkerPoolUpdate struct {
+	Workers     *int `json:"workers,omitempty"`
+	Concurrency *int `json:"concurrency,omitempty"`
+	Prefetch    *int `json:"prefetch,omitempty"`
+}
+
+// Problem is an RFC 7807 error response. Code and RequestId are extension
+// members: the machine readable error code and the id of the failed request.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      apperr.Code         `json:"code"`
+	RequestId string              `json:"request_id"`
+	Errors    []apperr.FieldError `json:"errors,omitempty"`
 }
@@ -0,0 +1,201 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// MaxScheduleHorizon is how far in the future a notification may be
+// scheduled.
+const MaxScheduleHorizon = 365 * 24 * time.Hour
+
+// Validate reports every invalid field of the notification. The username,
+// the recipient and the group are only checked for presence here,
+// resolving them is up to the caller.
+func (n CreateNotificationRequest) Validate(now time.Time) []apperr.FieldError {
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(n.Text) == "" {
+		add("text", "must not be empty")
+	} else if limit := n.Options.TextLimit(); utf8.RuneCountInString(n.Text) > limit {
+		add("text", "must not be longer than %d characters", limit)
+	}
+
+	hasChat := n.TelegramId != 0 || strings.TrimSpace(n.Username) != ""
+	switch {
+	case n.RecipientId != 0 && hasChat:
+		add("recipient_id", "must not be combined with telegram_id or username")
+	case n.GroupId != 0 && (hasChat || n.RecipientId != 0):
+		add("group_id", "must not be combined with telegram_id, username or recipient_id")
+	case n.RecipientId < 0:
+		add("recipient_id", "must be positive")
+	case n.GroupId < 0:
+		add("group_id", "must be positive")
+	case n.RecipientId == 0 && n.GroupId == 0 && !hasChat:
+		add("telegram_id", "telegram_id, username, recipient_id or group_id is required")
+	}
+
+	if len(n.Channels) > 0 && n.RecipientId == 0 && n.GroupId == 0 {
+		add("channels", "requires recipient_id or group_id")
+	}
+	seen := make(map[string]bool, len(n.Channels))
+	for _, channel := range n.Channels {
+		if !model.IsChannel(channel) {
+			add("channels", "unsupported channel %q", channel)
+		} else if seen[channel] {
+			add("channels", "channel %q is listed twice", channel)
+		}
+		seen[channel] = true
+	}
+
+	if n.Category != "" && !model.IsCategory(n.Category) {
+		add("category", "must be 1 to 64 lowercase letters, digits, dashes or underscores")
+	}
+	if n.Tenant != "" && !model.IsTenant(n.Tenant) {
+		add("tenant", "must be 1 to 64 lowercase letters, digits, dashes or underscores")
+	}
+	if len(n.CollapseKey) > model.MaxCollapseKeyLength {
+		add("collapse_key", "must not be longer than %d bytes", model.MaxCollapseKeyLength)
+	}
+
+	switch {
+	case n.SendAt.IsZero():
+		add("send_at", "is required")
+	case !n.SendAt.After(now):
+		add("send_at", "must be in the future")
+	case n.SendAt.Sub(now) > MaxScheduleHorizon:
+		add("send_at", "must not be later than %d days from now", int(MaxScheduleHorizon.Hours()/24))
+	}
+
+	switch {
+	case n.ExpiresAt != nil && n.MaxLateness != 0:
+		add("expires_at", "must not be combined with max_lateness")
+	case n.ExpiresAt != nil && !n.ExpiresAt.After(n.SendAt):
+		add("expires_at", "must be after send_at")
+	case n.MaxLateness < 0:
+		add("max_lateness", "must be positive")
+	case n.MaxLateness > int(MaxScheduleHorizon/time.Second):
+		add("max_lateness", "must not be longer than %d days", int(MaxScheduleHorizon.Hours()/24))
+	}
+
+	// The text length is checked above, so the options are validated
+	// against an empty text to report only their own problems.
+	if err := n.Options.Validate(""); err != nil {
+		add("options", "%s", err.Error())
+	}
+
+	return fields
+}
+
+// Validate reports every invalid field of the follow-up. Unless required,
+// the time may be omitted to send the follow-up right away.
+func (r FollowUpRequest) Validate(now time.Time, required bool) []apperr.FieldError {
+	var fields []apperr.FieldError
+	add := func(field, format string, args ...any) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	horizon := int(MaxScheduleHorizon.Hours() / 24)
+	switch {
+	case r.Delay != 0 && r.SendAt != nil:
+		add("delay", "must not be combined with send_at")
+	case r.Delay < 0:
+		add("delay", "must be positive")
+	case r.Delay > int(MaxScheduleHorizon/time.Second):
+		add("delay", "must not be longer than %d days", horizon)
+	case r.SendAt != nil && !r.SendAt.After(now):
+		add("send_at", "must be in the future")
+	case r.SendAt != nil && r.SendAt.Sub(now) > MaxScheduleHorizon:
+		add("send_at", "must not be later than %d days from now", horizon)
+	case required && r.Delay == 0 && r.SendAt == nil:
+		add("delay", "delay or send_at is required")
+	}
+
+	return fields
+}
+
+// Validate reports every invalid field of the recipient.
+func (r CreateRecipientRequest) Validate() []apperr.FieldError {
+	fields := validateName(r.Name)
+	for i, contact := range r.Contacts {
+		if err := contact.Model().Validate(); err != nil {
+			fields = append(fields, apperr.FieldError{
+				Field:   fmt.Sprintf("contacts[%d]", i),
+				Message: err.Error(),
+			})
+		}
+	}
+	return fields
+}
+
+func (r UpdateRecipientRequest) Validate() []apperr.FieldError {
+	return validateName(r.Name)
+}
+
+func (c ContactRequest) Validate() []apperr.FieldError {
+	if err := c.Model().Validate(); err != nil {
+		return []apperr.FieldError{{Field: "address", Message: err.Error()}}
+	}
+	return nil
+}
+
+func validateName(name string) []apperr.FieldError {
+	if strings.TrimSpace(name) == "" {
+		return []apperr.FieldError{{Field: "name", Message: "must not be empty"}}
+	}
+	return nil
+}
+
+func (g CreateGroupRequest) Validate() []apperr.FieldError {
+	return validateName(g.Name)
+}
+
+func (m AddGroupMemberRequest) Validate() []apperr.FieldError {
+	if m.RecipientId <= 0 {
+		return []apperr.FieldError{{Field: "recipient_id", Message: "must be positive"}}
+	}
+	return nil
+}
+
+func (p PreferencesRequest) Validate() []apperr.FieldError {
+	var fields []apperr.FieldError
+	for _, category := range p.MutedCategories {
+		if !model.IsCategory(category) {
+			fields = append(fields, apperr.FieldError{
+				Field:   "muted_categories",
+				Message: fmt.Sprintf("invalid category %q", category),
+			})
+		}
+	}
+	for _, channel := range p.OptedOutChannels {
+		if !model.IsChannel(channel) {
+			fields = append(fields, apperr.FieldError{
+				Field:   "opted_out_channels",
+				Message: fmt.Sprintf("unsupported channel %q", channel),
+			})
+		}
+	}
+	if p.DigestWindow < 0 || p.DigestWindow > model.MaxDigestWindow {
+		fields = append(fields, apperr.FieldError{
+			Field:   "digest_window",
+			Message: fmt.Sprintf("must be between 0 and %d seconds", model.MaxDigestWindow),
+		})
+	}
+	for _, category := range p.DigestCategories {
+		if !model.IsCategory(category) {
+			fields = append(fields, apperr.FieldError{
+				Field:   "digest_categories",
+				Message: fmt.Sprintf("invalid category %q", category),
+			})
+		}
+	}
+	return fields
+}
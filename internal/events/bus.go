@@ -0,0 +1,71 @@
+// Package events delivers notification change events to subscribers such as
+// the status stream. A Bus serves a single process, a Fanout shares events
+// between replicas.
+package events
+
+import (
+	"sync"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// subscriberBuffer is how many events a subscriber may lag behind before
+// events are dropped for it. Publishers never wait for subscribers.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	filter model.EventFilter
+	events chan model.Event
+}
+
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (b *Bus) Publish(event model.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subscribers {
+		if !s.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			zlog.Logger.Warn().Msgf("dropped %s event of notification %d for a slow subscriber", event.Type, event.Notification.Id)
+		}
+	}
+}
+
+// Subscribe returns events matching filter. The returned function
+// unsubscribes and closes the channel.
+func (b *Bus) Subscribe(filter model.EventFilter) (<-chan model.Event, func()) {
+	s := &subscriber{
+		filter: filter,
+		events: make(chan model.Event, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+			close(s.events)
+		})
+	}
+}
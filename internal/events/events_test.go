@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(eventType string, id, telegramId int) model.Event {
+	return model.Event{Type: eventType, Notification: model.Notification{Id: id, TelegramId: telegramId}}
+}
+
+func TestBus_Filters(t *testing.T) {
+	bus := NewBus()
+
+	all, unsubscribeAll := bus.Subscribe(model.EventFilter{})
+	defer unsubscribeAll()
+	byId, unsubscribeById := bus.Subscribe(model.EventFilter{Id: 1})
+	defer unsubscribeById()
+	byChat, unsubscribeByChat := bus.Subscribe(model.EventFilter{TelegramId: 20})
+	defer unsubscribeByChat()
+	byRecipient, unsubscribeByRecipient := bus.Subscribe(model.EventFilter{RecipientId: 5})
+	defer unsubscribeByRecipient()
+
+	forRecipient := model.Event{Type: model.EventCreated, Notification: model.Notification{Id: 3, RecipientId: 5}}
+	bus.Publish(event(model.EventCreated, 1, 10))
+	bus.Publish(event(model.EventCreated, 2, 20))
+	bus.Publish(forRecipient)
+
+	assert.Len(t, all, 3)
+	require.Len(t, byId, 1)
+	assert.Equal(t, 1, (<-byId).Notification.Id)
+	require.Len(t, byChat, 1)
+	assert.Equal(t, 2, (<-byChat).Notification.Id)
+	require.Len(t, byRecipient, 1)
+	assert.Equal(t, 3, (<-byRecipient).Notification.Id)
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+
+	events, unsubscribe := bus.Subscribe(model.EventFilter{})
+	unsubscribe()
+	unsubscribe()
+
+	bus.Publish(event(model.EventSent, 1, 10))
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestBus_DropsForSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+
+	events, unsubscribe := bus.Subscribe(model.EventFilter{})
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bus.Publish(event(model.EventCreated, i, 10))
+	}
+
+	assert.Len(t, events, subscriberBuffer)
+}
+
+// remote loops published events back, like redis pub/sub does for the
+// publishing replica.
+type remote struct {
+	handle func(model.Event)
+	err    error
+}
+
+func (r *remote) PublishEvent(event model.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.handle(event)
+	return nil
+}
+
+func (r *remote) SubscribeEvents(ctx context.Context, handle func(model.Event)) error {
+	r.handle = handle
+	return nil
+}
+
+func TestFanout_DeliversThroughRemote(t *testing.T) {
+	r := &remote{}
+	fanout := NewFanout(r)
+	require.NoError(t, fanout.Start(context.Background()))
+
+	events, unsubscribe := fanout.Subscribe(model.EventFilter{})
+	defer unsubscribe()
+
+	fanout.Publish(event(model.EventCanceled, 1, 10))
+	require.Len(t, events, 1, "event must be delivered once")
+
+	r.err = errors.New("connection refused")
+	fanout.Publish(event(model.EventFailed, 2, 10))
+	require.Len(t, events, 2, "event must be delivered locally when the remote is down")
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// Remote carries events between replicas. Every replica, the publishing one
+// included, receives each published event.
+type Remote interface {
+	PublishEvent(model.Event) error
+	SubscribeEvents(ctx context.Context, handle func(model.Event)) error
+}
+
+// Fanout publishes events through the remote and feeds events of all
+// replicas into the local bus.
+type Fanout struct {
+	*Bus
+	remote Remote
+}
+
+func NewFanout(remote Remote) *Fanout {
+	return &Fanout{
+		Bus:    NewBus(),
+		remote: remote,
+	}
+}
+
+// Start subscribes to the remote until ctx is done.
+func (f *Fanout) Start(ctx context.Context) error {
+	return f.remote.SubscribeEvents(ctx, f.Bus.Publish)
+}
+
+// Publish falls back to local delivery if the remote is unavailable, so
+// subscribers of this replica still see the event.
+func (f *Fanout) Publish(event model.Event) {
+	if err := f.remote.PublishEvent(event); err != nil {
+		zlog.Logger.Warn().Msg("could not publish event to other replicas: " + err.Error())
+		f.Bus.Publish(event)
+	}
+}
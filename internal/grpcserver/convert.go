@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(notification *model.Notification) *notifierpb.Notification {
+	return &notifierpb.Notification{
+		Id:         int64(notification.Id),
+		Text:       notification.Text,
+		Status:     notification.Status,
+		TelegramId: int64(notification.TelegramId),
+		SendAt:     timestamppb.New(time.UnixMilli(int64(notification.SendAt))),
+		CreatedAt:  timestamppb.New(notification.CreatedAt),
+		Options:    optionsToProto(notification.Options),
+		Version:    int64(notification.Version),
+	}
+}
+
+func optionsToProto(options *model.TelegramOptions) *notifierpb.TelegramOptions {
+	if options == nil {
+		return nil
+	}
+
+	result := &notifierpb.TelegramOptions{
+		ParseMode:             options.ParseMode,
+		DisableNotification:   options.DisableNotification,
+		DisableWebPagePreview: options.DisableWebPagePreview,
+	}
+	for _, row := range options.Buttons {
+		var buttons []*notifierpb.Button
+		for _, button := range row {
+			buttons = append(buttons, &notifierpb.Button{
+				Text:         button.Text,
+				Url:          button.URL,
+				CallbackData: button.CallbackData,
+			})
+		}
+		result.Buttons = append(result.Buttons, &notifierpb.ButtonRow{Buttons: buttons})
+	}
+	if options.Attachment != nil {
+		result.Attachment = &notifierpb.Attachment{
+			Type: options.Attachment.Type,
+			Url:  options.Attachment.URL,
+		}
+	}
+
+	return result
+}
+
+func optionsFromProto(options *notifierpb.TelegramOptions) *model.TelegramOptions {
+	if options == nil {
+		return nil
+	}
+
+	result := &model.TelegramOptions{
+		ParseMode:             options.GetParseMode(),
+		DisableNotification:   options.GetDisableNotification(),
+		DisableWebPagePreview: options.GetDisableWebPagePreview(),
+	}
+	for _, row := range options.GetButtons() {
+		buttons := []model.Button{}
+		for _, button := range row.GetButtons() {
+			buttons = append(buttons, model.Button{
+				Text:         button.GetText(),
+				URL:          button.GetUrl(),
+				CallbackData: button.GetCallbackData(),
+			})
+		}
+		result.Buttons = append(result.Buttons, buttons)
+	}
+	if attachment := options.GetAttachment(); attachment != nil {
+		result.Attachment = &model.Attachment{
+			Type: attachment.GetType(),
+			URL:  attachment.GetUrl(),
+		}
+	}
+
+	return result
+}
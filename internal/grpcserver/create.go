@@ -0,0 +1,65 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"github.com/wb-go/wbf/zlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateNotification validates the request the same way as POST /notify.
+func (s *Server) CreateNotification(ctx context.Context, req *notifierpb.CreateNotificationRequest) (*notifierpb.Notification, error) {
+	var sendAt time.Time
+	if req.GetSendAt() != nil {
+		sendAt = req.GetSendAt().AsTime()
+	}
+
+	options := optionsFromProto(req.GetOptions())
+	fields := dto.CreateNotificationRequest{
+		Text:       req.GetText(),
+		TelegramId: int(req.GetTelegramId()),
+		Username:   req.GetUsername(),
+		SendAt:     sendAt,
+		Options:    options,
+	}.Validate(time.Now())
+	if len(fields) > 0 {
+		zlog.Logger.Error().Msgf("invalid payload: %v", fields)
+		return nil, invalidArgument(fields...)
+	}
+
+	telegramId := int(req.GetTelegramId())
+	if telegramId == 0 {
+		subscriber, err := s.service.GetSubscriber(req.GetUsername())
+		if errors.Is(err, repository.ErrNoSuchSubscriber) {
+			return nil, invalidArgument(apperr.FieldError{Field: "username", Message: "user has not started the bot"})
+		}
+		if err != nil {
+			zlog.Logger.Error().Msg("could not resolve username: " + err.Error())
+			return nil, status.Error(codes.Internal, "could not create notification")
+		}
+
+		telegramId = subscriber.TelegramId
+	}
+
+	notification, err := s.service.CreateNotification(model.Notification{
+		Text:       req.GetText(),
+		TelegramId: telegramId,
+		SendAt:     int(sendAt.UnixMilli()),
+		Options:    options,
+	})
+	if err != nil {
+		zlog.Logger.Error().Msg("could not create notification: " + err.Error())
+		return nil, status.Error(codes.Internal, "could not create notification")
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled CreateNotification call")
+	return toProto(notification), nil
+}
@@ -0,0 +1,46 @@
+package grpcserver
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func (s *Server) GetNotification(ctx context.Context, req *notifierpb.GetNotificationRequest) (*notifierpb.Notification, error) {
+	notification, err := s.service.GetNotification(int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "could not get notification")
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GetNotification call with id: %d", req.GetId())
+	return toProto(notification), nil
+}
+
+func (s *Server) ListNotifications(req *notifierpb.ListNotificationsRequest, stream notifierpb.NotifierService_ListNotificationsServer) error {
+	notifications, err := s.service.GetAllNotifications()
+	if err != nil {
+		return toStatus(err, "could not get notifications")
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Id < notifications[j].Id
+	})
+
+	for i := range notifications {
+		if req.GetTelegramId() != 0 && int64(notifications[i].TelegramId) != req.GetTelegramId() {
+			continue
+		}
+		if req.GetStatus() != "" && notifications[i].Status != req.GetStatus() {
+			continue
+		}
+
+		if err := stream.Send(toProto(&notifications[i])); err != nil {
+			return err
+		}
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled ListNotifications call")
+	return nil
+}
@@ -0,0 +1,77 @@
+package grpcserver
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/handler"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"github.com/wb-go/wbf/zlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements notifierpb.NotifierServiceServer on top of the same
+// service as the HTTP handlers.
+type Server struct {
+	notifierpb.UnimplementedNotifierServiceServer
+	service handler.NotifierService
+}
+
+func New(service handler.NotifierService) *Server {
+	return &Server{
+		service: service,
+	}
+}
+
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	notifierpb.RegisterNotifierServiceServer(registrar, s)
+}
+
+func invalidArgument(fields ...apperr.FieldError) error {
+	var messages []string
+	for _, field := range fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	return status.Error(codes.InvalidArgument, "invalid payload: "+strings.Join(messages, "; "))
+}
+
+// sentinels maps errors of the storage and the service to the codes they
+// are reported with, as the HTTP handlers do.
+var sentinels = []struct {
+	err  error
+	code codes.Code
+}{
+	{repository.ErrNoSuchNotification, codes.NotFound},
+	{repository.ErrNoSuchRecipient, codes.NotFound},
+	{repository.ErrNoSuchContact, codes.NotFound},
+	{repository.ErrNoSuchGroup, codes.NotFound},
+	{repository.ErrNotGroupMember, codes.NotFound},
+	{repository.ErrDuplicateContact, codes.FailedPrecondition},
+	{repository.ErrNotActive, codes.FailedPrecondition},
+	{model.ErrNotSnoozable, codes.FailedPrecondition},
+	{model.ErrInvalidContact, codes.InvalidArgument},
+	{service.ErrInvalidVerificationCode, codes.InvalidArgument},
+	{service.ErrInvalidContactOrder, codes.InvalidArgument},
+	{unsubscribe.ErrInvalidToken, codes.InvalidArgument},
+}
+
+// toStatus logs err and maps it to a grpc status, msg describes the failed
+// operation. Known errors are reported with their own message, the causes
+// of internal errors are never sent to the client.
+func toStatus(err error, msg string) error {
+	zlog.Logger.Error().Msg(msg + ": " + err.Error())
+
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel.err) {
+			return status.Error(sentinel.code, sentinel.err.Error())
+		}
+	}
+	return status.Error(codes.Internal, msg)
+}
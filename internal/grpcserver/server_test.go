@@ -0,0 +1,239 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/memory"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newClient(t *testing.T) (notifierpb.NotifierServiceClient, *service.Service) {
+	svc := service.New(memory.NewStorage(), memory.NewCache(), memory.NewQueue(), nil, service.WithPollInterval(time.Hour))
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	New(svc).Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return notifierpb.NewNotifierServiceClient(conn), svc
+}
+
+func createRequest(telegramId int64) *notifierpb.CreateNotificationRequest {
+	return &notifierpb.CreateNotificationRequest{
+		Text:       "hello",
+		TelegramId: telegramId,
+		SendAt:     timestamppb.New(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestServer_CreateAndGet(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	req := createRequest(42)
+	req.Options = &notifierpb.TelegramOptions{
+		ParseMode: "HTML",
+		Buttons: []*notifierpb.ButtonRow{
+			{Buttons: []*notifierpb.Button{{Text: "open", Url: "https://example.com"}}},
+		},
+	}
+	created, err := client.CreateNotification(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "active", created.GetStatus())
+	assert.Equal(t, int64(42), created.GetTelegramId())
+
+	got, err := client.GetNotification(ctx, &notifierpb.GetNotificationRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got.GetText())
+	assert.Equal(t, req.GetSendAt().AsTime().UnixMilli(), got.GetSendAt().AsTime().UnixMilli())
+	assert.Equal(t, "https://example.com", got.GetOptions().GetButtons()[0].GetButtons()[0].GetUrl())
+}
+
+func TestServer_CreateByUsername(t *testing.T) {
+	client, svc := newClient(t)
+	require.NoError(t, svc.RegisterSubscriber("alice", 7))
+
+	req := createRequest(0)
+	req.Username = "@Alice"
+	created, err := client.CreateNotification(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.GetTelegramId())
+}
+
+func TestServer_CreateInvalid(t *testing.T) {
+	client, _ := newClient(t)
+
+	past := createRequest(1)
+	past.SendAt = timestamppb.New(time.Now().Add(-time.Hour))
+
+	noRecipient := createRequest(0)
+
+	unknownUser := createRequest(0)
+	unknownUser.Username = "bob"
+
+	badOptions := createRequest(1)
+	badOptions.Options = &notifierpb.TelegramOptions{ParseMode: "Markdown"}
+
+	for name, req := range map[string]*notifierpb.CreateNotificationRequest{
+		"time in the past": past,
+		"no recipient":     noRecipient,
+		"unknown username": unknownUser,
+		"invalid options":  badOptions,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.CreateNotification(context.Background(), req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestServer_NotFound(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetNotification(ctx, &notifierpb.GetNotificationRequest{Id: 100})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CancelNotification(ctx, &notifierpb.CancelNotificationRequest{Id: 100})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_CancelAndUpdate(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateNotification(ctx, createRequest(1))
+	require.NoError(t, err)
+
+	canceled, err := client.CancelNotification(ctx, &notifierpb.CancelNotificationRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", canceled.GetStatus())
+	assert.Greater(t, canceled.GetVersion(), created.GetVersion())
+
+	_, err = client.UpdateNotification(ctx, &notifierpb.UpdateNotificationRequest{Id: created.GetId(), Status: "sent"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// A finished notification is not made active again.
+	_, err = client.UpdateNotification(ctx, &notifierpb.UpdateNotificationRequest{Id: created.GetId(), Status: "active"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	got, err := client.GetNotification(ctx, &notifierpb.GetNotificationRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", got.GetStatus())
+}
+
+func TestServer_UpdateOnlyCancels(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateNotification(ctx, createRequest(1))
+	require.NoError(t, err)
+
+	for _, newStatus := range []string{"active", "completed", "failed", "expired"} {
+		_, err := client.UpdateNotification(ctx, &notifierpb.UpdateNotificationRequest{Id: created.GetId(), Status: newStatus})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), newStatus)
+	}
+
+	updated, err := client.UpdateNotification(ctx, &notifierpb.UpdateNotificationRequest{Id: created.GetId(), Status: "canceled"})
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", updated.GetStatus())
+
+	_, err = client.UpdateNotification(ctx, &notifierpb.UpdateNotificationRequest{Id: created.GetId(), Status: "canceled"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServer_CancelFinished(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateNotification(ctx, createRequest(1))
+	require.NoError(t, err)
+	_, err = client.CancelNotification(ctx, &notifierpb.CancelNotificationRequest{Id: created.GetId()})
+	require.NoError(t, err)
+
+	_, err = client.CancelNotification(ctx, &notifierpb.CancelNotificationRequest{Id: created.GetId()})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"not found", fmt.Errorf("could not get notification: %w", repository.ErrNoSuchNotification), codes.NotFound, repository.ErrNoSuchNotification.Error()},
+		{"not active", repository.ErrNotActive, codes.FailedPrecondition, repository.ErrNotActive.Error()},
+		{"invalid token", unsubscribe.ErrInvalidToken, codes.InvalidArgument, unsubscribe.ErrInvalidToken.Error()},
+		{"internal", errors.New("dial tcp 10.0.0.5:5432: connection refused"), codes.Internal, "could not get notification"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(toStatus(tt.err, "could not get notification"))
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
+
+func TestServer_ListNotifications(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	var ids []int64
+	for _, telegramId := range []int64{1, 2, 1} {
+		created, err := client.CreateNotification(ctx, createRequest(telegramId))
+		require.NoError(t, err)
+		ids = append(ids, created.GetId())
+	}
+	_, err := client.CancelNotification(ctx, &notifierpb.CancelNotificationRequest{Id: ids[2]})
+	require.NoError(t, err)
+
+	list := func(req *notifierpb.ListNotificationsRequest) []int64 {
+		stream, err := client.ListNotifications(ctx, req)
+		require.NoError(t, err)
+
+		var result []int64
+		for {
+			notification, err := stream.Recv()
+			if err == io.EOF {
+				return result
+			}
+			require.NoError(t, err)
+			result = append(result, notification.GetId())
+		}
+	}
+
+	assert.Equal(t, ids, list(&notifierpb.ListNotificationsRequest{}))
+	assert.Equal(t, []int64{ids[0], ids[2]}, list(&notifierpb.ListNotificationsRequest{TelegramId: 1}))
+	assert.Equal(t, []int64{ids[0]}, list(&notifierpb.ListNotificationsRequest{TelegramId: 1, Status: "active"}))
+}
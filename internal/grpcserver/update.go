@@ -0,0 +1,52 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/pkg/notifierpb"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// statuses are the statuses of notifications, true for the ones
+// UpdateNotification can set. The others are set by delivery, so a client
+// can neither complete a notification nor make a finished one active.
+var statuses = map[string]bool{
+	"active":    false,
+	"canceled":  true,
+	"completed": false,
+	"failed":    false,
+	"expired":   false,
+}
+
+func (s *Server) CancelNotification(ctx context.Context, req *notifierpb.CancelNotificationRequest) (*notifierpb.Notification, error) {
+	return s.updateStatus(req.GetId(), "canceled")
+}
+
+func (s *Server) UpdateNotification(ctx context.Context, req *notifierpb.UpdateNotificationRequest) (*notifierpb.Notification, error) {
+	settable, known := statuses[req.GetStatus()]
+	if !known {
+		zlog.Logger.Error().Msg("invalid payload: unknown status " + req.GetStatus())
+		return nil, invalidArgument(apperr.FieldError{Field: "status", Message: "must be one of active, canceled, completed, failed or expired"})
+	}
+	if !settable {
+		zlog.Logger.Error().Msg("invalid payload: status can not be set " + req.GetStatus())
+		return nil, invalidArgument(apperr.FieldError{Field: "status", Message: "only canceled can be set"})
+	}
+
+	return s.updateStatus(req.GetId(), req.GetStatus())
+}
+
+func (s *Server) updateStatus(id int64, newStatus string) (*notifierpb.Notification, error) {
+	if err := s.service.UpdateNotificationStatus(int(id), newStatus); err != nil {
+		return nil, toStatus(err, "could not update notification status")
+	}
+
+	notification, err := s.service.GetNotification(int(id))
+	if err != nil {
+		return nil, toStatus(err, "could not get notification")
+	}
+
+	zlog.Logger.Info().Msgf("successfully updated status of notification %d to %s", id, newStatus)
+	return toProto(notification), nil
+}
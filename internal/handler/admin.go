@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetWorkerPool serves GET /admin/workers on the admin listener, see
+// getWorkerPool in api/openapi.yaml.
+func (h *Handler) GetWorkerPool(c *ginext.Context) {
+	zlog.Logger.Info().Msg("successfully handled GET request for getting worker pool stats")
+	c.JSON(http.StatusOK, h.service.GetWorkerPoolStats())
+}
+
+// UpdateWorkerPool serves PUT /admin/workers on the admin listener, see
+// updateWorkerPool in api/openapi.yaml.
+func (h *Handler) UpdateWorkerPool(c *ginext.Context) {
+	var update dto.WorkerPoolUpdate
+	if !bindJSON(c, &update) {
+		return
+	}
+
+	stats, err := h.service.UpdateWorkerPool(update)
+	if errors.Is(err, service.ErrInvalidPoolConfig) {
+		abort(c, apperr.Validation(err.Error()))
+		return
+	}
+	if err != nil {
+		abort(c, apperr.Internal("could not update worker pool", err))
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled PUT request for updating worker pool")
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetCatchUpReport serves GET /admin/catchup on the admin listener, see
+// getCatchUpReport in api/openapi.yaml.
+func (h *Handler) GetCatchUpReport(c *ginext.Context) {
+	report := h.service.GetCatchUpReport()
+	if report == nil {
+		abort(c, apperr.NotFound("no missed notifications were caught up since the start"))
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request for getting catch-up report")
+	c.JSON(http.StatusOK, report)
+}
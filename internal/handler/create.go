@@ -1,60 +1,94 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
 	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
 	"github.com/gin-gonic/gin"
-	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
 
-// CreateNotification godoc
-// @Summary Create a new notification
-// @Description Create a new delayed notification with text and send time
-// @Tags notifications
-// @Accept json
-// @Produce json
-// @Param notification body dto.NotificationDTO true "Notification payload"
-// @Success 200 {object} model.Notification
-// @Failure 400 {object} ginext.H "Invalid payload or time in the past"
-// @Failure 500 {object} ginext.H "Could not create notification"
-// @Router /notify [post]
+// CreateNotification serves POST /api/v1/notifications, see
+// createNotification in api/openapi.yaml.
 func (h *Handler) CreateNotification(c *gin.Context) {
-	var notific dto.NotificationDTO
-	if err := c.BindJSON(&notific); err != nil {
-		zlog.Logger.Error().Msg("could not parse payload: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{
-			"error": "invalid payload",
-		})
+	var notific dto.CreateNotificationRequest
+	if !bindJSON(c, &notific) {
 		return
 	}
 
-	if time.Until(notific.SendAt) <= 0 {
-		zlog.Logger.Error().Msg("invalid payload: time is in the past")
-		c.JSON(http.StatusBadRequest, ginext.H{
-			"error": "invalid payload: time should be in future",
-		})
+	if fields := notific.Validate(time.Now()); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
 		return
 	}
 
+	if notific.RecipientId != 0 {
+		_, err := h.service.GetRecipient(notific.RecipientId)
+		if errors.Is(err, repository.ErrNoSuchRecipient) {
+			abort(c, apperr.Validation("invalid payload", apperr.FieldError{
+				Field:   "recipient_id",
+				Message: "there is no recipient with such id",
+			}))
+			return
+		}
+		if err != nil {
+			abort(c, apperr.Internal("could not create notification", err))
+			return
+		}
+	} else if notific.GroupId != 0 {
+		_, err := h.service.GetGroup(notific.GroupId)
+		if errors.Is(err, repository.ErrNoSuchGroup) {
+			abort(c, apperr.Validation("invalid payload", apperr.FieldError{
+				Field:   "group_id",
+				Message: "there is no group with such id",
+			}))
+			return
+		}
+		if err != nil {
+			abort(c, apperr.Internal("could not create notification", err))
+			return
+		}
+	} else if notific.TelegramId == 0 {
+		subscriber, err := h.service.GetSubscriber(notific.Username)
+		if errors.Is(err, repository.ErrNoSuchSubscriber) {
+			abort(c, apperr.Validation("invalid payload", apperr.FieldError{
+				Field:   "username",
+				Message: "user has not started the bot",
+			}))
+			return
+		}
+		if err != nil {
+			abort(c, apperr.Internal("could not create notification", err))
+			return
+		}
+
+		notific.TelegramId = subscriber.TelegramId
+	}
+
 	notification := &model.Notification{
-		Text:       notific.Text,
-		TelegramId: notific.TelegramId,
-		SendAt:     int(notific.SendAt.UnixMilli()),
+		Text:        notific.Text,
+		TelegramId:  notific.TelegramId,
+		RecipientId: notific.RecipientId,
+		GroupId:     notific.GroupId,
+		Channels:    notific.Channels,
+		Category:    notific.Category,
+		Tenant:      notific.Tenant,
+		CollapseKey: notific.CollapseKey,
+		SendAt:      int(notific.SendAt.UnixMilli()),
+		ExpiresAt:   notific.Expiry(),
+		Options:     notific.Options,
 	}
 
 	notification, err := h.service.CreateNotification(*notification)
 	if err != nil {
-		zlog.Logger.Error().Msg("could not create notification: " + err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{
-			"error": "could not create notification",
-		})
+		abort(c, apperr.Internal("could not create notification", err))
 		return
 	}
 
 	zlog.Logger.Info().Msgf("successfully handled POST request creating new notification")
-	c.JSON(http.StatusOK, notification)
+	c.JSON(http.StatusOK, dto.NewNotificationResponse(*notification))
 }
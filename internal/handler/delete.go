@@ -1,55 +1,70 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
-	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
 
-// UpdateNotificationStatus godoc
-// @Summary Cancel a notification by updating its status
-// @Description Update the status of a notification to "canceled" by its ID
-// @Tags notifications
-// @Produce json
-// @Param id path int true "Notification ID"
-// @Success 200 {object} ginext.H "Notification cancellation status"
-// @Failure 400 {object} ginext.H "Invalid ID or notification not found"
-// @Failure 500 {object} ginext.H "Could not update notification status"
-// @Router /notify/{id} [delete]
+// UpdateNotificationStatus cancels an active notification. It serves
+// DELETE /api/v1/notifications/{id}, see cancelNotification in
+// api/openapi.yaml. A hard delete is refused rather than silently turned
+// into a cancel, it is served by DeleteNotification.
 func (h *Handler) UpdateNotificationStatus(c *ginext.Context) {
-	id := c.Param("id")
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if _, hard := c.GetQuery("hard"); hard {
+		abort(c, apperr.Validation("hard delete is not available on this route", apperr.FieldError{
+			Field:   "hard",
+			Message: "use DELETE /admin/notifications/{id} on the admin listener",
+		}))
+		return
+	}
 
-	notifID, err := strconv.Atoi(id)
+	notification, err := h.service.GetNotification(notifID)
 	if err != nil {
-		zlog.Logger.Error().Msg("invalid id was provided: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{
-			"error": "invalid id was provided",
-		})
+		abort(c, err)
+		return
+	}
+
+	if notification.Status != "active" {
+		abort(c, apperr.Conflict("notification is already "+notification.Status))
 		return
 	}
 
 	err = h.service.UpdateNotificationStatus(notifID, "canceled")
 	if err != nil {
-		zlog.Logger.Error().Msg("could not update notification status: " + err.Error())
-		if errors.Is(err, repository.ErrNoSuchNotification) {
-			c.JSON(http.StatusBadRequest, ginext.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, ginext.H{
-			"error": "could not update notification status: " + err.Error(),
-		})
+		abort(c, err)
 		return
 	}
 
 	zlog.Logger.Info().Msgf("successfully handled DELETE request for updating notif status with id: %d", notifID)
-	c.JSON(http.StatusOK, ginext.H{
-		"status": "notification was cancelled succesfully",
+	c.JSON(http.StatusOK, dto.NotificationStatus{
+		Id:     notifID,
+		Status: "canceled",
 	})
 }
+
+// DeleteNotification deletes a notification for good whatever its status.
+// It serves DELETE /admin/notifications/{id} on the admin listener only, see
+// RegisterAdminRoutes.
+func (h *Handler) DeleteNotification(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteNotification(notifID); err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled DELETE request for deleting notification with id: %d", notifID)
+	c.Status(http.StatusNoContent)
+}
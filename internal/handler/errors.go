@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	requestIdHeader = "X-Request-Id"
+	requestIdKey    = "request_id"
+	problemType     = "application/problem+json"
+)
+
+var statusByCode = map[apperr.Code]int{
+	apperr.CodeNotFound:         http.StatusNotFound,
+	apperr.CodeValidationFailed: http.StatusUnprocessableEntity,
+	apperr.CodeConflict:         http.StatusConflict,
+	apperr.CodeInternal:         http.StatusInternalServerError,
+}
+
+// sentinels maps errors of the storage and the service to the codes they
+// are reported with.
+var sentinels = []struct {
+	err  error
+	code apperr.Code
+}{
+	{repository.ErrNoSuchNotification, apperr.CodeNotFound},
+	{repository.ErrNoSuchRecipient, apperr.CodeNotFound},
+	{repository.ErrNoSuchContact, apperr.CodeNotFound},
+	{repository.ErrNoSuchGroup, apperr.CodeNotFound},
+	{repository.ErrNotGroupMember, apperr.CodeNotFound},
+	{repository.ErrDuplicateContact, apperr.CodeConflict},
+	{repository.ErrNotActive, apperr.CodeConflict},
+	{model.ErrInvalidContact, apperr.CodeValidationFailed},
+	{service.ErrInvalidVerificationCode, apperr.CodeValidationFailed},
+	{service.ErrInvalidContactOrder, apperr.CodeValidationFailed},
+	{unsubscribe.ErrInvalidToken, apperr.CodeValidationFailed},
+}
+
+// RequestID reuses the X-Request-Id header of the request or generates a
+// new id, and echoes it in the response. Errors report this id.
+func RequestID() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		requestId(c)
+		c.Next()
+	}
+}
+
+// Recovery turns panics into internal error responses.
+func Recovery() ginext.HandlerFunc {
+	return gin.CustomRecovery(func(c *ginext.Context, recovered any) {
+		zlog.Logger.Error().Msgf("panic while handling request: %v", recovered)
+		abort(c, apperr.Internal("internal error", nil))
+	})
+}
+
+// NotFound answers requests to unknown routes.
+func (h *Handler) NotFound(c *ginext.Context) {
+	abort(c, apperr.NotFound("no route for "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+func requestId(c *ginext.Context) string {
+	if id := c.GetString(requestIdKey); id != "" {
+		return id
+	}
+
+	id := c.GetHeader(requestIdHeader)
+	if id == "" {
+		buf := make([]byte, 16)
+		rand.Read(buf)
+		id = hex.EncodeToString(buf)
+	}
+
+	c.Set(requestIdKey, id)
+	c.Header(requestIdHeader, id)
+	return id
+}
+
+// abort writes err as a problem+json response. Storage errors are mapped to
+// their codes, causes of internal errors are logged and never sent to the
+// client.
+func abort(c *ginext.Context, err error) {
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel.err) {
+			err = &apperr.Error{Code: sentinel.code, Message: err.Error()}
+			break
+		}
+	}
+	appErr := apperr.From(err)
+
+	id := requestId(c)
+	zlog.Logger.Error().Str(requestIdKey, id).Msg(err.Error())
+
+	status := statusByCode[appErr.Code]
+	c.Header("Content-Type", problemType)
+	c.AbortWithStatusJSON(status, dto.Problem{
+		Type:      "/problems/" + string(appErr.Code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    appErr.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      appErr.Code,
+		RequestId: id,
+		Errors:    appErr.Fields,
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// SnoozeNotification serves POST /api/v1/notifications/{id}/snooze, see
+// snoozeNotification in api/openapi.yaml.
+func (h *Handler) SnoozeNotification(c *ginext.Context) {
+	h.followUp(c, true)
+}
+
+// ResendNotification serves POST /api/v1/notifications/{id}/resend, see
+// resendNotification in api/openapi.yaml.
+func (h *Handler) ResendNotification(c *ginext.Context) {
+	h.followUp(c, false)
+}
+
+// followUp creates a follow-up of a finished notification. Unless
+// timeRequired, the body may be empty to send it right away.
+func (h *Handler) followUp(c *ginext.Context, timeRequired bool) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.FollowUpRequest
+	if (timeRequired || c.Request.ContentLength != 0) && !bindJSON(c, &request) {
+		return
+	}
+
+	now := time.Now()
+	if fields := request.Validate(now, timeRequired); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	source, err := h.service.GetNotification(notifID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	if source.Status == "active" {
+		abort(c, apperr.Conflict("notification is still active"))
+		return
+	}
+
+	notification, err := h.service.FollowUpNotification(*source, request.Time(now))
+	if err != nil {
+		abort(c, apperr.Internal("could not create notification", err))
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request creating follow-up of notification with id: %d", notifID)
+	c.JSON(http.StatusOK, dto.NewNotificationResponse(*notification))
+}
+
+// GetFollowUps serves GET /api/v1/notifications/{id}/follow-ups, see
+// getFollowUps in api/openapi.yaml.
+func (h *Handler) GetFollowUps(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	notifications, err := h.service.GetFollowUps(notifID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	response := make([]dto.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response = append(response, dto.NewNotificationResponse(notification))
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting follow-ups of notification with id: %d", notifID)
+	c.JSON(http.StatusOK, response)
+}
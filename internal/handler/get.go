@@ -1,53 +1,95 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
-	_ "github.com/Komilov31/delayed-notifier/internal/dto"
-	_ "github.com/Komilov31/delayed-notifier/internal/model"
-
-	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/api"
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
 
-// GetNotificationStatus godoc
-// @Summary Get notification status by ID
-// @Description Retrieve the status of a notification by its ID
-// @Tags notifications
-// @Produce json
-// @Param id path int true "Notification ID"
-// @Success 200 {object} dto.NotificationStatus
-// @Failure 400 {object} ginext.H "Invalid ID or notification not found"
-// @Failure 500 {object} ginext.H "Could not get notification status"
-// @Router /notify/{id} [get]
-func (h *Handler) GetNotificationStatus(c *ginext.Context) {
-	id := c.Param("id")
+// GetNotification serves GET /api/v1/notifications/{id}, see
+// getNotification in api/openapi.yaml.
+func (h *Handler) GetNotification(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
 
-	notifID, err := strconv.Atoi(id)
+	notification, err := h.service.GetNotification(notifID)
 	if err != nil {
-		zlog.Logger.Error().Msg("invalid id was provided: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{
-			"error": "invalid id was provided",
-		})
+		abort(c, err)
 		return
 	}
 
-	status, err := h.service.GetNotificationStatus(notifID)
-	if err != nil {
-		zlog.Logger.Error().Msg("could not get notificatino status: " + err.Error())
-		if errors.Is(err, repository.ErrNoSuchNotification) {
-			c.JSON(http.StatusBadRequest, ginext.H{
-				"error": err.Error(),
-			})
+	response := dto.NewNotificationResponse(*notification)
+	if notification.GroupId != 0 {
+		stats, _, err := h.service.GetGroupDeliveries(notifID)
+		if err != nil {
+			abort(c, err)
 			return
 		}
+		response.Deliveries = stats
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting notification with id: %d", notifID)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDeliveryHistory serves GET /api/v1/notifications/{id}/history, see
+// getDeliveryHistory in api/openapi.yaml.
+func (h *Handler) GetDeliveryHistory(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.service.GetDeliveryHistory(notifID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	response := make([]dto.DeliveryAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, dto.NewDeliveryAttemptResponse(attempt))
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting delivery history of notification with id: %d", notifID)
+	c.JSON(http.StatusOK, response)
+}
 
-		c.JSON(http.StatusInternalServerError, ginext.H{
-			"error": "could not get notification status: " + err.Error(),
-		})
+// GetGroupDeliveries serves GET /api/v1/notifications/{id}/deliveries, see
+// getGroupDeliveries in api/openapi.yaml.
+func (h *Handler) GetGroupDeliveries(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	stats, deliveries, err := h.service.GetGroupDeliveries(notifID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting deliveries of notification with id: %d", notifID)
+	c.JSON(http.StatusOK, dto.NewGroupDeliveriesResponse(*stats, deliveries))
+}
+
+// GetNotificationStatus serves the deprecated GET /notify/{id} that
+// returns only the status of the notification.
+func (h *Handler) GetNotificationStatus(c *ginext.Context) {
+	notifID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetNotificationStatus(notifID)
+	if err != nil {
+		abort(c, err)
 		return
 	}
 
@@ -55,35 +97,30 @@ func (h *Handler) GetNotificationStatus(c *ginext.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
-// GetAllNotifications godoc
-// @Summary Get all notifications
-// @Description Retrieve a list of all notifications
-// @Tags notifications
-// @Produce json
-// @Success 200 {array} model.Notification
-// @Failure 500 {object} ginext.H "Could not get notifications"
-// @Router /notify [get]
+// GetAllNotifications serves GET /api/v1/notifications, see
+// listNotifications in api/openapi.yaml.
 func (h *Handler) GetAllNotifications(c *ginext.Context) {
 	notifications, err := h.service.GetAllNotifications()
 	if err != nil {
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{
-			"error": "could not get notifications: " + err.Error(),
-		})
+		abort(c, apperr.Internal("could not get notifications", err))
 		return
 	}
 
+	response := make([]dto.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response = append(response, dto.NewNotificationResponse(notification))
+	}
+
 	zlog.Logger.Info().Msgf("successfully handled GET request for getting all notifications")
-	c.JSON(http.StatusOK, notifications)
+	c.JSON(http.StatusOK, response)
 }
 
-// GetMainPage godoc
-// @Summary Get main page
-// @Description Serve the main index.html page
-// @Tags main
-// @Produce html
-// @Success 200 {string} string "HTML page"
-// @Router / [get]
+// GetMainPage serves the UI.
 func (h *Handler) GetMainPage(c *ginext.Context) {
 	c.HTML(http.StatusOK, "index.html", nil)
 }
+
+// GetOpenAPI serves GET /api/v1/openapi.yaml.
+func (h *Handler) GetOpenAPI(c *ginext.Context) {
+	c.Data(http.StatusOK, "application/yaml", api.OpenAPI)
+}
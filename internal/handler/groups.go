@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// CreateGroup serves POST /api/v1/groups, see createGroup in
+// api/openapi.yaml.
+func (h *Handler) CreateGroup(c *ginext.Context) {
+	var request dto.CreateGroupRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	group, err := h.service.CreateGroup(request.Name)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request creating group with id: %d", group.Id)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(*group))
+}
+
+// GetAllGroups serves GET /api/v1/groups, see listGroups in
+// api/openapi.yaml.
+func (h *Handler) GetAllGroups(c *ginext.Context) {
+	groups, err := h.service.GetAllGroups()
+	if err != nil {
+		abort(c, apperr.Internal("could not get groups", err))
+		return
+	}
+
+	response := make([]dto.GroupResponse, 0, len(groups))
+	for _, group := range groups {
+		response = append(response, dto.NewGroupResponse(group))
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request for getting all groups")
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGroup serves GET /api/v1/groups/{id}, see getGroup in
+// api/openapi.yaml.
+func (h *Handler) GetGroup(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.service.GetGroup(id)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting group with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(*group))
+}
+
+// DeleteGroup serves DELETE /api/v1/groups/{id}, see deleteGroup in
+// api/openapi.yaml.
+func (h *Handler) DeleteGroup(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteGroup(id); err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled DELETE request for deleting group with id: %d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// AddGroupMember serves POST /api/v1/groups/{id}/members, see
+// addGroupMember in api/openapi.yaml.
+func (h *Handler) AddGroupMember(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.AddGroupMemberRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	group, err := h.service.AddGroupMember(id, request.RecipientId)
+	if errors.Is(err, repository.ErrNoSuchRecipient) {
+		abort(c, apperr.Validation("invalid payload", apperr.FieldError{
+			Field:   "recipient_id",
+			Message: "there is no recipient with such id",
+		}))
+		return
+	}
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request adding recipient %d to group with id: %d", request.RecipientId, id)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(*group))
+}
+
+// RemoveGroupMember serves DELETE
+// /api/v1/groups/{id}/members/{recipient_id}, see removeGroupMember in
+// api/openapi.yaml.
+func (h *Handler) RemoveGroupMember(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	recipientId, ok := intParam(c, "recipient_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveGroupMember(id, recipientId); err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled DELETE request removing recipient %d from group with id: %d", recipientId, id)
+	c.Status(http.StatusNoContent)
+}
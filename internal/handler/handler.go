@@ -2,18 +2,54 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
+	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
 	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/wb-go/wbf/ginext"
 )
 
 type NotifierService interface {
 	GetNotificationStatus(int) (*dto.NotificationStatus, error)
+	GetNotification(int) (*model.Notification, error)
 	GetAllNotifications() ([]model.Notification, error)
+	GetDeliveryHistory(int) ([]model.DeliveryAttempt, error)
 	CreateNotification(model.Notification) (*model.Notification, error)
+	FollowUpNotification(model.Notification, time.Time) (*model.Notification, error)
+	GetFollowUps(int) ([]model.Notification, error)
 	UpdateNotificationStatus(int, string) error
+	DeleteNotification(int) error
+	GetSubscriber(string) (*model.Subscriber, error)
 	PublishReadyNotifications(context.Context) error
 	ConsumeMessages(ctx context.Context) error
+	GetWorkerPoolStats() dto.WorkerPoolStats
+	UpdateWorkerPool(dto.WorkerPoolUpdate) (*dto.WorkerPoolStats, error)
+	GetCatchUpReport() *dto.CatchUpReport
+	SubscribeEvents(model.EventFilter) (<-chan model.Event, func())
+	CreateRecipient(model.Recipient) (*model.Recipient, error)
+	GetRecipient(int) (*model.Recipient, error)
+	GetAllRecipients() ([]model.Recipient, error)
+	RenameRecipient(int, string) (*model.Recipient, error)
+	DeleteRecipient(int) error
+	AddContact(int, model.Contact) (*model.Contact, error)
+	DeleteContact(int, int) error
+	VerifyContact(int, int, string) (*model.Contact, error)
+	ReorderContacts(int, []int) (*model.Recipient, error)
+	GetPreferences(int) (*model.Preferences, error)
+	UpdatePreferences(model.Preferences) (*model.Preferences, error)
+	VerifyUnsubscribe(string) (*unsubscribe.Token, error)
+	Unsubscribe(string) (*model.Preferences, error)
+	CreateGroup(string) (*model.Group, error)
+	GetGroup(int) (*model.Group, error)
+	GetAllGroups() ([]model.Group, error)
+	DeleteGroup(int) error
+	AddGroupMember(int, int) (*model.Group, error)
+	RemoveGroupMember(int, int) error
+	GetGroupDeliveries(int) (*model.GroupDeliveries, []model.Notification, error)
 }
 
 type Handler struct {
@@ -25,3 +61,34 @@ func New(service NotifierService) *Handler {
 		service: service,
 	}
 }
+
+// idParam parses the id path parameter, an invalid id is reported to the
+// client and ok is false.
+func idParam(c *ginext.Context) (id int, ok bool) {
+	return intParam(c, "id")
+}
+
+func intParam(c *ginext.Context, name string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		abort(c, apperr.Validation("invalid "+name+" was provided", apperr.FieldError{
+			Field:   name,
+			Message: "must be an integer",
+		}))
+		return 0, false
+	}
+	return value, true
+}
+
+// bindJSON decodes the request body into payload. Unknown fields are
+// rejected, so clients notice when they send server-owned fields like id or
+// status.
+func bindJSON(c *ginext.Context, payload any) bool {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(payload); err != nil {
+		abort(c, apperr.Validation("invalid payload: "+err.Error()))
+		return false
+	}
+	return true
+}
@@ -4,16 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
 	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockNotifierService is a mock implementation of NotifierService
@@ -26,11 +34,37 @@ func (m *MockNotifierService) CreateNotification(notification model.Notification
 	return args.Get(0).(*model.Notification), args.Error(1)
 }
 
+func (m *MockNotifierService) FollowUpNotification(source model.Notification, sendAt time.Time) (*model.Notification, error) {
+	args := m.Called(source, sendAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Notification), args.Error(1)
+}
+
+func (m *MockNotifierService) GetFollowUps(id int) ([]model.Notification, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
+func (m *MockNotifierService) DeleteNotification(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func (m *MockNotifierService) GetNotificationStatus(id int) (*dto.NotificationStatus, error) {
 	args := m.Called(id)
 	return args.Get(0).(*dto.NotificationStatus), args.Error(1)
 }
 
+func (m *MockNotifierService) GetNotification(id int) (*model.Notification, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Notification), args.Error(1)
+}
+
 func (m *MockNotifierService) GetAllNotifications() ([]model.Notification, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -39,11 +73,24 @@ func (m *MockNotifierService) GetAllNotifications() ([]model.Notification, error
 	return args.Get(0).([]model.Notification), args.Error(1)
 }
 
+func (m *MockNotifierService) GetDeliveryHistory(id int) ([]model.DeliveryAttempt, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DeliveryAttempt), args.Error(1)
+}
+
 func (m *MockNotifierService) UpdateNotificationStatus(id int, status string) error {
 	args := m.Called(id, status)
 	return args.Error(0)
 }
 
+func (m *MockNotifierService) GetSubscriber(handle string) (*model.Subscriber, error) {
+	args := m.Called(handle)
+	return args.Get(0).(*model.Subscriber), args.Error(1)
+}
+
 func (m *MockNotifierService) PublishReadyNotifications(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -54,11 +101,187 @@ func (m *MockNotifierService) ConsumeMessages(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (m *MockNotifierService) GetWorkerPoolStats() dto.WorkerPoolStats {
+	args := m.Called()
+	return args.Get(0).(dto.WorkerPoolStats)
+}
+
+func (m *MockNotifierService) GetCatchUpReport() *dto.CatchUpReport {
+	args := m.Called()
+	return args.Get(0).(*dto.CatchUpReport)
+}
+
+func (m *MockNotifierService) UpdateWorkerPool(update dto.WorkerPoolUpdate) (*dto.WorkerPoolStats, error) {
+	args := m.Called(update)
+	return args.Get(0).(*dto.WorkerPoolStats), args.Error(1)
+}
+
+func (m *MockNotifierService) SubscribeEvents(filter model.EventFilter) (<-chan model.Event, func()) {
+	args := m.Called(filter)
+	return args.Get(0).(<-chan model.Event), args.Get(1).(func())
+}
+
+func (m *MockNotifierService) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	args := m.Called(recipient)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockNotifierService) GetRecipient(id int) (*model.Recipient, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockNotifierService) GetAllRecipients() ([]model.Recipient, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Recipient), args.Error(1)
+}
+
+func (m *MockNotifierService) RenameRecipient(id int, name string) (*model.Recipient, error) {
+	args := m.Called(id, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockNotifierService) DeleteRecipient(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockNotifierService) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	args := m.Called(recipientId, contact)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Contact), args.Error(1)
+}
+
+func (m *MockNotifierService) DeleteContact(recipientId, contactId int) error {
+	args := m.Called(recipientId, contactId)
+	return args.Error(0)
+}
+
+func (m *MockNotifierService) VerifyContact(recipientId, contactId int, code string) (*model.Contact, error) {
+	args := m.Called(recipientId, contactId, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Contact), args.Error(1)
+}
+
+func (m *MockNotifierService) ReorderContacts(recipientId int, contactIds []int) (*model.Recipient, error) {
+	args := m.Called(recipientId, contactIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockNotifierService) GetPreferences(recipientId int) (*model.Preferences, error) {
+	args := m.Called(recipientId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Preferences), args.Error(1)
+}
+
+func (m *MockNotifierService) UpdatePreferences(preferences model.Preferences) (*model.Preferences, error) {
+	args := m.Called(preferences)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Preferences), args.Error(1)
+}
+
+func (m *MockNotifierService) VerifyUnsubscribe(token string) (*unsubscribe.Token, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*unsubscribe.Token), args.Error(1)
+}
+
+func (m *MockNotifierService) Unsubscribe(token string) (*model.Preferences, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Preferences), args.Error(1)
+}
+
+func (m *MockNotifierService) CreateGroup(name string) (*model.Group, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Group), args.Error(1)
+}
+
+func (m *MockNotifierService) GetGroup(id int) (*model.Group, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Group), args.Error(1)
+}
+
+func (m *MockNotifierService) GetAllGroups() ([]model.Group, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Group), args.Error(1)
+}
+
+func (m *MockNotifierService) DeleteGroup(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockNotifierService) AddGroupMember(groupId, recipientId int) (*model.Group, error) {
+	args := m.Called(groupId, recipientId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Group), args.Error(1)
+}
+
+func (m *MockNotifierService) RemoveGroupMember(groupId, recipientId int) error {
+	args := m.Called(groupId, recipientId)
+	return args.Error(0)
+}
+
+func (m *MockNotifierService) GetGroupDeliveries(id int) (*model.GroupDeliveries, []model.Notification, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.GroupDeliveries), args.Get(1).([]model.Notification), args.Error(2)
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) dto.Problem {
+	t.Helper()
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem dto.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, w.Code, problem.Status)
+	assert.NotEmpty(t, problem.RequestId)
+	return problem
+}
+
 func TestHandler_CreateNotification_Success(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
-	notificationDTO := dto.NotificationDTO{
+	notificationDTO := dto.CreateNotificationRequest{
 		Text:       "Test notification",
 		TelegramId: 123,
 		SendAt:     time.Now().Add(time.Hour),
@@ -101,7 +324,7 @@ func TestHandler_CreateNotification_InvalidPayload(t *testing.T) {
 
 	handler.CreateNotification(c)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	mockService.AssertNotCalled(t, "CreateNotification")
 }
 
@@ -109,7 +332,7 @@ func TestHandler_CreateNotification_TimeInPast(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
-	notificationDTO := dto.NotificationDTO{
+	notificationDTO := dto.CreateNotificationRequest{
 		Text:       "Test notification",
 		TelegramId: 123,
 		SendAt:     time.Now().Add(-time.Hour),
@@ -125,7 +348,7 @@ func TestHandler_CreateNotification_TimeInPast(t *testing.T) {
 
 	handler.CreateNotification(c)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	mockService.AssertNotCalled(t, "CreateNotification")
 }
 
@@ -133,7 +356,7 @@ func TestHandler_CreateNotification_ServiceError(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
-	notificationDTO := dto.NotificationDTO{
+	notificationDTO := dto.CreateNotificationRequest{
 		Text:       "Test notification",
 		TelegramId: 123,
 		SendAt:     time.Now().Add(time.Hour),
@@ -182,11 +405,12 @@ func TestHandler_GetNotificationStatus_InvalidID(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/notify/invalid", nil)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: "invalid"}}
 
 	handler.GetNotificationStatus(c)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	mockService.AssertNotCalled(t, "GetNotificationStatus")
 }
 
@@ -194,15 +418,39 @@ func TestHandler_GetNotificationStatus_NotFound(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
-	mockService.On("GetNotificationStatus", 1).Return((*dto.NotificationStatus)(nil), assert.AnError)
+	mockService.On("GetNotificationStatus", 1).Return((*dto.NotificationStatus)(nil), repository.ErrNoSuchNotification)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/notify/1", nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+
+	handler.GetNotificationStatus(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, apperr.CodeNotFound, problem.Code)
+	assert.Equal(t, "/notify/1", problem.Instance)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetNotificationStatus_InternalError(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("GetNotificationStatus", 1).Return((*dto.NotificationStatus)(nil), errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/notify/1", nil)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
 
 	handler.GetNotificationStatus(c)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, apperr.CodeInternal, problem.Code)
+	assert.NotContains(t, w.Body.String(), "connection refused")
 	mockService.AssertExpectations(t)
 }
 
@@ -234,6 +482,7 @@ func TestHandler_GetAllNotifications_ServiceError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/notify", nil)
 
 	handler.GetAllNotifications(c)
 
@@ -258,6 +507,7 @@ func TestHandler_UpdateNotificationStatus_Success(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
+	mockService.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "active"}, nil)
 	mockService.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
 
 	w := httptest.NewRecorder()
@@ -276,11 +526,12 @@ func TestHandler_UpdateNotificationStatus_InvalidID(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/notify/invalid", nil)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: "invalid"}}
 
 	handler.UpdateNotificationStatus(c)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	mockService.AssertNotCalled(t, "UpdateNotificationStatus")
 }
 
@@ -288,14 +539,410 @@ func TestHandler_UpdateNotificationStatus_NotFound(t *testing.T) {
 	mockService := new(MockNotifierService)
 	handler := New(mockService)
 
-	mockService.On("UpdateNotificationStatus", 1, "canceled").Return(assert.AnError)
+	mockService.On("GetNotification", 1).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/notify/1", nil)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
 
 	handler.UpdateNotificationStatus(c)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_UpdateNotificationStatus_Conflict(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "completed"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/notify/1", nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+
+	handler.UpdateNotificationStatus(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, apperr.CodeConflict, decodeProblem(t, w).Code)
+	mockService.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+}
+
+func TestHandler_DeleteNotification_Success(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("DeleteNotification", 1).Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/admin/notifications/1", nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+
+	handler.DeleteNotification(c)
+
+	assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_DeleteNotification_NotFound(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("DeleteNotification", 1).Return(repository.ErrNoSuchNotification)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/admin/notifications/1", nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+
+	handler.DeleteNotification(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_RegisterRoutes_NoHardDelete(t *testing.T) {
+	mockService := new(MockNotifierService)
+	router := gin.New()
+	New(mockService).RegisterRoutes(router.Group("/api/v1"))
+
+	mockService.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "active"}, nil).Maybe()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/notifications/1?hard=true", nil))
+
+	// The active notification is neither deleted nor canceled.
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "/admin/notifications/{id}")
+	mockService.AssertNotCalled(t, "DeleteNotification", mock.Anything)
+	mockService.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+}
+
+func TestHandler_UpdateWorkerPool_Success(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	workers := 5
+	update := dto.WorkerPoolUpdate{Workers: &workers}
+	body, _ := json.Marshal(update)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/workers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockService.On("UpdateWorkerPool", update).Return(&dto.WorkerPoolStats{Workers: 5, Concurrency: 16}, nil)
+
+	handler.UpdateWorkerPool(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_UpdateWorkerPool_InvalidConfig(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	workers := 0
+	update := dto.WorkerPoolUpdate{Workers: &workers}
+	body, _ := json.Marshal(update)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/workers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockService.On("UpdateWorkerPool", update).Return((*dto.WorkerPoolStats)(nil), service.ErrInvalidPoolConfig)
+
+	handler.UpdateWorkerPool(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetCatchUpReport_NoneYet(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/catchup", nil)
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockService.On("GetCatchUpReport").Return((*dto.CatchUpReport)(nil))
+
+	handler.GetCatchUpReport(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateNotification_InvalidOptions(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	notificationDTO := dto.CreateNotificationRequest{
+		Text:       "Test notification",
+		TelegramId: 123,
+		SendAt:     time.Now().Add(time.Hour),
+		Options: &model.TelegramOptions{
+			ParseMode: "Markdown",
+			Buttons:   [][]model.Button{{{Text: "Open", URL: "https://example.com", CallbackData: "open"}}},
+		},
+	}
+	body, _ := json.Marshal(notificationDTO)
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateNotification(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockService.AssertNotCalled(t, "CreateNotification")
+}
+
+func TestHandler_CreateNotification_ByUsername(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	notificationDTO := dto.CreateNotificationRequest{
+		Text:     "Test notification",
+		Username: "@alice",
+		SendAt:   time.Now().Add(time.Hour),
+	}
+	body, _ := json.Marshal(notificationDTO)
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockService.On("GetSubscriber", "@alice").Return(&model.Subscriber{Handle: "alice", TelegramId: 123}, nil)
+	mockService.On("CreateNotification", mock.MatchedBy(func(n model.Notification) bool {
+		return n.TelegramId == 123
+	})).Return(&model.Notification{Id: 1, TelegramId: 123, Status: "active"}, nil)
+
+	handler.CreateNotification(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateNotification_UnknownUsername(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	notificationDTO := dto.CreateNotificationRequest{
+		Text:     "Test notification",
+		Username: "bob",
+		SendAt:   time.Now().Add(time.Hour),
+	}
+	body, _ := json.Marshal(notificationDTO)
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockService.On("GetSubscriber", "bob").Return((*model.Subscriber)(nil), repository.ErrNoSuchSubscriber)
+
+	handler.CreateNotification(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockService.AssertNotCalled(t, "CreateNotification")
+}
+
+func TestHandler_StreamNotifications_SSE(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	events := make(chan model.Event, 2)
+	events <- model.Event{Type: model.EventCreated, Notification: model.Notification{Id: 7, TelegramId: 123, Status: "active"}}
+	events <- model.Event{Type: model.EventSent, Notification: model.Notification{Id: 7, TelegramId: 123, Status: "completed"}}
+	close(events)
+
+	unsubscribed := false
+	mockService.On("SubscribeEvents", model.EventFilter{Id: 7, TelegramId: 123, RecipientId: 5}).
+		Return((<-chan model.Event)(events), func() { unsubscribed = true })
+
+	req := httptest.NewRequest(http.MethodGet, "/notify/stream?id=7&telegram_id=123&recipient_id=5", nil)
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.StreamNotifications(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event:created\n")
+	assert.Contains(t, body, "event:sent\n")
+	assert.Contains(t, body, `"status":"completed"`)
+	assert.True(t, unsubscribed)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_StreamNotifications_InvalidFilter(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/notify/stream?telegram_id=abc", nil)
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.StreamNotifications(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockService.AssertNotCalled(t, "SubscribeEvents", mock.Anything)
+}
+
+func TestHandler_StreamNotifications_WebSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	events := make(chan model.Event, 1)
+	mockService.On("SubscribeEvents", model.EventFilter{}).Return((<-chan model.Event)(events), func() {})
+
+	router := gin.New()
+	router.GET("/notify/:id", handler.GetNotificationStatus)
+	router.GET("/notify/stream", handler.StreamNotifications)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/notify/stream", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	events <- model.Event{Type: model.EventCanceled, Notification: model.Notification{Id: 3, Status: "canceled"}}
+
+	var event dto.EventResponse
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, model.EventCanceled, event.Type)
+	assert.Equal(t, 3, event.Notification.Id)
+}
+
+func TestHandler_CreateNotification_FieldErrors(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	notificationDTO := dto.CreateNotificationRequest{
+		Text:   strings.Repeat("a", 4097),
+		SendAt: time.Now().Add(dto.MaxScheduleHorizon + time.Hour),
+	}
+	body, _ := json.Marshal(notificationDTO)
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateNotification(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, apperr.CodeValidationFailed, problem.Code)
+
+	var fields []string
+	for _, field := range problem.Errors {
+		fields = append(fields, field.Field)
+	}
+	assert.ElementsMatch(t, []string{"text", "telegram_id", "send_at"}, fields)
+	mockService.AssertNotCalled(t, "CreateNotification")
+}
+
+func TestHandler_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	router := gin.New()
+	router.Use(RequestID(), Recovery())
+	router.GET("/notify/:id", handler.GetNotificationStatus)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.NoRoute(handler.NotFound)
+
+	t.Run("propagated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/notify/abc", nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "req-1", w.Header().Get("X-Request-Id"))
+		assert.Equal(t, "req-1", decodeProblem(t, w).RequestId)
+	})
+
+	t.Run("generated for unknown route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, w.Header().Get("X-Request-Id"), problem.RequestId)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, apperr.CodeInternal, decodeProblem(t, w).Code)
+	})
+}
+
+func TestHandler_ConfirmUnsubscribe_DoesNotApply(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("VerifyUnsubscribe", "signed").Return(&unsubscribe.Token{RecipientId: 1, Category: "billing"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/unsubscribe?token=signed", nil)
+
+	handler.ConfirmUnsubscribe(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="?token=signed"`)
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "Unsubscribe", mock.Anything)
+}
+
+func TestHandler_Unsubscribe_Browser(t *testing.T) {
+	mockService := new(MockNotifierService)
+	handler := New(mockService)
+
+	mockService.On("Unsubscribe", "signed").Return(&model.Preferences{RecipientId: 1, MutedCategories: []string{"billing"}}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/unsubscribe?token=signed", nil)
+	c.Request.Header.Set("Accept", "text/html")
+
+	handler.Unsubscribe(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
 	mockService.AssertExpectations(t)
 }
@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/api"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// The unsubscribe pages are text/html, which kin-openapi does not decode.
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	spec, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	require.NoError(t, err)
+	require.NoError(t, spec.Validate(context.Background()))
+	return spec
+}
+
+func newAPI(service NotifierService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Recovery())
+	handler := New(service)
+	handler.RegisterRoutes(router.Group("/api/v1"))
+	handler.RegisterAdminRoutes(router)
+	return router
+}
+
+// pathParam matches gin path parameters like :id.
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPI_CoversRoutes(t *testing.T) {
+	spec := loadSpec(t)
+
+	var documented []string
+	for path, item := range spec.Paths {
+		prefix := "/api/v1"
+		if len(item.Servers) > 0 {
+			prefix = strings.TrimSuffix(item.Servers[0].URL, "/")
+		}
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+prefix+path)
+		}
+	}
+
+	var registered []string
+	for _, route := range newAPI(new(MockNotifierService)).Routes() {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		registered = append(registered, route.Method+" "+path)
+	}
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+	assert.Equal(t, documented, registered)
+}
+
+func TestOpenAPI_RequestsAndResponses(t *testing.T) {
+	publicRouter := specRouter(t, false)
+	adminRouter := specRouter(t, true)
+
+	sendAt := time.Now().Add(time.Hour).UTC()
+	stored := &model.Notification{
+		Id:         1,
+		Text:       "hello",
+		Status:     "active",
+		TelegramId: 123,
+		SendAt:     int(sendAt.UnixMilli()),
+		CreatedAt:  time.Now().UTC(),
+		Options: &model.TelegramOptions{
+			ParseMode: model.ParseModeHTML,
+			Buttons:   [][]model.Button{{{Text: "open", URL: "https://example.com"}}},
+		},
+		Version: 1,
+	}
+	completed := *stored
+	completed.Id = 2
+	completed.Status = "completed"
+
+	mockService := new(MockNotifierService)
+	mockService.On("CreateNotification", mock.AnythingOfType("model.Notification")).Return(stored, nil)
+	mockService.On("GetAllNotifications").Return([]model.Notification{*stored, completed}, nil)
+	mockService.On("GetNotification", 1).Return(stored, nil)
+	mockService.On("GetNotification", 2).Return(&completed, nil)
+	mockService.On("GetNotification", 3).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
+	mockService.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockService.On("DeleteNotification", 2).Return(nil)
+	mockService.On("DeleteNotification", 3).Return(repository.ErrNoSuchNotification)
+	mockService.On("GetDeliveryHistory", 1).Return([]model.DeliveryAttempt{
+		{Id: 1, NotificationId: 1, Channel: model.ChannelTelegram, Address: "123", Status: model.DeliveryFailed, Error: "blocked", CreatedAt: time.Now().UTC()},
+		{Id: 2, NotificationId: 1, Channel: model.ChannelEmail, Address: "alice@example.com", Status: model.DeliverySent, CreatedAt: time.Now().UTC()},
+	}, nil)
+	mockService.On("GetDeliveryHistory", 3).Return(nil, repository.ErrNoSuchNotification)
+	mockService.On("GetWorkerPoolStats").Return(dto.WorkerPoolStats{Workers: 3, Concurrency: 16, Prefetch: 10})
+	mockService.On("GetCatchUpReport").Return(&dto.CatchUpReport{
+		Policy:      model.CatchUpSpread,
+		StartedAt:   time.Now().UTC(),
+		OldestDueAt: time.Now().Add(-time.Hour).UTC(),
+		Missed:      3,
+		Sent:        1,
+		Expired:     1,
+		Rescheduled: 1,
+	})
+	mockService.On("UpdateWorkerPool", mock.Anything).Return(&dto.WorkerPoolStats{Workers: 5, Concurrency: 16, Prefetch: 10}, nil)
+
+	recipient := &model.Recipient{
+		Id:   1,
+		Name: "Alice",
+		Contacts: []model.Contact{
+			{Id: 1, Channel: model.ChannelTelegram, Address: "123", Verified: true, CreatedAt: time.Now().UTC()},
+			{Id: 2, Channel: model.ChannelEmail, Address: "alice@example.com", Position: 1, CreatedAt: time.Now().UTC()},
+		},
+		CreatedAt: time.Now().UTC(),
+	}
+	mockService.On("CreateRecipient", mock.AnythingOfType("model.Recipient")).Return(recipient, nil)
+	mockService.On("GetAllRecipients").Return([]model.Recipient{*recipient}, nil)
+	mockService.On("GetRecipient", 1).Return(recipient, nil)
+	mockService.On("GetRecipient", 3).Return(nil, repository.ErrNoSuchRecipient)
+	mockService.On("RenameRecipient", 1, "Bob").Return(recipient, nil)
+	mockService.On("DeleteRecipient", 1).Return(nil)
+	mockService.On("AddContact", 1, mock.AnythingOfType("model.Contact")).Return(&recipient.Contacts[1], nil)
+	mockService.On("DeleteContact", 1, 2).Return(nil)
+	mockService.On("VerifyContact", 1, 2, "000000").Return(nil, service.ErrInvalidVerificationCode)
+	mockService.On("ReorderContacts", 1, []int{2, 1}).Return(recipient, nil)
+
+	broadcast := *stored
+	broadcast.Id = 4
+	broadcast.TelegramId = 0
+	broadcast.GroupId = 1
+	broadcast.Status = "completed"
+	delivery := *stored
+	delivery.Id = 5
+	delivery.TelegramId = 0
+	delivery.RecipientId = 1
+	delivery.ParentId = 4
+	group := &model.Group{Id: 1, Name: "Team", Members: []int{1}, CreatedAt: time.Now().UTC()}
+	mockService.On("GetNotification", 4).Return(&broadcast, nil)
+	followUp := *stored
+	followUp.Id = 5
+	followUp.FollowUpOf = completed.Id
+	mockService.On("FollowUpNotification", completed, mock.AnythingOfType("time.Time")).Return(&followUp, nil)
+	mockService.On("GetFollowUps", 2).Return([]model.Notification{followUp}, nil)
+	mockService.On("GetFollowUps", 3).Return(nil, repository.ErrNoSuchNotification)
+	mockService.On("GetGroupDeliveries", 4).Return(&model.GroupDeliveries{Total: 1, Pending: 1}, []model.Notification{delivery}, nil)
+	mockService.On("GetGroupDeliveries", 3).Return(nil, nil, repository.ErrNoSuchNotification)
+	mockService.On("CreateGroup", "Team").Return(group, nil)
+	mockService.On("GetAllGroups").Return([]model.Group{*group}, nil)
+	mockService.On("GetGroup", 1).Return(group, nil)
+	mockService.On("GetGroup", 3).Return(nil, repository.ErrNoSuchGroup)
+	mockService.On("DeleteGroup", 1).Return(nil)
+	mockService.On("AddGroupMember", 1, 1).Return(group, nil)
+	mockService.On("AddGroupMember", 1, 3).Return(nil, repository.ErrNoSuchRecipient)
+	mockService.On("RemoveGroupMember", 1, 1).Return(nil)
+	mockService.On("RemoveGroupMember", 1, 2).Return(repository.ErrNotGroupMember)
+	preferences := &model.Preferences{RecipientId: 1, MutedCategories: []string{"billing"}, OptedOutChannels: []string{}}
+	mockService.On("GetPreferences", 1).Return(preferences, nil)
+	mockService.On("GetPreferences", 3).Return(nil, repository.ErrNoSuchRecipient)
+	mockService.On("UpdatePreferences", mock.AnythingOfType("model.Preferences")).Return(preferences, nil)
+	mockService.On("VerifyUnsubscribe", "signed").Return(&unsubscribe.Token{RecipientId: 1, Category: "billing"}, nil)
+	mockService.On("VerifyUnsubscribe", "forged").Return(nil, unsubscribe.ErrInvalidToken)
+	mockService.On("Unsubscribe", "signed").Return(preferences, nil)
+	mockService.On("Unsubscribe", "forged").Return(nil, unsubscribe.ErrInvalidToken)
+	router := newAPI(mockService)
+
+	validCreate := `{"text":"hello","telegram_id":123,"send_at":"` + sendAt.Format(time.RFC3339) + `","options":{"parse_mode":"HTML"}}`
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		invalid      bool // the request violates the spec
+		expectedCode int
+	}{
+		{"create", http.MethodPost, "/notifications", validCreate, false, http.StatusOK},
+		{"create with server-owned fields", http.MethodPost, "/notifications", `{"id":1,"status":"active","text":"hello","telegram_id":123,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, true, http.StatusUnprocessableEntity},
+		{"create without text", http.MethodPost, "/notifications", `{"telegram_id":123,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, true, http.StatusUnprocessableEntity},
+		{"create in the past", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":123,"send_at":"2020-01-01T00:00:00Z"}`, false, http.StatusUnprocessableEntity},
+		{"list", http.MethodGet, "/notifications", "", false, http.StatusOK},
+		{"get", http.MethodGet, "/notifications/1", "", false, http.StatusOK},
+		{"get missing", http.MethodGet, "/notifications/3", "", false, http.StatusNotFound},
+		{"get invalid id", http.MethodGet, "/notifications/abc", "", true, http.StatusUnprocessableEntity},
+		{"history", http.MethodGet, "/notifications/1/history", "", false, http.StatusOK},
+		{"history of missing", http.MethodGet, "/notifications/3/history", "", false, http.StatusNotFound},
+		{"cancel", http.MethodDelete, "/notifications/1", "", false, http.StatusOK},
+		{"cancel completed", http.MethodDelete, "/notifications/2", "", false, http.StatusConflict},
+		{"cancel missing", http.MethodDelete, "/notifications/3", "", false, http.StatusNotFound},
+		{"hard delete on the public route", http.MethodDelete, "/notifications/1?hard=true", "", false, http.StatusUnprocessableEntity},
+		{"snooze", http.MethodPost, "/notifications/2/snooze", `{"delay":600}`, false, http.StatusOK},
+		{"snooze at", http.MethodPost, "/notifications/2/snooze", `{"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"snooze without time", http.MethodPost, "/notifications/2/snooze", `{}`, false, http.StatusUnprocessableEntity},
+		{"snooze without body", http.MethodPost, "/notifications/2/snooze", "", true, http.StatusUnprocessableEntity},
+		{"snooze with delay and send_at", http.MethodPost, "/notifications/2/snooze", `{"delay":600,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"snooze beyond the horizon", http.MethodPost, "/notifications/2/snooze", `{"delay":9223372037}`, false, http.StatusUnprocessableEntity},
+		{"snooze active", http.MethodPost, "/notifications/1/snooze", `{"delay":600}`, false, http.StatusConflict},
+		{"snooze missing", http.MethodPost, "/notifications/3/snooze", `{"delay":600}`, false, http.StatusNotFound},
+		{"resend", http.MethodPost, "/notifications/2/resend", "", false, http.StatusOK},
+		{"resend later", http.MethodPost, "/notifications/2/resend", `{"delay":60}`, false, http.StatusOK},
+		{"resend in the past", http.MethodPost, "/notifications/2/resend", `{"send_at":"2020-01-01T00:00:00Z"}`, false, http.StatusUnprocessableEntity},
+		{"follow-ups", http.MethodGet, "/notifications/2/follow-ups", "", false, http.StatusOK},
+		{"follow-ups of missing", http.MethodGet, "/notifications/3/follow-ups", "", false, http.StatusNotFound},
+		{"stream invalid filter", http.MethodGet, "/notifications/stream?telegram_id=abc", "", true, http.StatusUnprocessableEntity},
+		{"stream invalid recipient filter", http.MethodGet, "/notifications/stream?recipient_id=abc", "", true, http.StatusUnprocessableEntity},
+		{"hard delete", http.MethodDelete, "/admin/notifications/2", "", false, http.StatusNoContent},
+		{"hard delete missing", http.MethodDelete, "/admin/notifications/3", "", false, http.StatusNotFound},
+		{"get workers", http.MethodGet, "/admin/workers", "", false, http.StatusOK},
+		{"update workers", http.MethodPut, "/admin/workers", `{"workers":5}`, false, http.StatusOK},
+		{"update workers unknown field", http.MethodPut, "/admin/workers", `{"threads":5}`, true, http.StatusUnprocessableEntity},
+		{"get catch-up report", http.MethodGet, "/admin/catchup", "", false, http.StatusOK},
+		{"create for recipient", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with fallback chain", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"channels":["email","telegram"],"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with chain without recipient", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":123,"channels":["email"],"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"create with collapse key", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"collapse_key":"order-42","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with too long collapse key", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"collapse_key":"` + strings.Repeat("k", 256) + `","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, true, http.StatusUnprocessableEntity},
+		{"create for tenant", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"tenant":"acme","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with invalid tenant", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"tenant":"Acme Inc","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, true, http.StatusUnprocessableEntity},
+		{"create with expiry", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `","expires_at":"` + sendAt.Add(time.Hour).Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with max lateness", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `","max_lateness":600}`, false, http.StatusOK},
+		{"create with max lateness beyond the horizon", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `","max_lateness":9223372037}`, true, http.StatusUnprocessableEntity},
+		{"create expiring before send_at", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `","expires_at":"` + sendAt.Add(-time.Minute).Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"create with expires_at and max lateness", http.MethodPost, "/notifications", `{"text":"hello","telegram_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `","expires_at":"` + sendAt.Add(time.Hour).Format(time.RFC3339) + `","max_lateness":600}`, false, http.StatusUnprocessableEntity},
+		{"create for missing recipient", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":3,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"create recipient", http.MethodPost, "/recipients", `{"name":"Alice","contacts":[{"channel":"telegram","address":"123"}]}`, false, http.StatusOK},
+		{"create recipient with invalid contact", http.MethodPost, "/recipients", `{"name":"Alice","contacts":[{"channel":"email","address":"alice"}]}`, false, http.StatusUnprocessableEntity},
+		{"list recipients", http.MethodGet, "/recipients", "", false, http.StatusOK},
+		{"get recipient", http.MethodGet, "/recipients/1", "", false, http.StatusOK},
+		{"get missing recipient", http.MethodGet, "/recipients/3", "", false, http.StatusNotFound},
+		{"rename recipient", http.MethodPut, "/recipients/1", `{"name":"Bob"}`, false, http.StatusOK},
+		{"delete recipient", http.MethodDelete, "/recipients/1", "", false, http.StatusNoContent},
+		{"add contact", http.MethodPost, "/recipients/1/contacts", `{"channel":"email","address":"alice@example.com"}`, false, http.StatusOK},
+		{"add contact of unknown channel", http.MethodPost, "/recipients/1/contacts", `{"channel":"pager","address":"1"}`, true, http.StatusUnprocessableEntity},
+		{"add loopback webhook", http.MethodPost, "/recipients/1/contacts", `{"channel":"webhook","address":"http://localhost:8081/admin/workers"}`, false, http.StatusUnprocessableEntity},
+		{"add link-local webhook", http.MethodPost, "/recipients/1/contacts", `{"channel":"webhook","address":"http://169.254.169.254/latest/meta-data"}`, false, http.StatusUnprocessableEntity},
+		{"add private webhook", http.MethodPost, "/recipients/1/contacts", `{"channel":"webhook","address":"https://[fd00::1]/hook"}`, false, http.StatusUnprocessableEntity},
+		{"delete contact", http.MethodDelete, "/recipients/1/contacts/2", "", false, http.StatusNoContent},
+		{"verify contact with wrong code", http.MethodPost, "/recipients/1/contacts/2/verify", `{"code":"000000"}`, false, http.StatusUnprocessableEntity},
+		{"reorder contacts", http.MethodPut, "/recipients/1/contacts/order", `{"contact_ids":[2,1]}`, false, http.StatusOK},
+		{"create for group", http.MethodPost, "/notifications", `{"text":"hello","group_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create for missing group", http.MethodPost, "/notifications", `{"text":"hello","group_id":3,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"create for group and recipient", http.MethodPost, "/notifications", `{"text":"hello","group_id":1,"recipient_id":1,"send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusUnprocessableEntity},
+		{"get group notification", http.MethodGet, "/notifications/4", "", false, http.StatusOK},
+		{"group deliveries", http.MethodGet, "/notifications/4/deliveries", "", false, http.StatusOK},
+		{"deliveries of missing", http.MethodGet, "/notifications/3/deliveries", "", false, http.StatusNotFound},
+		{"create group", http.MethodPost, "/groups", `{"name":"Team"}`, false, http.StatusOK},
+		{"create group without name", http.MethodPost, "/groups", `{"name":""}`, true, http.StatusUnprocessableEntity},
+		{"list groups", http.MethodGet, "/groups", "", false, http.StatusOK},
+		{"get group", http.MethodGet, "/groups/1", "", false, http.StatusOK},
+		{"get missing group", http.MethodGet, "/groups/3", "", false, http.StatusNotFound},
+		{"delete group", http.MethodDelete, "/groups/1", "", false, http.StatusNoContent},
+		{"add group member", http.MethodPost, "/groups/1/members", `{"recipient_id":1}`, false, http.StatusOK},
+		{"add missing recipient to group", http.MethodPost, "/groups/1/members", `{"recipient_id":3}`, false, http.StatusUnprocessableEntity},
+		{"remove group member", http.MethodDelete, "/groups/1/members/1", "", false, http.StatusNoContent},
+		{"remove non-member", http.MethodDelete, "/groups/1/members/2", "", false, http.StatusNotFound},
+		{"create with category", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"category":"billing","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, false, http.StatusOK},
+		{"create with invalid category", http.MethodPost, "/notifications", `{"text":"hello","recipient_id":1,"category":"Billing!","send_at":"` + sendAt.Format(time.RFC3339) + `"}`, true, http.StatusUnprocessableEntity},
+		{"get preferences", http.MethodGet, "/recipients/1/preferences", "", false, http.StatusOK},
+		{"get preferences of missing recipient", http.MethodGet, "/recipients/3/preferences", "", false, http.StatusNotFound},
+		{"update preferences", http.MethodPut, "/recipients/1/preferences", `{"muted_categories":["billing"],"opted_out_channels":["email"]}`, false, http.StatusOK},
+		{"update preferences with unknown channel", http.MethodPut, "/recipients/1/preferences", `{"opted_out_channels":["pager"]}`, true, http.StatusUnprocessableEntity},
+		{"turn on digests", http.MethodPut, "/recipients/1/preferences", `{"digest_window":300,"digest_categories":["reminders"]}`, false, http.StatusOK},
+		{"turn on digests with too long window", http.MethodPut, "/recipients/1/preferences", `{"digest_window":100000}`, true, http.StatusUnprocessableEntity},
+		{"unsubscribe", http.MethodGet, "/unsubscribe?token=signed", "", false, http.StatusOK},
+		{"unsubscribe with one click", http.MethodPost, "/unsubscribe?token=signed", "", false, http.StatusOK},
+		{"unsubscribe with forged token", http.MethodGet, "/unsubscribe?token=forged", "", false, http.StatusUnprocessableEntity},
+		{"unsubscribe without token", http.MethodGet, "/unsubscribe", "", true, http.StatusUnprocessableEntity},
+		{"spec", http.MethodGet, "/openapi.yaml", "", false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, specRouter := "http://localhost/api/v1", publicRouter
+			if strings.HasPrefix(tt.path, "/admin/") {
+				base, specRouter = "http://localhost", adminRouter
+			}
+			req := httptest.NewRequest(tt.method, base+tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			route, pathParams, err := specRouter.FindRoute(req)
+			require.NoError(t, err)
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{IncludeResponseStatus: true},
+			}
+
+			err = openapi3filter.ValidateRequest(context.Background(), input)
+			if tt.invalid {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Validation consumed the body, serve a fresh copy.
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedCode, w.Code, w.Body.String())
+
+			assert.NoError(t, validateResponse(input, w))
+		})
+	}
+}
+
+// specRouter routes requests to the public paths of the spec, or to the
+// admin ones, which have servers of their own. The spec uses relative
+// server urls, requests are routed by path only.
+func specRouter(t *testing.T, admin bool) routers.Router {
+	t.Helper()
+
+	spec := loadSpec(t)
+	spec.Servers = openapi3.Servers{{URL: "http://localhost/api/v1"}}
+	if admin {
+		spec.Servers = openapi3.Servers{{URL: "http://localhost"}}
+	}
+	for path, item := range spec.Paths {
+		if (len(item.Servers) > 0) != admin {
+			delete(spec.Paths, path)
+		}
+		item.Servers = nil
+	}
+
+	router, err := gorillamux.NewRouter(spec)
+	require.NoError(t, err)
+	return router
+}
+
+func validateResponse(input *openapi3filter.RequestValidationInput, w *httptest.ResponseRecorder) error {
+	return openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options:                input.Options,
+	})
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// unsubscribePage asks to confirm an unsubscribe link and reports that it
+// was processed.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Unsubscribe</title>
+</head>
+<body>
+{{- if .Done}}
+    <p>You are unsubscribed.</p>
+{{- else}}
+    <form method="post" action="?token={{.Token}}">
+        <p>{{if .Category}}Stop receiving notifications of category {{.Category}}?{{else}}Stop receiving all notifications?{{end}}</p>
+        <button type="submit">Unsubscribe</button>
+    </form>
+{{- end}}
+</body>
+</html>
+`))
+
+type unsubscribeView struct {
+	Token    string
+	Category string
+	Done     bool
+}
+
+// GetPreferences serves GET /api/v1/recipients/{id}/preferences, see
+// getPreferences in api/openapi.yaml.
+func (h *Handler) GetPreferences(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.service.GetPreferences(id)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting preferences of recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewPreferencesResponse(*preferences))
+}
+
+// UpdatePreferences serves PUT /api/v1/recipients/{id}/preferences, see
+// updatePreferences in api/openapi.yaml.
+func (h *Handler) UpdatePreferences(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.PreferencesRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	preferences, err := h.service.UpdatePreferences(request.Model(id))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled PUT request for updating preferences of recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewPreferencesResponse(*preferences))
+}
+
+// ConfirmUnsubscribe serves GET /api/v1/unsubscribe, the target of
+// unsubscribe links, see unsubscribe in api/openapi.yaml. Link previews and
+// mail scanners open links on their own, so it only checks the token and
+// asks to confirm with a POST.
+func (h *Handler) ConfirmUnsubscribe(c *ginext.Context) {
+	token, ok := unsubscribeToken(c)
+	if !ok {
+		return
+	}
+
+	verified, err := h.service.VerifyUnsubscribe(token)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Render(http.StatusOK, render.HTML{
+		Template: unsubscribePage,
+		Data:     unsubscribeView{Token: token, Category: verified.Category},
+	})
+}
+
+// Unsubscribe serves POST /api/v1/unsubscribe, sent by the confirmation
+// page and by the one click unsubscribe of mail clients (RFC 8058), see
+// unsubscribeOneClick in api/openapi.yaml. Browsers get a page, other
+// clients the updated preferences.
+func (h *Handler) Unsubscribe(c *ginext.Context) {
+	token, ok := unsubscribeToken(c)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.service.Unsubscribe(token)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled unsubscribe request of recipient with id: %d", preferences.RecipientId)
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		c.Render(http.StatusOK, render.HTML{Template: unsubscribePage, Data: unsubscribeView{Done: true}})
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewPreferencesResponse(*preferences))
+}
+
+func unsubscribeToken(c *ginext.Context) (string, bool) {
+	token := c.Query("token")
+	if token == "" {
+		abort(c, apperr.Validation("token is required", apperr.FieldError{
+			Field:   "token",
+			Message: "is required",
+		}))
+		return "", false
+	}
+	return token, true
+}
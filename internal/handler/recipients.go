@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// CreateRecipient serves POST /api/v1/recipients, see createRecipient in
+// api/openapi.yaml.
+func (h *Handler) CreateRecipient(c *ginext.Context) {
+	var request dto.CreateRecipientRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	recipient, err := h.service.CreateRecipient(request.Model())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request creating recipient with id: %d", recipient.Id)
+	c.JSON(http.StatusOK, dto.NewRecipientResponse(*recipient))
+}
+
+// GetAllRecipients serves GET /api/v1/recipients, see listRecipients in
+// api/openapi.yaml.
+func (h *Handler) GetAllRecipients(c *ginext.Context) {
+	recipients, err := h.service.GetAllRecipients()
+	if err != nil {
+		abort(c, apperr.Internal("could not get recipients", err))
+		return
+	}
+
+	response := make([]dto.RecipientResponse, 0, len(recipients))
+	for _, recipient := range recipients {
+		response = append(response, dto.NewRecipientResponse(recipient))
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request for getting all recipients")
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRecipient serves GET /api/v1/recipients/{id}, see getRecipient in
+// api/openapi.yaml.
+func (h *Handler) GetRecipient(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	recipient, err := h.service.GetRecipient(id)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled GET request for getting recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewRecipientResponse(*recipient))
+}
+
+// UpdateRecipient serves PUT /api/v1/recipients/{id}, see updateRecipient
+// in api/openapi.yaml.
+func (h *Handler) UpdateRecipient(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateRecipientRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	recipient, err := h.service.RenameRecipient(id, request.Name)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled PUT request for updating recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewRecipientResponse(*recipient))
+}
+
+// DeleteRecipient serves DELETE /api/v1/recipients/{id}, see
+// deleteRecipient in api/openapi.yaml.
+func (h *Handler) DeleteRecipient(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRecipient(id); err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled DELETE request for deleting recipient with id: %d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// AddContact serves POST /api/v1/recipients/{id}/contacts, see addContact
+// in api/openapi.yaml.
+func (h *Handler) AddContact(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.ContactRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if fields := request.Validate(); len(fields) > 0 {
+		abort(c, apperr.Validation("invalid payload", fields...))
+		return
+	}
+
+	contact, err := h.service.AddContact(id, request.Model())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request adding contact to recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewContactResponse(*contact))
+}
+
+// DeleteContact serves DELETE /api/v1/recipients/{id}/contacts/{contact_id},
+// see deleteContact in api/openapi.yaml.
+func (h *Handler) DeleteContact(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	contactId, ok := intParam(c, "contact_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteContact(id, contactId); err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled DELETE request for deleting contact with id: %d", contactId)
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyContact serves POST
+// /api/v1/recipients/{id}/contacts/{contact_id}/verify, see verifyContact
+// in api/openapi.yaml.
+func (h *Handler) VerifyContact(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	contactId, ok := intParam(c, "contact_id")
+	if !ok {
+		return
+	}
+
+	var request dto.VerifyContactRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	contact, err := h.service.VerifyContact(id, contactId, request.Code)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled POST request verifying contact with id: %d", contactId)
+	c.JSON(http.StatusOK, dto.NewContactResponse(*contact))
+}
+
+// ReorderContacts serves PUT /api/v1/recipients/{id}/contacts/order, see
+// reorderContacts in api/openapi.yaml.
+func (h *Handler) ReorderContacts(c *ginext.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var request dto.ReorderContactsRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	recipient, err := h.service.ReorderContacts(id, request.ContactIds)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msgf("successfully handled PUT request reordering contacts of recipient with id: %d", id)
+	c.JSON(http.StatusOK, dto.NewRecipientResponse(*recipient))
+}
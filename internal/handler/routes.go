@@ -0,0 +1,51 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes registers the REST API described by api/openapi.yaml on
+// router, which is expected to be the /api/v1 group.
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/openapi.yaml", h.GetOpenAPI)
+
+	router.POST("/notifications", h.CreateNotification)
+	router.GET("/notifications", h.GetAllNotifications)
+	router.GET("/notifications/stream", h.StreamNotifications)
+	router.GET("/notifications/:id", h.GetNotification)
+	router.GET("/notifications/:id/history", h.GetDeliveryHistory)
+	router.GET("/notifications/:id/deliveries", h.GetGroupDeliveries)
+	router.GET("/notifications/:id/follow-ups", h.GetFollowUps)
+	router.POST("/notifications/:id/snooze", h.SnoozeNotification)
+	router.POST("/notifications/:id/resend", h.ResendNotification)
+	router.DELETE("/notifications/:id", h.UpdateNotificationStatus)
+
+	router.POST("/recipients", h.CreateRecipient)
+	router.GET("/recipients", h.GetAllRecipients)
+	router.GET("/recipients/:id", h.GetRecipient)
+	router.PUT("/recipients/:id", h.UpdateRecipient)
+	router.DELETE("/recipients/:id", h.DeleteRecipient)
+	router.POST("/recipients/:id/contacts", h.AddContact)
+	router.PUT("/recipients/:id/contacts/order", h.ReorderContacts)
+	router.DELETE("/recipients/:id/contacts/:contact_id", h.DeleteContact)
+	router.POST("/recipients/:id/contacts/:contact_id/verify", h.VerifyContact)
+	router.GET("/recipients/:id/preferences", h.GetPreferences)
+	router.PUT("/recipients/:id/preferences", h.UpdatePreferences)
+
+	router.GET("/unsubscribe", h.ConfirmUnsubscribe)
+	router.POST("/unsubscribe", h.Unsubscribe)
+
+	router.POST("/groups", h.CreateGroup)
+	router.GET("/groups", h.GetAllGroups)
+	router.GET("/groups/:id", h.GetGroup)
+	router.DELETE("/groups/:id", h.DeleteGroup)
+	router.POST("/groups/:id/members", h.AddGroupMember)
+	router.DELETE("/groups/:id/members/:recipient_id", h.RemoveGroupMember)
+}
+
+// RegisterAdminRoutes registers the routes that must not be reachable by
+// API clients on router, which is expected to serve the admin listener.
+func (h *Handler) RegisterAdminRoutes(router gin.IRouter) {
+	router.GET("/admin/workers", h.GetWorkerPool)
+	router.PUT("/admin/workers", h.UpdateWorkerPool)
+	router.DELETE("/admin/notifications/:id", h.DeleteNotification)
+	router.GET("/admin/catchup", h.GetCatchUpReport)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/apperr"
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/gorilla/websocket"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// heartbeatInterval keeps idle streams open behind proxies.
+const heartbeatInterval = 15 * time.Second
+
+var upgrader = websocket.Upgrader{}
+
+// StreamNotifications serves GET /api/v1/notifications/stream, see
+// streamNotifications in api/openapi.yaml.
+func (h *Handler) StreamNotifications(c *ginext.Context) {
+	var filter model.EventFilter
+	params := map[string]*int{"id": &filter.Id, "telegram_id": &filter.TelegramId, "recipient_id": &filter.RecipientId}
+	for param, value := range params {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			abort(c, apperr.Validation("invalid stream filter was provided", apperr.FieldError{
+				Field:   param,
+				Message: "must be an integer",
+			}))
+			return
+		}
+		*value = parsed
+	}
+
+	events, unsubscribe := h.service.SubscribeEvents(filter)
+	defer unsubscribe()
+
+	zlog.Logger.Info().Msg("started notification stream")
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		streamWebSocket(c, events)
+	} else {
+		streamSSE(c, events)
+	}
+	zlog.Logger.Info().Msg("finished notification stream")
+}
+
+func streamSSE(c *ginext.Context, events <-chan model.Event) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, dto.NewEventResponse(event))
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func streamWebSocket(c *ginext.Context, events <-chan model.Event) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied with an error.
+		zlog.Logger.Error().Msg("could not upgrade to websocket: " + err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// The client does not send anything, reading only notices it is gone.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(dto.NewEventResponse(event)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
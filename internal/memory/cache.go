@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/cache"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/go-redis/redis/v8"
+)
+
+// cacheEntry with a nil notification remembers a missing id.
+type cacheEntry struct {
+	notification *model.Notification
+	expiresAt    time.Time
+}
+
+// Cache reports missing and expired keys with redis.Nil, like the redis
+// cache does, so the service handles both the same way. Expiration follows
+// cache.TTL.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[int]cacheEntry
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		entries: make(map[int]cacheEntry),
+	}
+}
+
+func (c *Cache) GetNotification(id int) (*model.Notification, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.get(id)
+	if !ok {
+		return nil, redis.Nil
+	}
+
+	if entry.notification == nil {
+		return nil, repository.ErrNoSuchNotification
+	}
+
+	notification := *entry.notification
+	return &notification, nil
+}
+
+func (c *Cache) SetNotification(notification model.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(notification)
+	return nil
+}
+
+func (c *Cache) SetNotifications(notifications []model.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, notification := range notifications {
+		c.set(notification)
+	}
+	return nil
+}
+
+// SetMissing does not replace a cached notification: it may have been
+// created after the caller looked it up.
+func (c *Cache) SetMissing(id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.get(id); ok {
+		return nil
+	}
+
+	c.entries[id] = cacheEntry{expiresAt: time.Now().Add(cache.MissingTTL)}
+	return nil
+}
+
+func (c *Cache) DeleteNotification(id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+	return nil
+}
+
+// get must be called with c.mu held.
+func (c *Cache) get(id int) (cacheEntry, bool) {
+	entry, ok := c.entries[id]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, id)
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// set must be called with c.mu held.
+func (c *Cache) set(notification model.Notification) {
+	if entry, ok := c.get(notification.Id); ok && entry.notification != nil && entry.notification.Version > notification.Version {
+		return
+	}
+
+	now := time.Now()
+	c.entries[notification.Id] = cacheEntry{
+		notification: &notification,
+		expiresAt:    now.Add(cache.TTL(notification, now)),
+	}
+}
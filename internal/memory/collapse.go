@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+func (s *Storage) CollapseNotification(notification model.Notification, policy string) (*model.CollapseResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earlier []model.Notification
+	for _, n := range s.notifications {
+		if n.CollapseKey == notification.CollapseKey && n.Status == "active" &&
+			n.TelegramId == notification.TelegramId && n.RecipientId == notification.RecipientId && n.GroupId == notification.GroupId {
+			earlier = append(earlier, n)
+		}
+	}
+	sort.Slice(earlier, func(i, j int) bool {
+		return earlier[i].Id < earlier[j].Id
+	})
+
+	switch {
+	case len(earlier) == 0:
+		return &model.CollapseResult{Notification: s.insert(notification), Created: true}, nil
+
+	case policy == model.CollapseKeepFirst:
+		return &model.CollapseResult{Notification: earlier[0]}, nil
+
+	case policy == model.CollapseMerge:
+		merged := earlier[0]
+		merged.Text = notification.Text
+		merged.Options = notification.Options
+		merged.Channels = notification.Channels
+		merged.Category = notification.Category
+		merged.SendAt = min(merged.SendAt, notification.SendAt)
+		merged.ExpiresAt = notification.ExpiresAt
+		merged.Claims = 0
+		merged.Version++
+		s.notifications[merged.Id] = merged
+		delete(s.claimedUntil, merged.Id)
+		return &model.CollapseResult{Notification: merged, Merged: true}, nil
+	}
+
+	result := model.CollapseResult{Created: true}
+	for _, n := range earlier {
+		n.Status = "canceled"
+		n.Version++
+		s.notifications[n.Id] = n
+		result.Canceled = append(result.Canceled, n.Id)
+	}
+	result.Notification = s.insert(notification)
+	return &result, nil
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+func (s *Storage) AssignDigest(leaderId, recipientId, dueBefore int, categories []string) ([]model.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []model.Notification
+	for id, n := range s.notifications {
+		if n.RecipientId != recipientId || n.Status != "active" || n.SendAt >= dueBefore ||
+			n.Options != nil || len(n.Channels) != 0 {
+			continue
+		}
+		if len(categories) != 0 && !slices.Contains(categories, n.Category) {
+			continue
+		}
+		if n.DigestId != 0 && n.DigestId != leaderId && s.leadsDigest(n.DigestId) {
+			continue
+		}
+
+		n.DigestId = leaderId
+		s.notifications[id] = n
+		notifications = append(notifications, n)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].SendAt != notifications[j].SendAt {
+			return notifications[i].SendAt < notifications[j].SendAt
+		}
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+func (s *Storage) LeaveDigest(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, ok := s.notifications[id]
+	if !ok {
+		return repository.ErrNoSuchNotification
+	}
+
+	notification.DigestId = 0
+	s.notifications[id] = notification
+	return nil
+}
+
+// leadsDigest reports whether the notification is an active digest, see
+// repository.AssignDigest.
+func (s *Storage) leadsDigest(id int) bool {
+	leader, ok := s.notifications[id]
+	return ok && leader.Status == "active" && leader.DigestId == id
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+func (s *Storage) CreateGroup(group model.Group) (*model.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastGroupId++
+	group.Id = s.lastGroupId
+	group.CreatedAt = time.Now()
+	group.Members = []int{}
+	s.groups[group.Id] = group
+
+	return copyGroup(group), nil
+}
+
+func (s *Storage) GetGroup(id int) (*model.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	group, ok := s.groups[id]
+	if !ok {
+		return nil, repository.ErrNoSuchGroup
+	}
+
+	return copyGroup(group), nil
+}
+
+func (s *Storage) GetAllGroups() ([]model.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var groups []model.Group
+	for _, group := range s.groups {
+		groups = append(groups, *copyGroup(group))
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Id < groups[j].Id
+	})
+	return groups, nil
+}
+
+func (s *Storage) DeleteGroup(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return repository.ErrNoSuchGroup
+	}
+
+	delete(s.groups, id)
+	return nil
+}
+
+func (s *Storage) AddGroupMember(groupId, recipientId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[groupId]
+	if !ok {
+		return repository.ErrNoSuchGroup
+	}
+	if _, ok := s.recipients[recipientId]; !ok {
+		return repository.ErrNoSuchRecipient
+	}
+
+	i := sort.SearchInts(group.Members, recipientId)
+	if i < len(group.Members) && group.Members[i] == recipientId {
+		return nil
+	}
+
+	members := append(append(append([]int{}, group.Members[:i]...), recipientId), group.Members[i:]...)
+	group.Members = members
+	s.groups[groupId] = group
+	return nil
+}
+
+func (s *Storage) RemoveGroupMember(groupId, recipientId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[groupId]
+	if !ok {
+		return repository.ErrNotGroupMember
+	}
+
+	members := removeMember(group.Members, recipientId)
+	if len(members) == len(group.Members) {
+		return repository.ErrNotGroupMember
+	}
+
+	group.Members = members
+	s.groups[groupId] = group
+	return nil
+}
+
+func copyGroup(group model.Group) *model.Group {
+	group.Members = append([]int{}, group.Members...)
+	return &group
+}
+
+// removeMember returns a copy of members without the recipient.
+func removeMember(members []int, recipientId int) []int {
+	kept := make([]int, 0, len(members))
+	for _, id := range members {
+		if id != recipientId {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
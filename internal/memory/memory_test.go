@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/repository/storagetest"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) service.Storage {
+		return NewStorage()
+	})
+}
+
+func TestCache_GetSet(t *testing.T) {
+	cache := NewCache()
+
+	_, err := cache.GetNotification(1)
+	assert.ErrorIs(t, err, redis.Nil)
+
+	notification := model.Notification{Id: 1, Text: "Hello", Status: "active", SendAt: int(time.Now().Add(time.Hour).UnixMilli()), Version: 1}
+	require.NoError(t, cache.SetNotification(notification))
+	got, err := cache.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, notification, *got)
+
+	require.NoError(t, cache.DeleteNotification(1))
+	_, err = cache.GetNotification(1)
+	assert.ErrorIs(t, err, redis.Nil)
+}
+
+func TestCache_KeepsNewerVersion(t *testing.T) {
+	cache := NewCache()
+
+	require.NoError(t, cache.SetNotification(model.Notification{Id: 1, Status: "canceled", Version: 3}))
+	require.NoError(t, cache.SetNotification(model.Notification{Id: 1, Status: "active", Version: 2}))
+
+	got, err := cache.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", got.Status)
+	assert.Equal(t, 3, got.Version)
+
+	require.NoError(t, cache.SetNotifications([]model.Notification{{Id: 1, Status: "completed", Version: 4}}))
+	got, err = cache.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", got.Status)
+}
+
+func TestCache_Missing(t *testing.T) {
+	cache := NewCache()
+
+	require.NoError(t, cache.SetMissing(1))
+	_, err := cache.GetNotification(1)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+
+	require.NoError(t, cache.SetNotification(model.Notification{Id: 1, Status: "active", Version: 1}))
+	got, err := cache.GetNotification(1)
+	require.NoError(t, err)
+	assert.Equal(t, "active", got.Status)
+
+	require.NoError(t, cache.SetMissing(1))
+	_, err = cache.GetNotification(1)
+	assert.NoError(t, err, "a cached notification must not be replaced with a missing marker")
+}
+
+func TestQueue_PublishConsume(t *testing.T) {
+	queue := NewQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for id := 1; id <= 3; id++ {
+		require.NoError(t, queue.Publish(model.Notification{Id: id}))
+	}
+
+	messages, err := queue.Consume(ctx)
+	require.NoError(t, err)
+
+	for id := 1; id <= 3; id++ {
+		var notification model.Notification
+		msg := <-messages
+		require.NoError(t, json.Unmarshal(msg.Body, &notification))
+		require.NoError(t, msg.Ack())
+		assert.Equal(t, id, notification.Id)
+	}
+
+	require.NoError(t, queue.Publish(model.Notification{Id: 4}))
+	select {
+	case msg := <-messages:
+		var notification model.Notification
+		require.NoError(t, json.Unmarshal(msg.Body, &notification))
+		assert.Equal(t, 4, notification.Id)
+	case <-time.After(time.Second):
+		t.Fatal("message published after Consume was not delivered")
+	}
+
+	cancel()
+	_, ok := <-messages
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// Queue is an unbounded FIFO queue. Publish never blocks, messages are
+// handed to the consumer one at a time in the order they were published.
+type Queue struct {
+	mu       sync.Mutex
+	messages [][]byte
+	ready    chan struct{}
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		ready: make(chan struct{}, 1),
+	}
+}
+
+func (q *Queue) Publish(notification model.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("could not marshal notification to publish: %w", err)
+	}
+
+	q.mu.Lock()
+	q.messages = append(q.messages, body)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Consume returns a channel of published messages, which is closed when ctx
+// is canceled. Messages left in the queue stay there. Acknowledging does
+// nothing, the queue does not outlive the process anyway.
+func (q *Queue) Consume(ctx context.Context) (<-chan model.QueueMessage, error) {
+	messages := make(chan model.QueueMessage)
+
+	go func() {
+		defer close(messages)
+
+		for {
+			msg, ok := q.pop()
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-q.ready:
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				q.pushFront(msg)
+				return
+			case messages <- model.QueueMessage{Body: msg, Ack: func() error { return nil }}:
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// SetPrefetch is a no-op, messages are always handed out one at a time.
+func (q *Queue) SetPrefetch(int) error {
+	return nil
+}
+
+// Len returns the number of messages waiting to be consumed.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+func (q *Queue) pop() ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.messages) == 0 {
+		return nil, false
+	}
+
+	msg := q.messages[0]
+	q.messages = q.messages[1:]
+	return msg, true
+}
+
+func (q *Queue) pushFront(msg []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages = append([][]byte{msg}, q.messages...)
+}
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+func (s *Storage) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRecipientId++
+	recipient.Id = s.lastRecipientId
+	recipient.CreatedAt = time.Now()
+
+	contacts := make([]model.Contact, 0, len(recipient.Contacts))
+	for i, contact := range recipient.Contacts {
+		if hasContact(contacts, contact) {
+			s.lastRecipientId--
+			return nil, repository.ErrDuplicateContact
+		}
+		contact.Position = i
+		contacts = append(contacts, contact)
+	}
+	for i := range contacts {
+		s.lastContactId++
+		contacts[i].Id = s.lastContactId
+		contacts[i].CreatedAt = recipient.CreatedAt
+	}
+	recipient.Contacts = contacts
+	s.recipients[recipient.Id] = recipient
+
+	return copyRecipient(recipient), nil
+}
+
+func (s *Storage) GetRecipient(id int) (*model.Recipient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipient, ok := s.recipients[id]
+	if !ok {
+		return nil, repository.ErrNoSuchRecipient
+	}
+
+	return copyRecipient(recipient), nil
+}
+
+func (s *Storage) GetAllRecipients() ([]model.Recipient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var recipients []model.Recipient
+	for _, recipient := range s.recipients {
+		recipients = append(recipients, *copyRecipient(recipient))
+	}
+
+	sort.Slice(recipients, func(i, j int) bool {
+		return recipients[i].Id < recipients[j].Id
+	})
+	return recipients, nil
+}
+
+func (s *Storage) RenameRecipient(id int, name string) error {
+	return s.updateRecipient(id, func(recipient *model.Recipient) error {
+		recipient.Name = name
+		return nil
+	})
+}
+
+func (s *Storage) DeleteRecipient(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipients[id]; !ok {
+		return repository.ErrNoSuchRecipient
+	}
+
+	delete(s.recipients, id)
+	delete(s.preferences, id)
+	for groupId, group := range s.groups {
+		group.Members = removeMember(group.Members, id)
+		s.groups[groupId] = group
+	}
+	return nil
+}
+
+func (s *Storage) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	err := s.updateRecipient(recipientId, func(recipient *model.Recipient) error {
+		if hasContact(recipient.Contacts, contact) {
+			return repository.ErrDuplicateContact
+		}
+
+		s.lastContactId++
+		contact.Id = s.lastContactId
+		contact.CreatedAt = time.Now()
+		contact.Position = 0
+		if n := len(recipient.Contacts); n > 0 {
+			contact.Position = recipient.Contacts[n-1].Position + 1
+		}
+		recipient.Contacts = append(recipient.Contacts, contact)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+func (s *Storage) DeleteContact(recipientId, contactId int) error {
+	return s.updateContacts(recipientId, func(recipient *model.Recipient) error {
+		i := contactIndex(recipient.Contacts, contactId)
+		if i < 0 {
+			return repository.ErrNoSuchContact
+		}
+
+		recipient.Contacts = append(recipient.Contacts[:i], recipient.Contacts[i+1:]...)
+		return nil
+	})
+}
+
+func (s *Storage) VerifyContact(recipientId, contactId int) error {
+	return s.updateContacts(recipientId, func(recipient *model.Recipient) error {
+		i := contactIndex(recipient.Contacts, contactId)
+		if i < 0 {
+			return repository.ErrNoSuchContact
+		}
+
+		recipient.Contacts[i].Verified = true
+		recipient.Contacts[i].VerificationCode = ""
+		return nil
+	})
+}
+
+func (s *Storage) ReorderContacts(recipientId int, contactIds []int) error {
+	return s.updateContacts(recipientId, func(recipient *model.Recipient) error {
+		for position, id := range contactIds {
+			i := contactIndex(recipient.Contacts, id)
+			if i < 0 {
+				return repository.ErrNoSuchContact
+			}
+			recipient.Contacts[i].Position = position
+		}
+
+		sort.SliceStable(recipient.Contacts, func(i, j int) bool {
+			return recipient.Contacts[i].Position < recipient.Contacts[j].Position
+		})
+		return nil
+	})
+}
+
+func (s *Storage) GetPreferences(recipientId int) (*model.Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.recipients[recipientId]; !ok {
+		return nil, repository.ErrNoSuchRecipient
+	}
+
+	preferences, ok := s.preferences[recipientId]
+	if !ok {
+		preferences = model.Preferences{RecipientId: recipientId}
+	}
+	preferences.MutedCategories = append([]string{}, preferences.MutedCategories...)
+	preferences.OptedOutChannels = append([]string{}, preferences.OptedOutChannels...)
+	preferences.DigestCategories = append([]string{}, preferences.DigestCategories...)
+	return &preferences, nil
+}
+
+func (s *Storage) SavePreferences(preferences model.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipients[preferences.RecipientId]; !ok {
+		return repository.ErrNoSuchRecipient
+	}
+
+	preferences.MutedCategories = append([]string{}, preferences.MutedCategories...)
+	preferences.OptedOutChannels = append([]string{}, preferences.OptedOutChannels...)
+	preferences.DigestCategories = append([]string{}, preferences.DigestCategories...)
+	s.preferences[preferences.RecipientId] = preferences
+	return nil
+}
+
+// updateRecipient applies change to a copy of the recipient and saves it if
+// change succeeds.
+func (s *Storage) updateRecipient(id int, change func(*model.Recipient) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.recipients[id]
+	if !ok {
+		return repository.ErrNoSuchRecipient
+	}
+
+	recipient := copyRecipient(stored)
+	if err := change(recipient); err != nil {
+		return err
+	}
+
+	s.recipients[id] = *recipient
+	return nil
+}
+
+// updateContacts is updateRecipient reporting a missing recipient as a
+// missing contact, like the SQL backends do.
+func (s *Storage) updateContacts(recipientId int, change func(*model.Recipient) error) error {
+	err := s.updateRecipient(recipientId, change)
+	if err == repository.ErrNoSuchRecipient {
+		return repository.ErrNoSuchContact
+	}
+	return err
+}
+
+func copyRecipient(recipient model.Recipient) *model.Recipient {
+	recipient.Contacts = append([]model.Contact(nil), recipient.Contacts...)
+	return &recipient
+}
+
+func hasContact(contacts []model.Contact, contact model.Contact) bool {
+	for _, existing := range contacts {
+		if existing.Channel == contact.Channel && existing.Address == contact.Address {
+			return true
+		}
+	}
+	return false
+}
+
+func contactIndex(contacts []model.Contact, id int) int {
+	for i, contact := range contacts {
+		if contact.Id == id {
+			return i
+		}
+	}
+	return -1
+}
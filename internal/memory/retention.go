@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// PurgeNotifications deletes up to limit notifications in scope, with the
+// deliveries of group notifications among them, and returns the ids of all
+// deleted notifications. Group notifications with active deliveries are
+// kept.
+func (s *Storage) PurgeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted []int
+	for _, id := range s.inScope(scope, limit, s.hasActiveDeliveries) {
+		if _, ok := s.notifications[id]; ok {
+			deleted = append(deleted, s.deleteWithDeliveries(id)...)
+		}
+	}
+
+	return deleted, nil
+}
+
+// AnonymizeNotifications clears the text and options of up to limit
+// notifications in scope that still have a text, and the addresses and
+// errors of their delivery attempts, and returns their ids.
+func (s *Storage) AnonymizeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := s.inScope(scope, limit, func(n model.Notification) bool { return n.Text == "" })
+	for _, id := range ids {
+		notification := s.notifications[id]
+		notification.Text = ""
+		notification.Options = nil
+		notification.Version++
+		s.notifications[id] = notification
+
+		for i := range s.attempts[id] {
+			s.attempts[id][i].Address = ""
+			s.attempts[id][i].Error = ""
+		}
+	}
+
+	return ids, nil
+}
+
+// inScope returns the ids of up to limit notifications in scope that are
+// not skipped, ordered by id.
+func (s *Storage) inScope(scope model.RetentionScope, limit int, skip func(model.Notification) bool) []int {
+	var ids []int
+	for id, notification := range s.notifications {
+		if scope.Matches(notification) && !skip(notification) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func (s *Storage) hasActiveDeliveries(notification model.Notification) bool {
+	for _, child := range s.notifications {
+		if child.ParentId == notification.Id && child.Status == "active" {
+			return true
+		}
+	}
+	return false
+}
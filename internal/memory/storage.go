@@ -0,0 +1,336 @@
+// Package memory implements the service storage, cache and queue in process
+// memory, so the notifier can run without Postgres, Redis and RabbitMQ.
+// Nothing survives a restart.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+// readyWindow matches the window used by repository.GetReadyNotifications.
+const readyWindow = 30 * time.Second
+
+type Storage struct {
+	mu            sync.RWMutex
+	lastId        int
+	notifications map[int]model.Notification
+	claimedUntil  map[int]int
+	subscribers   map[string]model.Subscriber
+	unreachable   map[int]string
+
+	lastRecipientId int
+	lastContactId   int
+	recipients      map[int]model.Recipient
+	preferences     map[int]model.Preferences
+
+	lastAttemptId int
+	attempts      map[int][]model.DeliveryAttempt
+
+	lastGroupId int
+	groups      map[int]model.Group
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		notifications: make(map[int]model.Notification),
+		claimedUntil:  make(map[int]int),
+		subscribers:   make(map[string]model.Subscriber),
+		unreachable:   make(map[int]string),
+		recipients:    make(map[int]model.Recipient),
+		preferences:   make(map[int]model.Preferences),
+		attempts:      make(map[int][]model.DeliveryAttempt),
+		groups:        make(map[int]model.Group),
+	}
+}
+
+func (s *Storage) CreateNotification(notification model.Notification) (*model.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := s.insert(notification)
+	return &created, nil
+}
+
+func (s *Storage) insert(notification model.Notification) model.Notification {
+	s.lastId++
+	notification.Id = s.lastId
+	notification.CreatedAt = time.Now()
+	notification.Version = 1
+	s.notifications[notification.Id] = notification
+	return notification
+}
+
+func (s *Storage) DeleteNotificationById(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteWithDeliveries(id)
+	return nil
+}
+
+// deleteWithDeliveries deletes the notification and, if it is a group
+// notification, its deliveries, and returns the ids of all of them.
+func (s *Storage) deleteWithDeliveries(id int) []int {
+	deleted := []int{id}
+	for childId, child := range s.notifications {
+		if child.ParentId == id {
+			s.deleteNotification(childId)
+			deleted = append(deleted, childId)
+		}
+	}
+	s.deleteNotification(id)
+	return deleted
+}
+
+// deleteNotification deletes the notification with its delivery history
+// and unlinks its follow-ups.
+func (s *Storage) deleteNotification(id int) {
+	delete(s.notifications, id)
+	delete(s.claimedUntil, id)
+	delete(s.attempts, id)
+
+	for followUpId, followUp := range s.notifications {
+		if followUp.FollowUpOf == id {
+			followUp.FollowUpOf = 0
+			s.notifications[followUpId] = followUp
+		}
+	}
+}
+
+func (s *Storage) GetNotificationById(id int) (*model.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notification, ok := s.notifications[id]
+	if !ok {
+		return nil, repository.ErrNoSuchNotification
+	}
+
+	return &notification, nil
+}
+
+func (s *Storage) GetAllNotifications() ([]model.Notification, error) {
+	notifications := s.filter(func(model.Notification) bool { return true })
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+// GetNotificationsAfter returns up to limit notifications with an id
+// greater than afterId ordered by id.
+func (s *Storage) GetNotificationsAfter(afterId, limit int) ([]model.Notification, error) {
+	notifications := s.filter(func(n model.Notification) bool { return n.Id > afterId })
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Id < notifications[j].Id
+	})
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (s *Storage) GetReadyNotifications() ([]model.Notification, error) {
+	deadline := time.Now().Add(readyWindow).UnixMilli()
+
+	notifications := s.filter(func(n model.Notification) bool {
+		return n.Status == "active" && int64(n.SendAt) < deadline
+	})
+	return notifications, nil
+}
+
+func (s *Storage) ClaimReadyNotifications(leaseUntil int) ([]model.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	deadline := now.Add(readyWindow).UnixMilli()
+
+	var notifications []model.Notification
+	for id, n := range s.notifications {
+		if n.Status != "active" || int64(n.SendAt) >= deadline || int64(s.claimedUntil[id]) >= now.UnixMilli() {
+			continue
+		}
+
+		s.claimedUntil[id] = leaseUntil
+		n.Claims++
+		s.notifications[id] = n
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+func (s *Storage) GetUpcomingNotifications(telegramId int) ([]model.Notification, error) {
+	notifications := s.filter(func(n model.Notification) bool {
+		return n.Status == "active" && n.TelegramId == telegramId
+	})
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].SendAt < notifications[j].SendAt
+	})
+	return notifications, nil
+}
+
+func (s *Storage) GetChildNotifications(parentId int) ([]model.Notification, error) {
+	notifications := s.filter(func(n model.Notification) bool {
+		return n.ParentId == parentId
+	})
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+// GetFollowUps returns the notifications snoozed or resent from the
+// notification ordered by id.
+func (s *Storage) GetFollowUps(id int) ([]model.Notification, error) {
+	notifications := s.filter(func(n model.Notification) bool {
+		return n.FollowUpOf == id
+	})
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+func (s *Storage) UpdateNotificationStatus(id int, newStatus string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, ok := s.notifications[id]
+	if !ok {
+		return repository.ErrNoSuchNotification
+	}
+	if notification.Status != "active" {
+		return repository.ErrNotActive
+	}
+
+	notification.Status = newStatus
+	notification.Version++
+	s.notifications[id] = notification
+
+	return nil
+}
+
+func (s *Storage) RescheduleNotification(id int, sendAt int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, ok := s.notifications[id]
+	if !ok {
+		return repository.ErrNoSuchNotification
+	}
+	if notification.Status != "active" && notification.Status != "completed" {
+		return repository.ErrNotActive
+	}
+
+	notification.SendAt = sendAt
+	notification.Status = "active"
+	notification.DigestId = 0
+	notification.Claims = 0
+	notification.Version++
+	s.notifications[id] = notification
+	delete(s.claimedUntil, id)
+
+	return nil
+}
+
+func (s *Storage) SaveSubscriber(subscriber model.Subscriber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.subscribers[subscriber.Handle]; ok {
+		if existing.TelegramId != subscriber.TelegramId {
+			return repository.ErrHandleTaken
+		}
+		subscriber.CreatedAt = existing.CreatedAt
+	} else {
+		subscriber.CreatedAt = time.Now()
+	}
+	s.subscribers[subscriber.Handle] = subscriber
+
+	return nil
+}
+
+func (s *Storage) GetSubscriberByHandle(handle string) (*model.Subscriber, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriber, ok := s.subscribers[handle]
+	if !ok {
+		return nil, repository.ErrNoSuchSubscriber
+	}
+
+	return &subscriber, nil
+}
+
+func (s *Storage) MarkRecipientUnreachable(telegramId int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unreachable[telegramId] = reason
+	return nil
+}
+
+func (s *Storage) MarkRecipientReachable(telegramId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.unreachable, telegramId)
+	return nil
+}
+
+func (s *Storage) IsRecipientUnreachable(telegramId int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.unreachable[telegramId]
+	return ok, nil
+}
+
+func (s *Storage) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.notifications[attempt.NotificationId]; !ok {
+		return repository.ErrNoSuchNotification
+	}
+
+	s.lastAttemptId++
+	attempt.Id = s.lastAttemptId
+	attempt.CreatedAt = time.Now()
+	s.attempts[attempt.NotificationId] = append(s.attempts[attempt.NotificationId], attempt)
+
+	return nil
+}
+
+func (s *Storage) GetDeliveryAttempts(notificationId int) ([]model.DeliveryAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]model.DeliveryAttempt(nil), s.attempts[notificationId]...), nil
+}
+
+func (s *Storage) filter(keep func(model.Notification) bool) []model.Notification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var notifications []model.Notification
+	for _, notification := range s.notifications {
+		if keep(notification) {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return notifications
+}
@@ -0,0 +1,27 @@
+package model
+
+// Catch-up policies decide what the scheduler does with missed
+// notifications, i.e. ones that are already overdue when they are claimed,
+// e.g. after the service was down.
+const (
+	// CatchUpSendAll sends every missed notification right away.
+	CatchUpSendAll = "send_all"
+	// CatchUpLatest sends only the latest missed notification of each
+	// addressee, the older ones expire.
+	CatchUpLatest = "latest"
+	// CatchUpDropOlder expires missed notifications overdue by more than the
+	// max age and sends the rest.
+	CatchUpDropOlder = "drop_older"
+	// CatchUpSpread reschedules missed notifications evenly over a window,
+	// oldest first, instead of sending them at once.
+	CatchUpSpread = "spread"
+)
+
+// IsCatchUpPolicy reports whether policy is a known catch-up policy.
+func IsCatchUpPolicy(policy string) bool {
+	switch policy {
+	case CatchUpSendAll, CatchUpLatest, CatchUpDropOlder, CatchUpSpread:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package model
+
+// Collapse policies decide what happens when a notification is created with
+// the collapse key of an active notification of the same addressee.
+const (
+	// CollapseKeepFirst drops the newer notification, the earlier one is
+	// sent as it is.
+	CollapseKeepFirst = "keep_first"
+	// CollapseKeepLast cancels the earlier notification in favor of the
+	// newer one.
+	CollapseKeepLast = "keep_last"
+	// CollapseMerge gives the earlier notification the text, category,
+	// channels, options and expiry of the newer one. It is sent at the
+	// earlier of both send times.
+	CollapseMerge = "merge"
+)
+
+// MaxCollapseKeyLength is the longest collapse key, in bytes.
+const MaxCollapseKeyLength = 255
+
+// CollapseResult is the outcome of creating a notification with a collapse
+// key.
+type CollapseResult struct {
+	// Notification is the created notification, or the earlier one it was
+	// collapsed into.
+	Notification Notification
+	Created      bool
+	// Merged reports whether the earlier notification took the content of
+	// the newer one, see CollapseMerge.
+	Merged bool
+	// Canceled are the ids of the earlier notifications canceled in favor
+	// of the created one.
+	Canceled []int
+}
+
+// IsCollapsePolicy reports whether policy is a known collapse policy.
+func IsCollapsePolicy(policy string) bool {
+	switch policy {
+	case CollapseKeepFirst, CollapseKeepLast, CollapseMerge:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Outcomes of a delivery attempt.
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// DeliveryAttempt records one contact a notification was sent to, after
+// retries of that contact were exhausted. The attempts of a notification
+// form its delivery history, the last sent one tells which channel
+// delivered it.
+type DeliveryAttempt struct {
+	Id             int       `json:"id"`
+	NotificationId int       `json:"notification_id"`
+	Channel        string    `json:"channel"`
+	Address        string    `json:"address"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
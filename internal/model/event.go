@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// Event types of notification changes.
+const (
+	EventCreated     = "created"
+	EventSent        = "sent"
+	EventFailed      = "failed"
+	EventCanceled    = "canceled"
+	EventExpired     = "expired"
+	EventRescheduled = "rescheduled"
+	EventUpdated     = "updated"
+)
+
+// Event reports a change of a notification. Notification is the state after
+// the change.
+type Event struct {
+	Type         string       `json:"type"`
+	Notification Notification `json:"notification"`
+	Time         time.Time    `json:"time"`
+}
+
+// EventTypeForStatus returns the event reported when a notification moves
+// to status.
+func EventTypeForStatus(status string) string {
+	switch status {
+	case "completed":
+		return EventSent
+	case "failed":
+		return EventFailed
+	case "canceled":
+		return EventCanceled
+	case "expired":
+		return EventExpired
+	default:
+		return EventRescheduled
+	}
+}
+
+// EventFilter selects events of a single notification, of a single
+// telegram chat, of a single recipient, or any combination. Zero fields
+// match everything.
+type EventFilter struct {
+	Id          int
+	TelegramId  int
+	RecipientId int
+}
+
+func (f EventFilter) Match(event Event) bool {
+	if f.Id != 0 && event.Notification.Id != f.Id {
+		return false
+	}
+	if f.TelegramId != 0 && event.Notification.TelegramId != f.TelegramId {
+		return false
+	}
+	if f.RecipientId != 0 && event.Notification.RecipientId != f.RecipientId {
+		return false
+	}
+	return true
+}
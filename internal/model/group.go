@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Group is a group or topic recipients subscribe to. A notification for a
+// group is sent to each of its members, see Notification.GroupId.
+type Group struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Members   []int     `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GroupDeliveries sums up the statuses of the per-recipient deliveries of a
+// group notification. Pending deliveries are still active.
+type GroupDeliveries struct {
+	Total     int `json:"total"`
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+	Pending   int `json:"pending"`
+}
+
+// NewGroupDeliveries counts the statuses of the deliveries.
+func NewGroupDeliveries(deliveries []Notification) GroupDeliveries {
+	stats := GroupDeliveries{Total: len(deliveries)}
+	for _, delivery := range deliveries {
+		switch delivery.Status {
+		case "completed":
+			stats.Delivered++
+		case "failed", "canceled", "expired":
+			stats.Failed++
+		default:
+			stats.Pending++
+		}
+	}
+	return stats
+}
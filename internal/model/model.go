@@ -3,10 +3,63 @@ package model
 import "time"
 
 type Notification struct {
-	Id         int       `json:"id"`
-	Text       string    `json:"text"`
-	Status     string    `json:"status"`
+	Id         int              `json:"id"`
+	Text       string           `json:"text"`
+	Status     string           `json:"status"`
+	TelegramId int              `json:"telegram_id"`
+	SendAt     int              `json:"send_at"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Options    *TelegramOptions `json:"options,omitempty"`
+	// RecipientId addresses the notification to a recipient instead of
+	// TelegramId. It is resolved to a contact when the notification is sent.
+	RecipientId int `json:"recipient_id,omitempty"`
+	// Channels is the fallback chain of a notification for a recipient:
+	// the channels of its contacts to try, in order. Empty means every
+	// verified contact in the recipient's preference order.
+	Channels []string `json:"channels,omitempty"`
+	// GroupId addresses the notification to every member of a group. When
+	// it is due, a delivery for each member is created with ParentId set to
+	// the id of the notification.
+	GroupId  int `json:"group_id,omitempty"`
+	ParentId int `json:"parent_id,omitempty"`
+	// Category lets recipients mute a kind of notifications, see
+	// Preferences.
+	Category string `json:"category,omitempty"`
+	// CollapseKey identifies notifications that stand for the same thing,
+	// of which an addressee has a single active one, see CollapseKeepFirst.
+	CollapseKey string `json:"collapse_key,omitempty"`
+	// ExpiresAt (unix millis) is when the notification is too late to be
+	// sent, it is expired instead. 0 means it never expires.
+	ExpiresAt int `json:"expires_at,omitempty"`
+	// FollowUpOf is the id of the notification this one was snoozed or
+	// resent from.
+	FollowUpOf int `json:"follow_up_of,omitempty"`
+	// Tenant is the client the notification was created for. It selects
+	// the retention policy applied to the notification, see RetentionScope.
+	Tenant string `json:"tenant,omitempty"`
+	// DigestId is the id of the notification this one is sent in a digest
+	// with, see Preferences.DigestWindow.
+	DigestId int `json:"digest_id,omitempty"`
+	// UnsubscribeURL is the unsubscribe link put into the text when the
+	// notification is sent, mail senders announce it in the
+	// List-Unsubscribe header. It is not stored.
+	UnsubscribeURL string `json:"-"`
+	// Claims counts the scheduler claims of the notification since it was
+	// last scheduled. More than one means its sending is retried once the
+	// claim lease runs out.
+	Claims int `json:"-"`
+	// Version is incremented by every status change and reschedule. It
+	// orders cached notifications, see service.Cache.
+	Version int `json:"version"`
+}
+
+// Expired reports whether the notification can no longer be sent at now.
+func (n Notification) Expired(now time.Time) bool {
+	return n.ExpiresAt != 0 && now.UnixMilli() > int64(n.ExpiresAt)
+}
+
+type Subscriber struct {
+	Handle     string    `json:"handle"`
 	TelegramId int       `json:"telegram_id"`
-	SendAt     int       `json:"send_at"`
 	CreatedAt  time.Time `json:"created_at"`
 }
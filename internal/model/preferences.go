@@ -0,0 +1,67 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+)
+
+var categoryPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// Preferences are the choices of a recipient about what it is sent. They
+// apply to notifications addressed to the recipient and to its deliveries
+// of group notifications.
+type Preferences struct {
+	RecipientId int `json:"recipient_id"`
+	// Unsubscribed stops every notification.
+	Unsubscribed bool `json:"unsubscribed"`
+	// MutedCategories stops notifications of these categories, see
+	// Notification.Category.
+	MutedCategories []string `json:"muted_categories"`
+	// OptedOutChannels leaves contacts of these channels out of fallback
+	// chains.
+	OptedOutChannels []string `json:"opted_out_channels"`
+	// DigestWindow, in seconds, turns on the digest mode: when a
+	// notification is due, the recipient's others due within the window are
+	// sent with it in one message. 0 sends every notification on its own.
+	DigestWindow int `json:"digest_window"`
+	// DigestCategories limits the digest mode to notifications of these
+	// categories. Empty means notifications of any category or none.
+	DigestCategories []string `json:"digest_categories"`
+}
+
+// MaxDigestWindow is the longest digest window, a day.
+const MaxDigestWindow = 24 * 60 * 60
+
+// Blocks returns why the notification must not be sent to the recipient,
+// or an empty string if it may be.
+func (p Preferences) Blocks(notification Notification) string {
+	switch {
+	case p.Unsubscribed:
+		return "recipient unsubscribed"
+	case notification.Category != "" && slices.Contains(p.MutedCategories, notification.Category):
+		return "recipient muted category " + notification.Category
+	}
+	return ""
+}
+
+// AllowsChannel reports whether the recipient may be reached through the
+// channel.
+func (p Preferences) AllowsChannel(channel string) bool {
+	return !slices.Contains(p.OptedOutChannels, channel)
+}
+
+// Digests reports whether the notification may be sent in a digest with
+// others. Only plain text notifications for the recipient whose fallback
+// chain is not overridden are.
+func (p Preferences) Digests(notification Notification) bool {
+	if p.DigestWindow <= 0 || notification.RecipientId == 0 || notification.Options != nil || len(notification.Channels) != 0 {
+		return false
+	}
+	return len(p.DigestCategories) == 0 || slices.Contains(p.DigestCategories, notification.Category)
+}
+
+// IsCategory reports whether category is a valid notification category:
+// 1 to 64 lowercase letters, digits, dashes and underscores.
+func IsCategory(category string) bool {
+	return categoryPattern.MatchString(category)
+}
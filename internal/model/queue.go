@@ -0,0 +1,9 @@
+package model
+
+// QueueMessage is a message taken from the queue. The queue keeps it, and
+// hands it out again if the consumer goes away, until Ack is called once it
+// was handled.
+type QueueMessage struct {
+	Body []byte
+	Ack  func() error
+}
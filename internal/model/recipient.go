@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channels a contact can be reached through.
+const (
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+	ChannelPhone    = "phone"
+	ChannelWebhook  = "webhook"
+)
+
+var (
+	ErrInvalidContact = errors.New("invalid contact")
+
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+// Recipient is a person notifications are addressed to. Contacts are
+// ordered by preference, the first verified one the service can deliver to
+// is used when a notification is sent.
+type Recipient struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Contacts  []Contact `json:"contacts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Contact is a way to reach a recipient: a telegram chat id, an email
+// address, a phone number in E.164 format or a webhook url. Position orders
+// the contacts of a recipient, lower is preferred.
+type Contact struct {
+	Id               int       `json:"id"`
+	Channel          string    `json:"channel"`
+	Address          string    `json:"address"`
+	Position         int       `json:"position"`
+	Verified         bool      `json:"verified"`
+	VerificationCode string    `json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// IsChannel reports whether channel is one of the supported channels.
+func IsChannel(channel string) bool {
+	switch channel {
+	case ChannelTelegram, ChannelEmail, ChannelPhone, ChannelWebhook:
+		return true
+	}
+	return false
+}
+
+// Validate checks that the address is well formed for the channel.
+func (c Contact) Validate() error {
+	switch c.Channel {
+	case ChannelTelegram:
+		if _, err := strconv.ParseInt(c.Address, 10, 64); err != nil {
+			return fmt.Errorf("%w: telegram address must be a chat id", ErrInvalidContact)
+		}
+	case ChannelEmail:
+		address, err := mail.ParseAddress(c.Address)
+		if err != nil || address.Address != c.Address {
+			return fmt.Errorf("%w: invalid email address", ErrInvalidContact)
+		}
+	case ChannelPhone:
+		if !phonePattern.MatchString(c.Address) {
+			return fmt.Errorf("%w: phone must be in E.164 format, e.g. +14155550123", ErrInvalidContact)
+		}
+	case ChannelWebhook:
+		u, err := url.Parse(c.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook must be an http or https url", ErrInvalidContact)
+		}
+		if !IsPublicHost(u.Hostname()) {
+			return fmt.Errorf("%w: webhook must not point to a private, loopback or link-local address", ErrInvalidContact)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported channel %q", ErrInvalidContact, c.Channel)
+	}
+
+	if strings.TrimSpace(c.Address) != c.Address {
+		return fmt.Errorf("%w: address must not have surrounding spaces", ErrInvalidContact)
+	}
+
+	return nil
+}
+
+// TelegramId returns the chat id of a telegram contact.
+func (c Contact) TelegramId() int {
+	id, _ := strconv.Atoi(c.Address)
+	return id
+}
+
+// IsPublicHost reports whether a webhook may be sent to host. Names are
+// only checked for localhost here, the addresses they resolve to are
+// checked again when the webhook is sent, see IsPublicIP.
+func IsPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// IsPublicIP reports whether ip is reachable from the internet, i.e. it is
+// not a private, loopback, link-local, multicast or unspecified address.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsUnspecified()
+}
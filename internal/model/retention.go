@@ -0,0 +1,35 @@
+package model
+
+import "slices"
+
+// FinishedStatuses are the statuses of notifications that will not be sent
+// anymore, the ones retention policies apply to.
+var FinishedStatuses = []string{"completed", "failed", "canceled", "expired"}
+
+// RetentionScope selects the notifications a retention policy applies to:
+// the ones with one of Statuses, due before Before (unix millis) and of
+// Tenant. A scope without a tenant is the default one, it skips the tenants
+// in Except, which have policies of their own.
+type RetentionScope struct {
+	Tenant   string
+	Except   []string
+	Statuses []string
+	Before   int
+}
+
+// Matches reports whether the notification is in the scope.
+func (s RetentionScope) Matches(n Notification) bool {
+	if !slices.Contains(s.Statuses, n.Status) || n.SendAt >= s.Before {
+		return false
+	}
+	if s.Tenant != "" {
+		return n.Tenant == s.Tenant
+	}
+	return !slices.Contains(s.Except, n.Tenant)
+}
+
+// IsTenant reports whether tenant is a valid tenant, which has the format
+// of a category.
+func IsTenant(tenant string) bool {
+	return categoryPattern.MatchString(tenant)
+}
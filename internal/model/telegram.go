@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+
+	AttachmentPhoto    = "photo"
+	AttachmentDocument = "document"
+
+	maxTextLength         = 4096
+	maxCaptionLength      = 1024
+	maxCallbackDataLength = 64
+)
+
+var (
+	ErrInvalidTelegramOptions = errors.New("invalid telegram options")
+	// ErrNotSnoozable means the notification was canceled, failed or
+	// expired, so its snooze button does nothing.
+	ErrNotSnoozable = errors.New("only active and completed notifications can be snoozed")
+)
+
+// TelegramOptions holds optional formatting and delivery settings of a
+// Telegram message. A nil *TelegramOptions means a plain text message.
+type TelegramOptions struct {
+	ParseMode             string      `json:"parse_mode,omitempty"`
+	Buttons               [][]Button  `json:"buttons,omitempty"`
+	DisableNotification   bool        `json:"disable_notification,omitempty"`
+	DisableWebPagePreview bool        `json:"disable_web_page_preview,omitempty"`
+	Attachment            *Attachment `json:"attachment,omitempty"`
+}
+
+// Button is an inline keyboard button. Exactly one of URL and CallbackData
+// must be set.
+type Button struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// Attachment is a photo or a document sent by URL, the notification text
+// becomes its caption.
+type Attachment struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// TextLimit returns the maximum length of the text in characters, captions
+// of attachments are shorter than plain messages.
+func (o *TelegramOptions) TextLimit() int {
+	if o != nil && o.Attachment != nil {
+		return maxCaptionLength
+	}
+	return maxTextLength
+}
+
+// Mode returns the parse mode of the options, empty for plain text.
+func (o *TelegramOptions) Mode() string {
+	if o == nil {
+		return ""
+	}
+	return o.ParseMode
+}
+
+// markdownV2Reserved are the characters that must be escaped in MarkdownV2
+// text.
+const markdownV2Reserved = "\\_*[]()~`>#+-=|{}.!"
+
+// Escape returns text escaped for the parse mode of the options, so that it
+// is shown as it is. Plain text messages need no escaping.
+func (o *TelegramOptions) Escape(text string) string {
+	if o == nil {
+		return text
+	}
+
+	switch o.ParseMode {
+	case ParseModeHTML:
+		return html.EscapeString(text)
+	case ParseModeMarkdownV2:
+		var b strings.Builder
+		for _, r := range text {
+			if strings.ContainsRune(markdownV2Reserved, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+	return text
+}
+
+// Validate checks that a message with the given text and options can be
+// accepted by the Telegram Bot API.
+func (o *TelegramOptions) Validate(text string) error {
+	limit := o.TextLimit()
+	if utf8.RuneCountInString(text) > limit {
+		return fmt.Errorf("%w: text must not be longer than %d characters", ErrInvalidTelegramOptions, limit)
+	}
+
+	if o == nil {
+		return nil
+	}
+
+	switch o.ParseMode {
+	case "", ParseModeMarkdownV2, ParseModeHTML:
+	default:
+		return fmt.Errorf("%w: unsupported parse mode %q", ErrInvalidTelegramOptions, o.ParseMode)
+	}
+
+	for _, row := range o.Buttons {
+		if len(row) == 0 {
+			return fmt.Errorf("%w: button row must not be empty", ErrInvalidTelegramOptions)
+		}
+
+		for _, button := range row {
+			if err := button.validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.Attachment != nil {
+		if o.Attachment.Type != AttachmentPhoto && o.Attachment.Type != AttachmentDocument {
+			return fmt.Errorf("%w: unsupported attachment type %q", ErrInvalidTelegramOptions, o.Attachment.Type)
+		}
+
+		if !isHTTPURL(o.Attachment.URL) {
+			return fmt.Errorf("%w: attachment url must be an http(s) url", ErrInvalidTelegramOptions)
+		}
+	}
+
+	return nil
+}
+
+func (b Button) validate() error {
+	if b.Text == "" {
+		return fmt.Errorf("%w: button text must not be empty", ErrInvalidTelegramOptions)
+	}
+
+	if (b.URL == "") == (b.CallbackData == "") {
+		return fmt.Errorf("%w: button %q must have either url or callback_data", ErrInvalidTelegramOptions, b.Text)
+	}
+
+	if b.URL != "" && !isHTTPURL(b.URL) {
+		return fmt.Errorf("%w: button %q url must be an http(s) url", ErrInvalidTelegramOptions, b.Text)
+	}
+
+	if len(b.CallbackData) > maxCallbackDataLength {
+		return fmt.Errorf("%w: button %q callback_data must not be longer than %d bytes", ErrInvalidTelegramOptions, b.Text, maxCallbackDataLength)
+	}
+
+	return nil
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -20,8 +20,9 @@ const (
 )
 
 type RabbitMq struct {
-	pulisher *rabbitmq.Publisher
-	consumer <-chan amqp.Delivery
+	pulisher  *rabbitmq.Publisher
+	consumer  <-chan amqp.Delivery
+	consumeCh *rabbitmq.Channel
 }
 
 func New() *RabbitMq {
@@ -54,14 +55,19 @@ func New() *RabbitMq {
 		log.Fatal("could not create channel for consumer rabbitmq: ", err)
 	}
 
+	if err := conCh.Qos(config.Cfg.Consumer.Prefetch, 0, false); err != nil {
+		log.Fatal("could not set prefetch for consumer rabbitmq: ", err)
+	}
+
 	deliveries, err := conCh.Consume("notification", "", false, false, false, false, amqp.Table{})
 	if err != nil {
 		log.Fatal("could not create consumer for rabbitmq: ", err)
 	}
 
 	return &RabbitMq{
-		pulisher: publisher,
-		consumer: deliveries,
+		pulisher:  publisher,
+		consumer:  deliveries,
+		consumeCh: conCh,
 	}
 }
 
@@ -80,28 +86,40 @@ func (r *RabbitMq) Publish(notification model.Notification) error {
 	return r.pulisher.PublishWithRetry(body, "notification", "application/json", strategy)
 }
 
-func (r *RabbitMq) Consume(ctx context.Context) (<-chan []byte, error) {
-	messages := make(chan []byte)
+// Consume returns a channel of deliveries, which is closed when ctx is
+// canceled. A delivery stays unacknowledged until the consumer handled it,
+// so at most prefetch messages are taken from the queue at a time and the
+// ones in flight are redelivered if the service stops.
+func (r *RabbitMq) Consume(ctx context.Context) (<-chan model.QueueMessage, error) {
+	messages := make(chan model.QueueMessage)
 
 	go func() {
+		defer close(messages)
+
 		for {
 			select {
 			case <-ctx.Done():
-				close(messages)
 				return
-			default:
-				next, ok := <-r.consumer
+			case next, ok := <-r.consumer:
 				if !ok {
 					return
 				}
 
-				if err := next.Ack(false); err != nil {
-					zlog.Logger.Error().Msg("could not acknowledge message consuming: " + err.Error())
+				select {
+				case <-ctx.Done():
+					if err := next.Nack(false, true); err != nil {
+						zlog.Logger.Error().Msg("could not requeue message: " + err.Error())
+					}
+					return
+				case messages <- model.QueueMessage{Body: next.Body, Ack: func() error { return next.Ack(false) }}:
 				}
-				messages <- next.Body
 			}
 		}
 	}()
 
 	return messages, nil
 }
+
+func (r *RabbitMq) SetPrefetch(prefetch int) error {
+	return r.consumeCh.Qos(prefetch, 0, false)
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/lib/pq"
+)
+
+const contactColumns = "id, channel, address, position, verified, verification_code, created_at"
+
+// CreateRecipient saves the recipient with its contacts, which get
+// positions in the given order.
+func (r *Repository) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	tx, err := r.db.Master.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO recipients(name) VALUES($1) RETURNING id, created_at"
+	if err := tx.QueryRow(query, recipient.Name).Scan(&recipient.Id, &recipient.CreatedAt); err != nil {
+		return nil, fmt.Errorf("could not save recipient to db: %w", err)
+	}
+
+	query = `INSERT INTO contacts(recipient_id, channel, address, position, verified, verification_code)
+	VALUES($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+
+	for i := range recipient.Contacts {
+		contact := &recipient.Contacts[i]
+		contact.Position = i
+
+		err := tx.QueryRow(
+			query,
+			recipient.Id,
+			contact.Channel,
+			contact.Address,
+			contact.Position,
+			contact.Verified,
+			contact.VerificationCode,
+		).Scan(&contact.Id, &contact.CreatedAt)
+		if err != nil {
+			return nil, contactError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit recipient: %w", err)
+	}
+
+	return &recipient, nil
+}
+
+func (r *Repository) GetRecipient(id int) (*model.Recipient, error) {
+	query := "SELECT id, name, created_at FROM recipients WHERE id = $1"
+
+	var recipient model.Recipient
+	err := r.db.Master.QueryRow(query, id).Scan(&recipient.Id, &recipient.Name, &recipient.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoSuchRecipient
+		}
+		return nil, fmt.Errorf("could not get recipient from db: %w", err)
+	}
+
+	contacts, err := r.queryContacts("WHERE recipient_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	recipient.Contacts = contacts[id]
+
+	return &recipient, nil
+}
+
+func (r *Repository) GetAllRecipients() ([]model.Recipient, error) {
+	rows, err := r.db.Master.Query("SELECT id, name, created_at FROM recipients ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("could not get recipients from db: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []model.Recipient
+	for rows.Next() {
+		var recipient model.Recipient
+		if err := rows.Scan(&recipient.Id, &recipient.Name, &recipient.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan recipient: %w", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get recipients from db: %w", err)
+	}
+
+	contacts, err := r.queryContacts("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range recipients {
+		recipients[i].Contacts = contacts[recipients[i].Id]
+	}
+
+	return recipients, nil
+}
+
+func (r *Repository) RenameRecipient(id int, name string) error {
+	result, err := r.db.Master.Exec("UPDATE recipients SET name = $1 WHERE id = $2", name, id)
+	if err != nil {
+		return fmt.Errorf("could not rename recipient: %w", err)
+	}
+
+	return expectAffected(result, ErrNoSuchRecipient)
+}
+
+// DeleteRecipient deletes the recipient with its contacts. Notifications
+// addressed to it stay and fail when they are sent.
+func (r *Repository) DeleteRecipient(id int) error {
+	result, err := r.db.Master.Exec("DELETE FROM recipients WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not delete recipient: %w", err)
+	}
+
+	return expectAffected(result, ErrNoSuchRecipient)
+}
+
+// AddContact appends the contact to the end of the recipient's contacts.
+func (r *Repository) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	query := `INSERT INTO contacts(recipient_id, channel, address, position, verified, verification_code)
+	SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0), $4, $5 FROM contacts WHERE recipient_id = $1
+	RETURNING id, position, created_at`
+
+	err := r.db.Master.QueryRow(
+		query,
+		recipientId,
+		contact.Channel,
+		contact.Address,
+		contact.Verified,
+		contact.VerificationCode,
+	).Scan(&contact.Id, &contact.Position, &contact.CreatedAt)
+	if err != nil {
+		return nil, contactError(err)
+	}
+
+	return &contact, nil
+}
+
+func (r *Repository) DeleteContact(recipientId, contactId int) error {
+	query := "DELETE FROM contacts WHERE id = $1 AND recipient_id = $2"
+
+	result, err := r.db.Master.Exec(query, contactId, recipientId)
+	if err != nil {
+		return fmt.Errorf("could not delete contact: %w", err)
+	}
+
+	return expectAffected(result, ErrNoSuchContact)
+}
+
+func (r *Repository) VerifyContact(recipientId, contactId int) error {
+	query := `UPDATE contacts SET verified = TRUE, verification_code = ''
+	WHERE id = $1 AND recipient_id = $2`
+
+	result, err := r.db.Master.Exec(query, contactId, recipientId)
+	if err != nil {
+		return fmt.Errorf("could not verify contact: %w", err)
+	}
+
+	return expectAffected(result, ErrNoSuchContact)
+}
+
+// ReorderContacts sets contact positions to their indexes in contactIds.
+func (r *Repository) ReorderContacts(recipientId int, contactIds []int) error {
+	tx, err := r.db.Master.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE contacts SET position = $1 WHERE id = $2 AND recipient_id = $3"
+	for position, id := range contactIds {
+		result, err := tx.Exec(query, position, id, recipientId)
+		if err != nil {
+			return fmt.Errorf("could not reorder contacts: %w", err)
+		}
+		if err := expectAffected(result, ErrNoSuchContact); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit contact order: %w", err)
+	}
+
+	return nil
+}
+
+// queryContacts returns contacts matching where grouped by recipient id and
+// ordered by position.
+func (r *Repository) queryContacts(where string, args ...any) (map[int][]model.Contact, error) {
+	query := "SELECT recipient_id, " + contactColumns + " FROM contacts " + where + " ORDER BY position, id"
+
+	rows, err := r.db.Master.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get contacts from db: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := make(map[int][]model.Contact)
+	for rows.Next() {
+		var recipientId int
+		var contact model.Contact
+		err := rows.Scan(
+			&recipientId,
+			&contact.Id,
+			&contact.Channel,
+			&contact.Address,
+			&contact.Position,
+			&contact.Verified,
+			&contact.VerificationCode,
+			&contact.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan contact: %w", err)
+		}
+		contacts[recipientId] = append(contacts[recipientId], contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get contacts from db: %w", err)
+	}
+
+	return contacts, nil
+}
+
+func contactError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return ErrDuplicateContact
+		case "23503": // foreign_key_violation
+			return ErrNoSuchRecipient
+		}
+	}
+	return fmt.Errorf("could not save contact to db: %w", err)
+}
+
+func expectAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
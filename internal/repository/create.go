@@ -1,22 +1,47 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/Komilov31/delayed-notifier/internal/model"
 )
 
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func (r *Repository) CreateNotification(notification model.Notification) (*model.Notification, error) {
-	query := `INSERT INTO notifications(text, status, telegram_id, send_at)
-	VALUES($1, $2, $3, $4) RETURNING id, created_at`
+	return insertNotification(r.db.Master, notification)
+}
+
+func insertNotification(db queryRower, notification model.Notification) (*model.Notification, error) {
+	query := `INSERT INTO notifications(text, status, telegram_id, send_at, options, recipient_id, channels, group_id, parent_id, category, collapse_key, expires_at, follow_up_of, tenant)
+	VALUES($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, 0), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, 0), NULLIF($13, 0), NULLIF($14, '')) RETURNING id, created_at, version`
+
+	options, err := marshalOptions(notification.Options)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal notification options: %w", err)
+	}
 
-	err := r.db.Master.QueryRow(
+	err = db.QueryRow(
 		query,
 		notification.Text,
 		notification.Status,
 		notification.TelegramId,
 		notification.SendAt,
-	).Scan(&notification.Id, &notification.CreatedAt)
+		options,
+		notification.RecipientId,
+		strings.Join(notification.Channels, ","),
+		notification.GroupId,
+		notification.ParentId,
+		notification.Category,
+		notification.CollapseKey,
+		notification.ExpiresAt,
+		notification.FollowUpOf,
+		notification.Tenant,
+	).Scan(&notification.Id, &notification.CreatedAt, &notification.Version)
 	if err != nil {
 		return nil, fmt.Errorf("could not scan notification info from db: %w", err)
 	}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+func (r *Repository) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	query := `INSERT INTO delivery_attempts(notification_id, channel, address, status, error)
+	VALUES($1, $2, $3, $4, $5)`
+
+	_, err := r.db.Master.Exec(query, attempt.NotificationId, attempt.Channel, attempt.Address, attempt.Status, attempt.Error)
+	if err != nil {
+		return fmt.Errorf("could not save delivery attempt to db: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveryAttempts returns the attempts of the notification, oldest
+// first.
+func (r *Repository) GetDeliveryAttempts(notificationId int) ([]model.DeliveryAttempt, error) {
+	query := `SELECT id, notification_id, channel, address, status, error, created_at
+	FROM delivery_attempts
+	WHERE notification_id = $1
+	ORDER BY id`
+
+	rows, err := r.db.Master.Query(query, notificationId)
+	if err != nil {
+		return nil, fmt.Errorf("could not get delivery attempts from db: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []model.DeliveryAttempt
+	for rows.Next() {
+		var attempt model.DeliveryAttempt
+		err := rows.Scan(
+			&attempt.Id,
+			&attempt.NotificationId,
+			&attempt.Channel,
+			&attempt.Address,
+			&attempt.Status,
+			&attempt.Error,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan delivery attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get delivery attempts from db: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// AssignDigest makes the notification leaderId the digest of the active
+// notifications of the recipient due before dueBefore (unix millis) and
+// returns them ordered by send time. Only plain text notifications without
+// channels of the given categories, or of any if there are none, are
+// assigned, and only if they are not in a digest of another active
+// notification already. The recipient is locked while the digest is
+// assigned, so concurrent digests of the recipient never overlap.
+func (r *Repository) AssignDigest(leaderId, recipientId, dueBefore int, categories []string) ([]model.Notification, error) {
+	tx, err := r.db.Master.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT id FROM recipients WHERE id = $1 FOR UPDATE", recipientId); err != nil {
+		return nil, fmt.Errorf("could not lock recipient: %w", err)
+	}
+
+	query := `UPDATE notifications n SET digest_id = $1
+	WHERE n.recipient_id = $2 AND n.status = 'active' AND n.send_at < $3
+	AND n.options IS NULL AND COALESCE(n.channels, '') = ''
+	AND ($4 = '' OR COALESCE(n.category, '') = ANY(string_to_array($4, ',')))
+	AND (n.digest_id IS NULL OR n.digest_id = $1 OR NOT EXISTS(
+		SELECT 1 FROM notifications l
+		WHERE l.id = n.digest_id AND l.status = 'active' AND l.digest_id = l.id
+	))
+	RETURNING ` + notificationColumns
+
+	rows, err := tx.Query(query, leaderId, recipientId, dueBefore, strings.Join(categories, ","))
+	if err != nil {
+		return nil, fmt.Errorf("could not assign digest: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row to model: %w", err)
+		}
+
+		notifications = append(notifications, *notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not assign digest: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit digest: %w", err)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].SendAt != notifications[j].SendAt {
+			return notifications[i].SendAt < notifications[j].SendAt
+		}
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+// LeaveDigest takes the notification out of its digest, so it is sent on
+// its own or in a later digest.
+func (r *Repository) LeaveDigest(id int) error {
+	result, err := r.db.Master.Exec("UPDATE notifications SET digest_id = NULL WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("could not leave digest: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not leave digest: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNoSuchNotification
+	}
+
+	return nil
+}
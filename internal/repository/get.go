@@ -8,17 +8,9 @@ import (
 )
 
 func (r *Repository) GetNotificationById(id int) (*model.Notification, error) {
-	query := "SELECT * FROM notifications WHERE id = $1"
-
-	var notification model.Notification
-	err := r.db.Master.QueryRow(query, id).Scan(
-		&notification.Id,
-		&notification.Text,
-		&notification.Status,
-		&notification.TelegramId,
-		&notification.SendAt,
-		&notification.CreatedAt,
-	)
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE id = $1"
+
+	notification, err := scanNotification(r.db.Master.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNoSuchNotification
@@ -26,47 +18,77 @@ func (r *Repository) GetNotificationById(id int) (*model.Notification, error) {
 		return nil, fmt.Errorf("could not get notification from db: %w", err)
 	}
 
-	return &notification, nil
+	return notification, nil
 }
 
 func (r *Repository) GetAllNotifications() ([]model.Notification, error) {
-	query := "SELECT * FROM notifications"
+	query := "SELECT " + notificationColumns + " FROM notifications ORDER BY id"
 
-	rows, err := r.db.Master.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("could not get all notifications from db: %w", err)
-	}
-	defer rows.Close()
-
-	var notifications []model.Notification
-	for rows.Next() {
-		var notification model.Notification
-		err := rows.Scan(
-			&notification.Id,
-			&notification.Text,
-			&notification.Status,
-			&notification.TelegramId,
-			&notification.SendAt,
-			&notification.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("could not scan row to model: %w", err)
-		}
+	return r.queryNotifications(query)
+}
 
-		notifications = append(notifications, notification)
-	}
+// GetNotificationsAfter returns up to limit notifications with an id
+// greater than afterId ordered by id, a page of a keyset scan.
+func (r *Repository) GetNotificationsAfter(afterId, limit int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE id > $1 ORDER BY id LIMIT $2"
 
-	return notifications, nil
+	return r.queryNotifications(query, afterId, limit)
 }
 
 func (r *Repository) GetReadyNotifications() ([]model.Notification, error) {
-	query := `SELECT *
+	query := `SELECT ` + notificationColumns + `
 	FROM notifications
 	WHERE (send_at - (EXTRACT(EPOCH FROM NOW()) * 1000)::BIGINT) < 30000 
 	AND status='active';
 	`
 
-	rows, err := r.db.Master.Query(query)
+	return r.queryNotifications(query)
+}
+
+// ClaimReadyNotifications returns ready notifications that are not claimed
+// by another scheduler and claims them until leaseUntil (unix millis). A
+// single UPDATE makes concurrent claims see each row at most once. Claims
+// of the returned notifications counts this claim.
+func (r *Repository) ClaimReadyNotifications(leaseUntil int) ([]model.Notification, error) {
+	query := `UPDATE notifications
+	SET claimed_until = $1, claims = claims + 1
+	WHERE (send_at - (EXTRACT(EPOCH FROM NOW()) * 1000)::BIGINT) < 30000
+	AND status = 'active'
+	AND claimed_until < (EXTRACT(EPOCH FROM NOW()) * 1000)::BIGINT
+	RETURNING ` + notificationColumns
+
+	return r.queryNotifications(query, leaseUntil)
+}
+
+// GetUpcomingNotifications returns active notifications of the telegram chat
+// ordered by send time.
+func (r *Repository) GetUpcomingNotifications(telegramId int) ([]model.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+	FROM notifications
+	WHERE telegram_id = $1 AND status = 'active'
+	ORDER BY send_at`
+
+	return r.queryNotifications(query, telegramId)
+}
+
+// GetChildNotifications returns the per-recipient deliveries of a group
+// notification ordered by id.
+func (r *Repository) GetChildNotifications(parentId int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE parent_id = $1 ORDER BY id"
+
+	return r.queryNotifications(query, parentId)
+}
+
+// GetFollowUps returns the notifications snoozed or resent from the
+// notification ordered by id.
+func (r *Repository) GetFollowUps(id int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE follow_up_of = $1 ORDER BY id"
+
+	return r.queryNotifications(query, id)
+}
+
+func (r *Repository) queryNotifications(query string, args ...any) ([]model.Notification, error) {
+	rows, err := r.db.Master.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get all notifications from db: %w", err)
 	}
@@ -74,21 +96,13 @@ func (r *Repository) GetReadyNotifications() ([]model.Notification, error) {
 
 	var notifications []model.Notification
 	for rows.Next() {
-		var notification model.Notification
-		err := rows.Scan(
-			&notification.Id,
-			&notification.Text,
-			&notification.Status,
-			&notification.TelegramId,
-			&notification.SendAt,
-			&notification.CreatedAt,
-		)
+		notification, err := scanNotification(rows)
 		if err != nil {
 			return nil, fmt.Errorf("could not scan row to model: %w", err)
 		}
 
-		notifications = append(notifications, notification)
+		notifications = append(notifications, *notification)
 	}
 
-	return notifications, nil
+	return notifications, rows.Err()
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/lib/pq"
+)
+
+// GetPreferences returns the preferences of the recipient, the defaults if
+// it never changed them.
+func (r *Repository) GetPreferences(recipientId int) (*model.Preferences, error) {
+	query := `SELECT r.id, COALESCE(p.unsubscribed, FALSE), COALESCE(p.muted_categories, ''), COALESCE(p.opted_out_channels, ''),
+		COALESCE(p.digest_window, 0), COALESCE(p.digest_categories, '')
+	FROM recipients r
+	LEFT JOIN recipient_preferences p ON p.recipient_id = r.id
+	WHERE r.id = $1`
+
+	var preferences model.Preferences
+	var muted, optedOut, digestCategories string
+	err := r.db.Master.QueryRow(query, recipientId).Scan(
+		&preferences.RecipientId,
+		&preferences.Unsubscribed,
+		&muted,
+		&optedOut,
+		&preferences.DigestWindow,
+		&digestCategories,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoSuchRecipient
+		}
+		return nil, fmt.Errorf("could not get preferences from db: %w", err)
+	}
+
+	preferences.MutedCategories = splitList(muted)
+	preferences.OptedOutChannels = splitList(optedOut)
+	preferences.DigestCategories = splitList(digestCategories)
+	return &preferences, nil
+}
+
+func (r *Repository) SavePreferences(preferences model.Preferences) error {
+	query := `INSERT INTO recipient_preferences(recipient_id, unsubscribed, muted_categories, opted_out_channels, digest_window, digest_categories)
+	VALUES($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (recipient_id) DO UPDATE SET
+		unsubscribed = EXCLUDED.unsubscribed,
+		muted_categories = EXCLUDED.muted_categories,
+		opted_out_channels = EXCLUDED.opted_out_channels,
+		digest_window = EXCLUDED.digest_window,
+		digest_categories = EXCLUDED.digest_categories,
+		updated_at = CURRENT_TIMESTAMP`
+
+	_, err := r.db.Master.Exec(
+		query,
+		preferences.RecipientId,
+		preferences.Unsubscribed,
+		strings.Join(preferences.MutedCategories, ","),
+		strings.Join(preferences.OptedOutChannels, ","),
+		preferences.DigestWindow,
+		strings.Join(preferences.DigestCategories, ","),
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+			return ErrNoSuchRecipient
+		}
+		return fmt.Errorf("could not save preferences to db: %w", err)
+	}
+
+	return nil
+}
+
+// splitList splits a comma separated column, an empty one is an empty list.
+func splitList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,38 @@
+package repository
+
+import "fmt"
+
+func (r *Repository) MarkRecipientUnreachable(telegramId int, reason string) error {
+	query := `INSERT INTO unreachable_recipients(telegram_id, reason)
+	VALUES($1, $2)
+	ON CONFLICT (telegram_id) DO UPDATE SET reason = EXCLUDED.reason`
+
+	_, err := r.db.Master.Exec(query, telegramId, reason)
+	if err != nil {
+		return fmt.Errorf("could not mark recipient as unreachable: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) MarkRecipientReachable(telegramId int) error {
+	query := "DELETE FROM unreachable_recipients WHERE telegram_id = $1"
+
+	_, err := r.db.Master.Exec(query, telegramId)
+	if err != nil {
+		return fmt.Errorf("could not mark recipient as reachable: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) IsRecipientUnreachable(telegramId int) (bool, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM unreachable_recipients WHERE telegram_id = $1)"
+
+	var unreachable bool
+	if err := r.db.Master.QueryRow(query, telegramId).Scan(&unreachable); err != nil {
+		return false, fmt.Errorf("could not check recipient reachability: %w", err)
+	}
+
+	return unreachable, nil
+}
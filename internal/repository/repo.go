@@ -1,13 +1,30 @@
 package repository
 
 import (
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/Komilov31/delayed-notifier/internal/model"
 	"github.com/wb-go/wbf/dbpg"
 )
 
+const (
+	notificationColumns = "id, text, status, telegram_id, send_at, created_at, options, version, COALESCE(recipient_id, 0), COALESCE(channels, ''), COALESCE(group_id, 0), COALESCE(parent_id, 0), COALESCE(category, ''), COALESCE(digest_id, 0), COALESCE(collapse_key, ''), COALESCE(expires_at, 0), COALESCE(follow_up_of, 0), COALESCE(tenant, ''), claims"
+)
+
 var (
 	ErrNoSuchNotification = errors.New("there is not notification with such id")
+	ErrNotActive          = errors.New("notification is not active anymore")
+	ErrNoSuchSubscriber   = errors.New("there is no subscriber with such handle")
+	ErrHandleTaken        = errors.New("handle is linked to another chat")
+	ErrNoSuchRecipient    = errors.New("there is no recipient with such id")
+	ErrNoSuchContact      = errors.New("there is no contact with such id")
+	ErrDuplicateContact   = errors.New("recipient already has this contact")
+	ErrNoSuchGroup        = errors.New("there is no group with such id")
+	ErrNotGroupMember     = errors.New("recipient is not a member of the group")
 )
 
 type Repository struct {
@@ -19,3 +36,65 @@ func New(db *dbpg.DB) *Repository {
 		db: db,
 	}
 }
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row scanner) (*model.Notification, error) {
+	var notification model.Notification
+	var options []byte
+	var channels string
+
+	err := row.Scan(
+		&notification.Id,
+		&notification.Text,
+		&notification.Status,
+		&notification.TelegramId,
+		&notification.SendAt,
+		&notification.CreatedAt,
+		&options,
+		&notification.Version,
+		&notification.RecipientId,
+		&channels,
+		&notification.GroupId,
+		&notification.ParentId,
+		&notification.Category,
+		&notification.DigestId,
+		&notification.CollapseKey,
+		&notification.ExpiresAt,
+		&notification.FollowUpOf,
+		&notification.Tenant,
+		&notification.Claims,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if channels != "" {
+		notification.Channels = strings.Split(channels, ",")
+	}
+
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &notification.Options); err != nil {
+			return nil, fmt.Errorf("could not unmarshal notification options: %w", err)
+		}
+	}
+
+	return &notification, nil
+}
+
+// marshalOptions returns options as a string because lib/pq sends []byte
+// parameters as bytea, which can not be cast to jsonb.
+func marshalOptions(options *model.TelegramOptions) (sql.NullString, error) {
+	if options == nil {
+		return sql.NullString{}, nil
+	}
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(body), Valid: true}, nil
+}
@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/repository/storagetest"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// tables are the tables of the migrations, to be extended with every new one.
+const tables = `notifications, subscribers, unreachable_recipients, recipients, contacts,
+	delivery_attempts, groups, group_members, recipient_preferences`
+
+// TestRepository_Conformance needs a migrated database, e.g. the one from
+// docker-compose:
+//
+//	TEST_POSTGRES_DSN="host=localhost port=5434 user=user password=... dbname=delayed-notifier sslmode=disable" go test ./internal/repository/
+//
+// The tables are truncated before every test case.
+func TestRepository_Conformance(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := dbpg.New(dsn, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Master.Close() })
+
+	storagetest.Run(t, func(t *testing.T) service.Storage {
+		_, err := db.Master.Exec("TRUNCATE " + tables + " RESTART IDENTITY CASCADE")
+		require.NoError(t, err)
+		return repository.New(db)
+	})
+}
@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// CollapseNotification creates the notification unless policy collapses it
+// into an active notification of the same addressee with the same collapse
+// key, see model.CollapseKeepFirst. The transaction holds the only
+// connection, so concurrent creates apply the policy one after another.
+func (r *Repository) CollapseNotification(notification model.Notification, policy string) (*model.CollapseResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + notificationColumns + `
+	FROM notifications
+	WHERE collapse_key = ? AND status = 'active'
+	AND telegram_id = ? AND COALESCE(recipient_id, 0) = ? AND COALESCE(group_id, 0) = ?
+	ORDER BY id`
+
+	rows, err := tx.Query(query, notification.CollapseKey, notification.TelegramId, notification.RecipientId, notification.GroupId)
+	if err != nil {
+		return nil, fmt.Errorf("could not get collapsed notifications from db: %w", err)
+	}
+
+	var earlier []model.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not scan row to model: %w", err)
+		}
+		earlier = append(earlier, *n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get collapsed notifications from db: %w", err)
+	}
+
+	var result model.CollapseResult
+	switch {
+	case len(earlier) == 0:
+		created, err := insertNotification(tx, notification)
+		if err != nil {
+			return nil, err
+		}
+		result = model.CollapseResult{Notification: *created, Created: true}
+
+	case policy == model.CollapseKeepFirst:
+		result = model.CollapseResult{Notification: earlier[0]}
+
+	case policy == model.CollapseMerge:
+		options, err := marshalOptions(notification.Options)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal notification options: %w", err)
+		}
+
+		query := `UPDATE notifications
+		SET text = ?, options = ?, channels = NULLIF(?, ''), category = NULLIF(?, ''),
+			send_at = MIN(send_at, ?), expires_at = NULLIF(?, 0), claimed_until = 0, claims = 0, version = version + 1
+		WHERE id = ?
+		RETURNING ` + notificationColumns
+
+		merged, err := scanNotification(tx.QueryRow(
+			query,
+			notification.Text,
+			options,
+			strings.Join(notification.Channels, ","),
+			notification.Category,
+			notification.SendAt,
+			notification.ExpiresAt,
+			earlier[0].Id,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("could not merge notification: %w", err)
+		}
+		result = model.CollapseResult{Notification: *merged, Merged: true}
+
+	default:
+		query := "UPDATE notifications SET status = 'canceled', version = version + 1 WHERE id = ?"
+		for _, n := range earlier {
+			if _, err := tx.Exec(query, n.Id); err != nil {
+				return nil, fmt.Errorf("could not cancel collapsed notification: %w", err)
+			}
+			result.Canceled = append(result.Canceled, n.Id)
+		}
+
+		created, err := insertNotification(tx, notification)
+		if err != nil {
+			return nil, err
+		}
+		result.Notification = *created
+		result.Created = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit collapsed notification: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+const contactColumns = "id, channel, address, position, verified, verification_code, created_at"
+
+// CreateRecipient saves the recipient with its contacts, which get
+// positions in the given order.
+func (r *Repository) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO recipients(name) VALUES(?) RETURNING id, created_at"
+	if err := tx.QueryRow(query, recipient.Name).Scan(&recipient.Id, &recipient.CreatedAt); err != nil {
+		return nil, fmt.Errorf("could not save recipient to db: %w", err)
+	}
+
+	query = `INSERT INTO contacts(recipient_id, channel, address, position, verified, verification_code)
+	VALUES(?, ?, ?, ?, ?, ?) RETURNING id, created_at`
+
+	for i := range recipient.Contacts {
+		contact := &recipient.Contacts[i]
+		contact.Position = i
+
+		err := tx.QueryRow(
+			query,
+			recipient.Id,
+			contact.Channel,
+			contact.Address,
+			contact.Position,
+			contact.Verified,
+			contact.VerificationCode,
+		).Scan(&contact.Id, &contact.CreatedAt)
+		if err != nil {
+			return nil, contactError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit recipient: %w", err)
+	}
+
+	return &recipient, nil
+}
+
+func (r *Repository) GetRecipient(id int) (*model.Recipient, error) {
+	query := "SELECT id, name, created_at FROM recipients WHERE id = ?"
+
+	var recipient model.Recipient
+	err := r.db.QueryRow(query, id).Scan(&recipient.Id, &recipient.Name, &recipient.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNoSuchRecipient
+		}
+		return nil, fmt.Errorf("could not get recipient from db: %w", err)
+	}
+
+	contacts, err := r.queryContacts("WHERE recipient_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	recipient.Contacts = contacts[id]
+
+	return &recipient, nil
+}
+
+func (r *Repository) GetAllRecipients() ([]model.Recipient, error) {
+	rows, err := r.db.Query("SELECT id, name, created_at FROM recipients ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("could not get recipients from db: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []model.Recipient
+	for rows.Next() {
+		var recipient model.Recipient
+		if err := rows.Scan(&recipient.Id, &recipient.Name, &recipient.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan recipient: %w", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get recipients from db: %w", err)
+	}
+
+	contacts, err := r.queryContacts("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range recipients {
+		recipients[i].Contacts = contacts[recipients[i].Id]
+	}
+
+	return recipients, nil
+}
+
+func (r *Repository) RenameRecipient(id int, name string) error {
+	result, err := r.db.Exec("UPDATE recipients SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		return fmt.Errorf("could not rename recipient: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNoSuchRecipient)
+}
+
+// DeleteRecipient deletes the recipient with its contacts. Notifications
+// addressed to it stay and fail when they are sent.
+func (r *Repository) DeleteRecipient(id int) error {
+	result, err := r.db.Exec("DELETE FROM recipients WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("could not delete recipient: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNoSuchRecipient)
+}
+
+// AddContact appends the contact to the end of the recipient's contacts.
+func (r *Repository) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	query := `INSERT INTO contacts(recipient_id, channel, address, position, verified, verification_code)
+	SELECT ?, ?, ?, COALESCE(MAX(position) + 1, 0), ?, ? FROM contacts WHERE recipient_id = ?
+	RETURNING id, position, created_at`
+
+	err := r.db.QueryRow(
+		query,
+		recipientId,
+		contact.Channel,
+		contact.Address,
+		contact.Verified,
+		contact.VerificationCode,
+		recipientId,
+	).Scan(&contact.Id, &contact.Position, &contact.CreatedAt)
+	if err != nil {
+		return nil, contactError(err)
+	}
+
+	return &contact, nil
+}
+
+func (r *Repository) DeleteContact(recipientId, contactId int) error {
+	query := "DELETE FROM contacts WHERE id = ? AND recipient_id = ?"
+
+	result, err := r.db.Exec(query, contactId, recipientId)
+	if err != nil {
+		return fmt.Errorf("could not delete contact: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNoSuchContact)
+}
+
+func (r *Repository) VerifyContact(recipientId, contactId int) error {
+	query := `UPDATE contacts SET verified = TRUE, verification_code = ''
+	WHERE id = ? AND recipient_id = ?`
+
+	result, err := r.db.Exec(query, contactId, recipientId)
+	if err != nil {
+		return fmt.Errorf("could not verify contact: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNoSuchContact)
+}
+
+// ReorderContacts sets contact positions to their indexes in contactIds.
+func (r *Repository) ReorderContacts(recipientId int, contactIds []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE contacts SET position = ? WHERE id = ? AND recipient_id = ?"
+	for position, id := range contactIds {
+		result, err := tx.Exec(query, position, id, recipientId)
+		if err != nil {
+			return fmt.Errorf("could not reorder contacts: %w", err)
+		}
+		if err := expectAffected(result, repository.ErrNoSuchContact); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit contact order: %w", err)
+	}
+
+	return nil
+}
+
+// queryContacts returns contacts matching where grouped by recipient id and
+// ordered by position.
+func (r *Repository) queryContacts(where string, args ...any) (map[int][]model.Contact, error) {
+	query := "SELECT recipient_id, " + contactColumns + " FROM contacts " + where + " ORDER BY position, id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get contacts from db: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := make(map[int][]model.Contact)
+	for rows.Next() {
+		var recipientId int
+		var contact model.Contact
+		err := rows.Scan(
+			&recipientId,
+			&contact.Id,
+			&contact.Channel,
+			&contact.Address,
+			&contact.Position,
+			&contact.Verified,
+			&contact.VerificationCode,
+			&contact.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan contact: %w", err)
+		}
+		contacts[recipientId] = append(contacts[recipientId], contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get contacts from db: %w", err)
+	}
+
+	return contacts, nil
+}
+
+func contactError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique:
+			return repository.ErrDuplicateContact
+		case sqlite3.ErrConstraintForeignKey:
+			return repository.ErrNoSuchRecipient
+		}
+	}
+	return fmt.Errorf("could not save contact to db: %w", err)
+}
+
+func expectAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
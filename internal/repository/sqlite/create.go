@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (r *Repository) CreateNotification(notification model.Notification) (*model.Notification, error) {
+	return insertNotification(r.db, notification)
+}
+
+func insertNotification(db queryRower, notification model.Notification) (*model.Notification, error) {
+	query := `INSERT INTO notifications(text, status, telegram_id, send_at, options, recipient_id, channels, group_id, parent_id, category, collapse_key, expires_at, follow_up_of, tenant)
+	VALUES(?, ?, ?, ?, ?, NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, 0), NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), NULLIF(?, 0), NULLIF(?, '')) RETURNING id, created_at, version`
+
+	options, err := marshalOptions(notification.Options)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal notification options: %w", err)
+	}
+
+	err = db.QueryRow(
+		query,
+		notification.Text,
+		notification.Status,
+		notification.TelegramId,
+		notification.SendAt,
+		options,
+		notification.RecipientId,
+		strings.Join(notification.Channels, ","),
+		notification.GroupId,
+		notification.ParentId,
+		notification.Category,
+		notification.CollapseKey,
+		notification.ExpiresAt,
+		notification.FollowUpOf,
+		notification.Tenant,
+	).Scan(&notification.Id, &notification.CreatedAt, &notification.Version)
+	if err != nil {
+		return nil, fmt.Errorf("could not scan notification info from db: %w", err)
+	}
+
+	return &notification, nil
+}
@@ -0,0 +1,14 @@
+package sqlite
+
+import "fmt"
+
+func (r *Repository) DeleteNotificationById(id int) error {
+	query := "DELETE FROM notifications WHERE id = ?"
+
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("could not delete notification from db: %w", err)
+	}
+
+	return nil
+}
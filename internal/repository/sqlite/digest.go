@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+// AssignDigest makes the notification leaderId the digest of the active
+// notifications of the recipient due before dueBefore (unix millis) and
+// returns them ordered by send time. Only plain text notifications without
+// channels of the given categories, or of any if there are none, are
+// assigned, and only if they are not in a digest of another active
+// notification already. SQLite runs the single UPDATE atomically, so
+// concurrent digests of the recipient never overlap.
+func (r *Repository) AssignDigest(leaderId, recipientId, dueBefore int, categories []string) ([]model.Notification, error) {
+	query := `UPDATE notifications SET digest_id = ?1
+	WHERE recipient_id = ?2 AND status = 'active' AND send_at < ?3
+	AND options IS NULL AND COALESCE(channels, '') = ''
+	AND (?4 = '' OR instr(',' || ?4 || ',', ',' || COALESCE(category, '') || ',') > 0)
+	AND (digest_id IS NULL OR digest_id = ?1 OR NOT EXISTS(
+		SELECT 1 FROM notifications l
+		WHERE l.id = notifications.digest_id AND l.status = 'active' AND l.digest_id = l.id
+	))
+	RETURNING ` + notificationColumns
+
+	notifications, err := r.queryNotifications(query, leaderId, recipientId, dueBefore, strings.Join(categories, ","))
+	if err != nil {
+		return nil, fmt.Errorf("could not assign digest: %w", err)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].SendAt != notifications[j].SendAt {
+			return notifications[i].SendAt < notifications[j].SendAt
+		}
+		return notifications[i].Id < notifications[j].Id
+	})
+	return notifications, nil
+}
+
+// LeaveDigest takes the notification out of its digest, so it is sent on
+// its own or in a later digest.
+func (r *Repository) LeaveDigest(id int) error {
+	result, err := r.db.Exec("UPDATE notifications SET digest_id = NULL WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("could not leave digest: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not leave digest: %w", err)
+	}
+
+	if affected == 0 {
+		return repository.ErrNoSuchNotification
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+// readyWindow matches the window used by the postgres repository.
+const readyWindow = 30 * time.Second
+
+func (r *Repository) GetNotificationById(id int) (*model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE id = ?"
+
+	notification, err := scanNotification(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNoSuchNotification
+		}
+		return nil, fmt.Errorf("could not get notification from db: %w", err)
+	}
+
+	return notification, nil
+}
+
+func (r *Repository) GetAllNotifications() ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications ORDER BY id"
+
+	return r.queryNotifications(query)
+}
+
+// GetNotificationsAfter returns up to limit notifications with an id
+// greater than afterId ordered by id, a page of a keyset scan.
+func (r *Repository) GetNotificationsAfter(afterId, limit int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE id > ? ORDER BY id LIMIT ?"
+
+	return r.queryNotifications(query, afterId, limit)
+}
+
+func (r *Repository) GetReadyNotifications() ([]model.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+	FROM notifications
+	WHERE send_at < ? AND status = 'active'`
+
+	return r.queryNotifications(query, time.Now().Add(readyWindow).UnixMilli())
+}
+
+func (r *Repository) ClaimReadyNotifications(leaseUntil int) ([]model.Notification, error) {
+	now := time.Now()
+	query := `UPDATE notifications
+	SET claimed_until = ?, claims = claims + 1
+	WHERE send_at < ? AND status = 'active' AND claimed_until < ?
+	RETURNING ` + notificationColumns
+
+	return r.queryNotifications(query, leaseUntil, now.Add(readyWindow).UnixMilli(), now.UnixMilli())
+}
+
+func (r *Repository) GetUpcomingNotifications(telegramId int) ([]model.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+	FROM notifications
+	WHERE telegram_id = ? AND status = 'active'
+	ORDER BY send_at`
+
+	return r.queryNotifications(query, telegramId)
+}
+
+// GetChildNotifications returns the per-recipient deliveries of a group
+// notification ordered by id.
+func (r *Repository) GetChildNotifications(parentId int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE parent_id = ? ORDER BY id"
+
+	return r.queryNotifications(query, parentId)
+}
+
+// GetFollowUps returns the notifications snoozed or resent from the
+// notification ordered by id.
+func (r *Repository) GetFollowUps(id int) ([]model.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE follow_up_of = ? ORDER BY id"
+
+	return r.queryNotifications(query, id)
+}
+
+func (r *Repository) queryNotifications(query string, args ...any) ([]model.Notification, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get all notifications from db: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row to model: %w", err)
+		}
+
+		notifications = append(notifications, *notification)
+	}
+
+	return notifications, rows.Err()
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+func (r *Repository) CreateGroup(group model.Group) (*model.Group, error) {
+	query := "INSERT INTO groups(name) VALUES(?) RETURNING id, created_at"
+	if err := r.db.QueryRow(query, group.Name).Scan(&group.Id, &group.CreatedAt); err != nil {
+		return nil, fmt.Errorf("could not save group to db: %w", err)
+	}
+
+	group.Members = []int{}
+	return &group, nil
+}
+
+func (r *Repository) GetGroup(id int) (*model.Group, error) {
+	query := "SELECT id, name, created_at FROM groups WHERE id = ?"
+
+	var group model.Group
+	err := r.db.QueryRow(query, id).Scan(&group.Id, &group.Name, &group.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNoSuchGroup
+		}
+		return nil, fmt.Errorf("could not get group from db: %w", err)
+	}
+
+	members, err := r.queryMembers("WHERE group_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	group.Members = append([]int{}, members[id]...)
+
+	return &group, nil
+}
+
+func (r *Repository) GetAllGroups() ([]model.Group, error) {
+	rows, err := r.db.Query("SELECT id, name, created_at FROM groups ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("could not get groups from db: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []model.Group
+	for rows.Next() {
+		var group model.Group
+		if err := rows.Scan(&group.Id, &group.Name, &group.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get groups from db: %w", err)
+	}
+
+	members, err := r.queryMembers("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].Members = append([]int{}, members[groups[i].Id]...)
+	}
+
+	return groups, nil
+}
+
+// DeleteGroup deletes the group with its memberships. Notifications for
+// the group stay and fail when they are sent.
+func (r *Repository) DeleteGroup(id int) error {
+	result, err := r.db.Exec("DELETE FROM groups WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("could not delete group: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNoSuchGroup)
+}
+
+// AddGroupMember subscribes the recipient to the group. Subscribing a
+// member again is not an error.
+func (r *Repository) AddGroupMember(groupId, recipientId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM groups WHERE id = ?)", groupId).Scan(&exists); err != nil {
+		return fmt.Errorf("could not get group from db: %w", err)
+	}
+	if !exists {
+		return repository.ErrNoSuchGroup
+	}
+
+	query := `INSERT INTO group_members(group_id, recipient_id) VALUES(?, ?)
+	ON CONFLICT (group_id, recipient_id) DO NOTHING`
+
+	if _, err := tx.Exec(query, groupId, recipientId); err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey {
+			return repository.ErrNoSuchRecipient
+		}
+		return fmt.Errorf("could not save group member to db: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit group member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RemoveGroupMember(groupId, recipientId int) error {
+	query := "DELETE FROM group_members WHERE group_id = ? AND recipient_id = ?"
+
+	result, err := r.db.Exec(query, groupId, recipientId)
+	if err != nil {
+		return fmt.Errorf("could not delete group member: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrNotGroupMember)
+}
+
+// queryMembers returns recipient ids of memberships matching where grouped
+// by group id and ordered by recipient id.
+func (r *Repository) queryMembers(where string, args ...any) (map[int][]int, error) {
+	query := "SELECT group_id, recipient_id FROM group_members " + where + " ORDER BY recipient_id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get group members from db: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[int][]int)
+	for rows.Next() {
+		var groupId, recipientId int
+		if err := rows.Scan(&groupId, &recipientId); err != nil {
+			return nil, fmt.Errorf("could not scan group member: %w", err)
+		}
+		members[groupId] = append(members[groupId], recipientId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get group members from db: %w", err)
+	}
+
+	return members, nil
+}
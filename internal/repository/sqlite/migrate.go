@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+const upMarker = "-- +goose Up"
+const downMarker = "-- +goose Down"
+
+// noTransactionMarker runs a migration outside of a transaction, e.g. one
+// that changes PRAGMA foreign_keys, which has no effect inside of one. Such
+// a migration manages its own transaction.
+const noTransactionMarker = "-- +goose NO TRANSACTION"
+
+// migrate applies the Up sections of embedded migrations that were not
+// applied yet. Files use the goose format of the postgres migrations.
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations(
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create migrations table: %w", err)
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("could not list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := applyMigration(db, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, name string) error {
+	version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+	var applied bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)", version).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("could not check migration %s: %w", version, err)
+	}
+
+	if applied {
+		return nil
+	}
+
+	content, err := migrations.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("could not read migration %s: %w", version, err)
+	}
+
+	if strings.Contains(string(content), noTransactionMarker) {
+		if _, err := db.Exec(upSection(string(content))); err != nil {
+			db.Exec("ROLLBACK")
+			return fmt.Errorf("could not apply migration %s: %w", version, err)
+		}
+
+		if _, err := db.Exec("INSERT INTO schema_migrations(version) VALUES(?)", version); err != nil {
+			return fmt.Errorf("could not record migration %s: %w", version, err)
+		}
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin migration %s: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(upSection(string(content))); err != nil {
+		return fmt.Errorf("could not apply migration %s: %w", version, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations(version) VALUES(?)", version); err != nil {
+		return fmt.Errorf("could not record migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
+}
+
+func upSection(content string) string {
+	_, up, found := strings.Cut(content, upMarker)
+	if !found {
+		up = content
+	}
+
+	up, _, _ = strings.Cut(up, downMarker)
+	return up
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notifications(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    text TEXT,
+    status TEXT CHECK (status IN ('active', 'canceled', 'completed', 'failed')),
+    telegram_id INTEGER NOT NULL,
+    send_at INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    options TEXT
+);
+
+CREATE INDEX IF NOT EXISTS notifications_telegram_id_idx ON notifications(telegram_id);
+CREATE INDEX IF NOT EXISTS notifications_status_send_at_idx ON notifications(status, send_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS subscribers(
+    handle TEXT PRIMARY KEY,
+    telegram_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS unreachable_recipients(
+    telegram_id INTEGER PRIMARY KEY,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS unreachable_recipients;
+DROP TABLE IF EXISTS subscribers;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN claimed_until INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN claimed_until;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN version;
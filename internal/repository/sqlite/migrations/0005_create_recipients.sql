@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS recipients(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS contacts(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipient_id INTEGER NOT NULL REFERENCES recipients(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL CHECK (channel IN ('telegram', 'email', 'phone', 'webhook')),
+    address TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verification_code TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recipient_id, channel, address)
+);
+
+ALTER TABLE notifications ADD COLUMN recipient_id INTEGER;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN recipient_id;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS recipients;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN channels TEXT;
+
+CREATE TABLE IF NOT EXISTS delivery_attempts(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id INTEGER NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL,
+    address TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS delivery_attempts_notification_id_idx ON delivery_attempts(notification_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS delivery_attempts;
+ALTER TABLE notifications DROP COLUMN channels;
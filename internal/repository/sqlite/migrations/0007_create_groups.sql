@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS groups(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_members(
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    recipient_id INTEGER NOT NULL REFERENCES recipients(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, recipient_id)
+);
+
+ALTER TABLE notifications ADD COLUMN group_id INTEGER;
+ALTER TABLE notifications ADD COLUMN parent_id INTEGER REFERENCES notifications(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS notifications_parent_id_idx ON notifications(parent_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_parent_id_idx;
+ALTER TABLE notifications DROP COLUMN parent_id;
+ALTER TABLE notifications DROP COLUMN group_id;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS recipient_preferences(
+    recipient_id INTEGER PRIMARY KEY REFERENCES recipients(id) ON DELETE CASCADE,
+    unsubscribed BOOLEAN NOT NULL DEFAULT FALSE,
+    muted_categories TEXT NOT NULL DEFAULT '',
+    opted_out_channels TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE notifications ADD COLUMN category TEXT;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN category;
+DROP TABLE IF EXISTS recipient_preferences;
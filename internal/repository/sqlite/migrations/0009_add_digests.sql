@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE recipient_preferences ADD COLUMN digest_window INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE recipient_preferences ADD COLUMN digest_categories TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE notifications ADD COLUMN digest_id INTEGER;
+CREATE INDEX IF NOT EXISTS notifications_recipient_id_status_idx ON notifications(recipient_id, status);
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_recipient_id_status_idx;
+ALTER TABLE notifications DROP COLUMN digest_id;
+ALTER TABLE recipient_preferences DROP COLUMN digest_categories;
+ALTER TABLE recipient_preferences DROP COLUMN digest_window;
@@ -0,0 +1,51 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- SQLite cannot change a CHECK constraint, the table is rebuilt to allow the
+-- expired status. Foreign keys are off so that dropping the old table does
+-- not cascade to the delivery history and to group deliveries.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE notifications_new(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    text TEXT,
+    status TEXT CHECK (status IN ('active', 'canceled', 'completed', 'failed', 'expired')),
+    telegram_id INTEGER NOT NULL,
+    send_at INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    options TEXT,
+    claimed_until INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    recipient_id INTEGER,
+    channels TEXT,
+    group_id INTEGER,
+    parent_id INTEGER REFERENCES notifications(id) ON DELETE CASCADE,
+    category TEXT,
+    digest_id INTEGER,
+    collapse_key TEXT,
+    expires_at INTEGER
+);
+
+INSERT INTO notifications_new(id, text, status, telegram_id, send_at, created_at, options, claimed_until, version,
+    recipient_id, channels, group_id, parent_id, category, digest_id, collapse_key)
+SELECT id, text, status, telegram_id, send_at, created_at, options, claimed_until, version,
+    recipient_id, channels, group_id, parent_id, category, digest_id, collapse_key
+FROM notifications;
+
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+
+CREATE INDEX IF NOT EXISTS notifications_telegram_id_idx ON notifications(telegram_id);
+CREATE INDEX IF NOT EXISTS notifications_status_send_at_idx ON notifications(status, send_at);
+CREATE INDEX IF NOT EXISTS notifications_parent_id_idx ON notifications(parent_id);
+CREATE INDEX IF NOT EXISTS notifications_recipient_id_status_idx ON notifications(recipient_id, status);
+CREATE INDEX IF NOT EXISTS notifications_collapse_key_idx ON notifications(collapse_key)
+    WHERE collapse_key IS NOT NULL AND status = 'active';
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN expires_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN follow_up_of INTEGER REFERENCES notifications(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS notifications_follow_up_of_idx ON notifications(follow_up_of)
+    WHERE follow_up_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_follow_up_of_idx;
+ALTER TABLE notifications DROP COLUMN follow_up_of;
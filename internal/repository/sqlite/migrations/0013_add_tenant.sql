@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN tenant TEXT;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN tenant;
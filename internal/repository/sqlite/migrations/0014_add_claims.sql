@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN claims INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN claims;
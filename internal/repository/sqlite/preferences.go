@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// GetPreferences returns the preferences of the recipient, the defaults if
+// it never changed them.
+func (r *Repository) GetPreferences(recipientId int) (*model.Preferences, error) {
+	query := `SELECT r.id, COALESCE(p.unsubscribed, FALSE), COALESCE(p.muted_categories, ''), COALESCE(p.opted_out_channels, ''),
+		COALESCE(p.digest_window, 0), COALESCE(p.digest_categories, '')
+	FROM recipients r
+	LEFT JOIN recipient_preferences p ON p.recipient_id = r.id
+	WHERE r.id = ?`
+
+	var preferences model.Preferences
+	var muted, optedOut, digestCategories string
+	err := r.db.QueryRow(query, recipientId).Scan(
+		&preferences.RecipientId,
+		&preferences.Unsubscribed,
+		&muted,
+		&optedOut,
+		&preferences.DigestWindow,
+		&digestCategories,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNoSuchRecipient
+		}
+		return nil, fmt.Errorf("could not get preferences from db: %w", err)
+	}
+
+	preferences.MutedCategories = splitList(muted)
+	preferences.OptedOutChannels = splitList(optedOut)
+	preferences.DigestCategories = splitList(digestCategories)
+	return &preferences, nil
+}
+
+func (r *Repository) SavePreferences(preferences model.Preferences) error {
+	query := `INSERT INTO recipient_preferences(recipient_id, unsubscribed, muted_categories, opted_out_channels, digest_window, digest_categories)
+	VALUES(?, ?, ?, ?, ?, ?)
+	ON CONFLICT (recipient_id) DO UPDATE SET
+		unsubscribed = EXCLUDED.unsubscribed,
+		muted_categories = EXCLUDED.muted_categories,
+		opted_out_channels = EXCLUDED.opted_out_channels,
+		digest_window = EXCLUDED.digest_window,
+		digest_categories = EXCLUDED.digest_categories,
+		updated_at = CURRENT_TIMESTAMP`
+
+	_, err := r.db.Exec(
+		query,
+		preferences.RecipientId,
+		preferences.Unsubscribed,
+		strings.Join(preferences.MutedCategories, ","),
+		strings.Join(preferences.OptedOutChannels, ","),
+		preferences.DigestWindow,
+		strings.Join(preferences.DigestCategories, ","),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey {
+			return repository.ErrNoSuchRecipient
+		}
+		return fmt.Errorf("could not save preferences to db: %w", err)
+	}
+
+	return nil
+}
+
+// splitList splits a comma separated column, an empty one is an empty list.
+func splitList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,113 @@
+// Package sqlite implements the notifier storage on top of SQLite for small
+// deployments that do not run Postgres.
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	notificationColumns = "id, text, status, telegram_id, send_at, created_at, options, version, COALESCE(recipient_id, 0), COALESCE(channels, ''), COALESCE(group_id, 0), COALESCE(parent_id, 0), COALESCE(category, ''), COALESCE(digest_id, 0), COALESCE(collapse_key, ''), COALESCE(expires_at, 0), COALESCE(follow_up_of, 0), COALESCE(tenant, ''), claims"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+// New opens the database at path, creating it if needed, and applies
+// migrations.
+func New(path string) (*Repository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("could not create sqlite db directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("could not open sqlite db: %w", err)
+	}
+
+	// SQLite allows a single writer, one connection avoids "database is
+	// locked" errors under concurrent updates.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Repository{
+		db: db,
+	}, nil
+}
+
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row scanner) (*model.Notification, error) {
+	var notification model.Notification
+	var options sql.NullString
+	var channels string
+
+	err := row.Scan(
+		&notification.Id,
+		&notification.Text,
+		&notification.Status,
+		&notification.TelegramId,
+		&notification.SendAt,
+		&notification.CreatedAt,
+		&options,
+		&notification.Version,
+		&notification.RecipientId,
+		&channels,
+		&notification.GroupId,
+		&notification.ParentId,
+		&notification.Category,
+		&notification.DigestId,
+		&notification.CollapseKey,
+		&notification.ExpiresAt,
+		&notification.FollowUpOf,
+		&notification.Tenant,
+		&notification.Claims,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if channels != "" {
+		notification.Channels = strings.Split(channels, ",")
+	}
+
+	if options.Valid {
+		if err := json.Unmarshal([]byte(options.String), &notification.Options); err != nil {
+			return nil, fmt.Errorf("could not unmarshal notification options: %w", err)
+		}
+	}
+
+	return &notification, nil
+}
+
+func marshalOptions(options *model.TelegramOptions) (sql.NullString, error) {
+	if options == nil {
+		return sql.NullString{}, nil
+	}
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(body), Valid: true}, nil
+}
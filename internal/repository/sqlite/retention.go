@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// retentionScope selects the notifications of a model.RetentionScope with
+// the parameters of retentionArgs.
+const retentionScope = `instr(',' || ?1 || ',', ',' || n.status || ',') > 0 AND n.send_at < ?2
+	AND ((?3 <> '' AND n.tenant = ?3)
+		OR (?3 = '' AND (?4 = '' OR instr(',' || ?4 || ',', ',' || COALESCE(n.tenant, '') || ',') = 0)))`
+
+func retentionArgs(scope model.RetentionScope) []any {
+	return []any{strings.Join(scope.Statuses, ","), scope.Before, scope.Tenant, strings.Join(scope.Except, ",")}
+}
+
+// PurgeNotifications deletes up to limit notifications in scope, with the
+// deliveries of group notifications among them, and returns the ids of all
+// deleted notifications. Group notifications with active deliveries are
+// kept.
+func (r *Repository) PurgeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Deliveries are collected up front: SQLite cascades deletes row by row,
+	// so they would be gone before a RETURNING clause could see them.
+	query := `WITH doomed AS (
+		SELECT n.id FROM notifications n
+		WHERE ` + retentionScope + `
+		AND NOT EXISTS(SELECT 1 FROM notifications c WHERE c.parent_id = n.id AND c.status = 'active')
+		ORDER BY n.id LIMIT ?5
+	)
+	SELECT id FROM notifications
+	WHERE id IN (SELECT id FROM doomed) OR parent_id IN (SELECT id FROM doomed)
+	ORDER BY id`
+
+	rows, err := tx.Query(query, append(retentionArgs(scope), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not purge notifications: %w", err)
+	}
+
+	ids, err := scanIds(rows)
+	if err != nil {
+		return nil, fmt.Errorf("could not purge notifications: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.Exec(`DELETE FROM notifications WHERE instr(',' || ? || ',', ',' || id || ',') > 0`, joinIds(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not purge notifications: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit purge: %w", err)
+	}
+
+	return ids, nil
+}
+
+// AnonymizeNotifications clears the text and options of up to limit
+// notifications in scope that still have a text, and the addresses and
+// errors of their delivery attempts, and returns their ids.
+func (r *Repository) AnonymizeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE notifications SET text = '', options = NULL, version = version + 1
+	WHERE id IN (
+		SELECT n.id FROM notifications n
+		WHERE ` + retentionScope + ` AND n.text <> ''
+		ORDER BY n.id LIMIT ?5
+	)
+	RETURNING id`
+
+	rows, err := tx.Query(query, append(retentionArgs(scope), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not anonymize notifications: %w", err)
+	}
+
+	ids, err := scanIds(rows)
+	if err != nil {
+		return nil, fmt.Errorf("could not anonymize notifications: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.Exec(`UPDATE delivery_attempts SET address = '', error = ''
+	WHERE instr(',' || ? || ',', ',' || notification_id || ',') > 0`, joinIds(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not anonymize delivery attempts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit anonymization: %w", err)
+	}
+
+	return ids, nil
+}
+
+func scanIds(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func joinIds(ids []int) string {
+	joined := make([]string, 0, len(ids))
+	for _, id := range ids {
+		joined = append(joined, strconv.Itoa(id))
+	}
+	return strings.Join(joined, ",")
+}
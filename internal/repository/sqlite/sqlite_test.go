@@ -0,0 +1,81 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/repository/sqlite"
+	"github.com/Komilov31/delayed-notifier/internal/repository/storagetest"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) service.Storage {
+		repo, err := sqlite.New(filepath.Join(t.TempDir(), "notifier.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
+
+func TestRepository_MigrationsAreIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifier.db")
+
+	repo, err := sqlite.New(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	repo, err = sqlite.New(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+}
+
+func TestRepository_StatusRebuildKeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifier.db")
+
+	repo, err := sqlite.New(path)
+	require.NoError(t, err)
+
+	parent, err := repo.CreateNotification(model.Notification{Text: "group", Status: "completed", GroupId: 1, SendAt: 1})
+	require.NoError(t, err)
+	child, err := repo.CreateNotification(model.Notification{Text: "delivery", Status: "active", ParentId: parent.Id, SendAt: 1})
+	require.NoError(t, err)
+	require.NoError(t, repo.AddDeliveryAttempt(model.DeliveryAttempt{
+		NotificationId: child.Id,
+		Channel:        model.ChannelTelegram,
+		Address:        "42",
+		Status:         model.DeliverySent,
+	}))
+	require.NoError(t, repo.Close())
+
+	// Run the rebuild, and the migrations after it, once more against a
+	// table with rows referencing it.
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM schema_migrations WHERE version >= '0011_add_expires_at'")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo, err = sqlite.New(path)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	children, err := repo.GetChildNotifications(parent.Id)
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, "delivery", children[0].Text)
+
+	attempts, err := repo.GetDeliveryAttempts(child.Id)
+	require.NoError(t, err)
+	assert.Len(t, attempts, 1)
+
+	require.NoError(t, repo.UpdateNotificationStatus(child.Id, "expired"))
+	require.NoError(t, repo.DeleteNotificationById(parent.Id))
+	_, err = repo.GetNotificationById(child.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification, "foreign keys cascade after the rebuild")
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+// SaveSubscriber links handle to the telegram chat. A handle already linked
+// to another chat is not moved, repository.ErrHandleTaken is returned.
+func (r *Repository) SaveSubscriber(subscriber model.Subscriber) error {
+	query := `INSERT INTO subscribers(handle, telegram_id)
+	VALUES(?, ?)
+	ON CONFLICT (handle) DO UPDATE SET telegram_id = excluded.telegram_id
+	WHERE subscribers.telegram_id = excluded.telegram_id`
+
+	result, err := r.db.Exec(query, subscriber.Handle, subscriber.TelegramId)
+	if err != nil {
+		return fmt.Errorf("could not save subscriber to db: %w", err)
+	}
+
+	return expectAffected(result, repository.ErrHandleTaken)
+}
+
+func (r *Repository) GetSubscriberByHandle(handle string) (*model.Subscriber, error) {
+	query := "SELECT handle, telegram_id, created_at FROM subscribers WHERE handle = ?"
+
+	var subscriber model.Subscriber
+	err := r.db.QueryRow(query, handle).Scan(
+		&subscriber.Handle,
+		&subscriber.TelegramId,
+		&subscriber.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNoSuchSubscriber
+		}
+		return nil, fmt.Errorf("could not get subscriber from db: %w", err)
+	}
+
+	return &subscriber, nil
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
+// UpdateNotificationStatus sets the status of the notification. Only an
+// active notification can get a terminal status, repository.ErrNotActive is
+// returned for one that was already sent, canceled, failed or expired.
+// Terminal notifications are only made active again by
+// RescheduleNotification.
+func (r *Repository) UpdateNotificationStatus(id int, newStatus string) error {
+	query := `UPDATE notifications
+	SET status = ?1, version = version + 1
+	WHERE id = ?2 AND status = 'active'`
+
+	result, err := r.db.Exec(query, newStatus, id)
+	if err != nil {
+		return fmt.Errorf("could not update notification status: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not update notification status: %w", err)
+	}
+
+	if affected == 0 {
+		return r.notActive(id)
+	}
+
+	return nil
+}
+
+// RescheduleNotification moves the notification to sendAt and makes it
+// active again. Only an active notification, or a completed one being
+// snoozed, can be rescheduled, repository.ErrNotActive is returned for one
+// that was canceled, failed or expired.
+func (r *Repository) RescheduleNotification(id int, sendAt int) error {
+	query := `UPDATE notifications
+	SET send_at = ?, status = 'active', claimed_until = 0, claims = 0, digest_id = NULL, version = version + 1
+	WHERE id = ? AND status IN ('active', 'completed')`
+
+	result, err := r.db.Exec(query, sendAt, id)
+	if err != nil {
+		return fmt.Errorf("could not reschedule notification: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not reschedule notification: %w", err)
+	}
+
+	if affected == 0 {
+		return r.notActive(id)
+	}
+
+	return nil
+}
+
+// notActive tells why a guarded update of the notification matched no row:
+// repository.ErrNotActive if it exists, repository.ErrNoSuchNotification
+// otherwise.
+func (r *Repository) notActive(id int) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("could not check notification: %w", err)
+	}
+	if exists {
+		return repository.ErrNotActive
+	}
+	return repository.ErrNoSuchNotification
+}
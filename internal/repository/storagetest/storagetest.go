@@ -0,0 +1,938 @@
+// Package storagetest is a conformance test suite for implementations of
+// service.Storage. Every backend runs the same suite, so they stay
+// interchangeable.
+package storagetest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty storage. It is called once per test case.
+type Factory func(t *testing.T) service.Storage
+
+// Run runs the whole suite against storages created by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(*testing.T, service.Storage)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetNotFound", testGetNotFound},
+		{"GetAll", testGetAll},
+		{"GetAfter", testGetAfter},
+		{"ReadyNotifications", testReadyNotifications},
+		{"ClaimReadyNotifications", testClaimReadyNotifications},
+		{"ConcurrentClaims", testConcurrentClaims},
+		{"UpcomingNotifications", testUpcomingNotifications},
+		{"UpdateStatus", testUpdateStatus},
+		{"ConcurrentStatusUpdates", testConcurrentStatusUpdates},
+		{"Reschedule", testReschedule},
+		{"Versions", testVersions},
+		{"Delete", testDelete},
+		{"Subscribers", testSubscribers},
+		{"Reachability", testReachability},
+		{"Recipients", testRecipients},
+		{"Contacts", testContacts},
+		{"NotificationRecipient", testNotificationRecipient},
+		{"DeliveryAttempts", testDeliveryAttempts},
+		{"Groups", testGroups},
+		{"GroupNotification", testGroupNotification},
+		{"Preferences", testPreferences},
+		{"Digests", testDigests},
+		{"Collapse", testCollapse},
+		{"FollowUps", testFollowUps},
+		{"Retention", testRetention},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func sendAt(d time.Duration) int {
+	return int(time.Now().Add(d).UnixMilli())
+}
+
+func create(t *testing.T, storage service.Storage, notification model.Notification) *model.Notification {
+	t.Helper()
+
+	if notification.Status == "" {
+		notification.Status = "active"
+	}
+
+	created, err := storage.CreateNotification(notification)
+	require.NoError(t, err)
+	return created
+}
+
+func ids(notifications []model.Notification) []int {
+	result := make([]int, 0, len(notifications))
+	for _, notification := range notifications {
+		result = append(result, notification.Id)
+	}
+	return result
+}
+
+func testCreateAndGet(t *testing.T, storage service.Storage) {
+	options := &model.TelegramOptions{
+		ParseMode: model.ParseModeHTML,
+		Buttons:   [][]model.Button{{{Text: "Open", URL: "https://example.com"}}},
+	}
+
+	created := create(t, storage, model.Notification{
+		Text:       "Hello",
+		TelegramId: 42,
+		SendAt:     sendAt(time.Hour),
+		Options:    options,
+	})
+	assert.NotZero(t, created.Id)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	got, err := storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, got.Id)
+	assert.Equal(t, "Hello", got.Text)
+	assert.Equal(t, "active", got.Status)
+	assert.Equal(t, 42, got.TelegramId)
+	assert.Equal(t, created.SendAt, got.SendAt)
+	assert.Equal(t, options, got.Options)
+
+	plain := create(t, storage, model.Notification{Text: "Plain", TelegramId: 42, SendAt: sendAt(time.Hour)})
+	assert.NotEqual(t, created.Id, plain.Id)
+
+	got, err = storage.GetNotificationById(plain.Id)
+	require.NoError(t, err)
+	assert.Nil(t, got.Options)
+	assert.Zero(t, got.ExpiresAt)
+
+	expiring := create(t, storage, model.Notification{Text: "Expiring", TelegramId: 42, SendAt: sendAt(time.Hour), ExpiresAt: sendAt(2 * time.Hour)})
+	got, err = storage.GetNotificationById(expiring.Id)
+	require.NoError(t, err)
+	assert.Equal(t, expiring.ExpiresAt, got.ExpiresAt)
+}
+
+func testGetNotFound(t *testing.T, storage service.Storage) {
+	_, err := storage.GetNotificationById(100500)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+}
+
+func testGetAll(t *testing.T, storage service.Storage) {
+	all, err := storage.GetAllNotifications()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	first := create(t, storage, model.Notification{Text: "1", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	second := create(t, storage, model.Notification{Text: "2", TelegramId: 2, SendAt: sendAt(-time.Hour)})
+
+	all, err = storage.GetAllNotifications()
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.Id, second.Id}, ids(all))
+}
+
+func testGetAfter(t *testing.T, storage service.Storage) {
+	first := create(t, storage, model.Notification{Text: "1", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	second := create(t, storage, model.Notification{Text: "2", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	third := create(t, storage, model.Notification{Text: "3", TelegramId: 1, SendAt: sendAt(time.Hour)})
+
+	page, err := storage.GetNotificationsAfter(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.Id, second.Id}, ids(page))
+
+	page, err = storage.GetNotificationsAfter(second.Id, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{third.Id}, ids(page))
+
+	page, err = storage.GetNotificationsAfter(third.Id, 2)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func testReadyNotifications(t *testing.T, storage service.Storage) {
+	overdue := create(t, storage, model.Notification{Text: "overdue", TelegramId: 1, SendAt: sendAt(-time.Hour)})
+	soon := create(t, storage, model.Notification{Text: "soon", TelegramId: 1, SendAt: sendAt(10 * time.Second)})
+	create(t, storage, model.Notification{Text: "later", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	canceled := create(t, storage, model.Notification{Text: "canceled", TelegramId: 1, SendAt: sendAt(-time.Minute)})
+	require.NoError(t, storage.UpdateNotificationStatus(canceled.Id, "canceled"))
+	completed := create(t, storage, model.Notification{Text: "completed", TelegramId: 1, SendAt: sendAt(-time.Minute)})
+	require.NoError(t, storage.UpdateNotificationStatus(completed.Id, "completed"))
+
+	ready, err := storage.GetReadyNotifications()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{overdue.Id, soon.Id}, ids(ready))
+}
+
+func testClaimReadyNotifications(t *testing.T, storage service.Storage) {
+	due := create(t, storage, model.Notification{Text: "due", TelegramId: 1, SendAt: sendAt(-time.Minute)})
+	create(t, storage, model.Notification{Text: "later", TelegramId: 1, SendAt: sendAt(time.Hour)})
+
+	claimed, err := storage.ClaimReadyNotifications(sendAt(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.Id}, ids(claimed))
+	assert.Equal(t, "due", claimed[0].Text)
+	assert.Equal(t, 1, claimed[0].Claims)
+
+	claimed, err = storage.ClaimReadyNotifications(sendAt(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "notification must not be claimed twice while the lease is active")
+
+	ready, err := storage.GetReadyNotifications()
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.Id}, ids(ready), "claimed notifications are still ready")
+
+	require.NoError(t, storage.RescheduleNotification(due.Id, sendAt(-time.Second)))
+
+	claimed, err = storage.ClaimReadyNotifications(sendAt(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.Id}, ids(claimed), "rescheduling must drop the claim")
+	assert.Equal(t, 1, claimed[0].Claims, "rescheduling must reset the claims")
+
+	claimed, err = storage.ClaimReadyNotifications(sendAt(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.Id}, ids(claimed), "expired lease must allow a new claim")
+	assert.Equal(t, 2, claimed[0].Claims)
+}
+
+func testConcurrentClaims(t *testing.T, storage service.Storage) {
+	const notifications = 30
+	const schedulers = 8
+
+	for i := 0; i < notifications; i++ {
+		create(t, storage, model.Notification{Text: "due", TelegramId: i, SendAt: sendAt(-time.Minute)})
+	}
+
+	var mu sync.Mutex
+	claims := make(map[int]int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < schedulers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			claimed, err := storage.ClaimReadyNotifications(sendAt(time.Minute))
+			assert.NoError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, notification := range claimed {
+				claims[notification.Id]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claims, notifications)
+	for id, count := range claims {
+		assert.Equal(t, 1, count, "notification %d claimed more than once", id)
+	}
+}
+
+func testUpcomingNotifications(t *testing.T, storage service.Storage) {
+	later := create(t, storage, model.Notification{Text: "later", TelegramId: 1, SendAt: sendAt(2 * time.Hour)})
+	sooner := create(t, storage, model.Notification{Text: "sooner", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	create(t, storage, model.Notification{Text: "other chat", TelegramId: 2, SendAt: sendAt(time.Hour)})
+	canceled := create(t, storage, model.Notification{Text: "canceled", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	require.NoError(t, storage.UpdateNotificationStatus(canceled.Id, "canceled"))
+
+	upcoming, err := storage.GetUpcomingNotifications(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{sooner.Id, later.Id}, ids(upcoming))
+
+	upcoming, err = storage.GetUpcomingNotifications(3)
+	require.NoError(t, err)
+	assert.Empty(t, upcoming)
+}
+
+func testUpdateStatus(t *testing.T, storage service.Storage) {
+	for _, status := range []string{"canceled", "completed", "failed", "expired"} {
+		created := create(t, storage, model.Notification{Text: "status", TelegramId: 1, SendAt: sendAt(time.Hour)})
+		require.NoError(t, storage.UpdateNotificationStatus(created.Id, status))
+
+		got, err := storage.GetNotificationById(created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, status, got.Status)
+
+		// A terminal status is final, e.g. a canceled notification is
+		// not completed by a consumer that was already sending it.
+		assert.ErrorIs(t, storage.UpdateNotificationStatus(created.Id, "completed"), repository.ErrNotActive)
+		got, err = storage.GetNotificationById(created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, status, got.Status)
+
+		// Nor is it made active again by a status update.
+		assert.ErrorIs(t, storage.UpdateNotificationStatus(created.Id, "active"), repository.ErrNotActive)
+		got, err = storage.GetNotificationById(created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, status, got.Status)
+	}
+
+	assert.ErrorIs(t, storage.UpdateNotificationStatus(100500, "canceled"), repository.ErrNoSuchNotification)
+}
+
+func testConcurrentStatusUpdates(t *testing.T, storage service.Storage) {
+	created := create(t, storage, model.Notification{Text: "status", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	statuses := []string{"canceled", "completed", "failed"}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied []string
+	)
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(status string) {
+			defer wg.Done()
+			err := storage.UpdateNotificationStatus(created.Id, status)
+			if errors.Is(err, repository.ErrNotActive) {
+				return
+			}
+			assert.NoError(t, err)
+
+			mu.Lock()
+			applied = append(applied, status)
+			mu.Unlock()
+		}(statuses[i%len(statuses)])
+	}
+	wg.Wait()
+
+	// Exactly one of the concurrent updates wins.
+	require.Len(t, applied, 1)
+	got, err := storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, applied[0], got.Status)
+}
+
+func testReschedule(t *testing.T, storage service.Storage) {
+	created := create(t, storage, model.Notification{Text: "reschedule", TelegramId: 1, SendAt: sendAt(-time.Hour)})
+	require.NoError(t, storage.UpdateNotificationStatus(created.Id, "completed"))
+
+	newSendAt := sendAt(time.Hour)
+	require.NoError(t, storage.RescheduleNotification(created.Id, newSendAt))
+
+	got, err := storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, newSendAt, got.SendAt)
+	assert.Equal(t, "active", got.Status)
+
+	assert.ErrorIs(t, storage.RescheduleNotification(100500, newSendAt), repository.ErrNoSuchNotification)
+
+	// A canceled notification is not revived.
+	canceled := create(t, storage, model.Notification{Text: "canceled", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	require.NoError(t, storage.UpdateNotificationStatus(canceled.Id, "canceled"))
+	assert.ErrorIs(t, storage.RescheduleNotification(canceled.Id, newSendAt), repository.ErrNotActive)
+
+	got, err = storage.GetNotificationById(canceled.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", got.Status)
+	assert.Equal(t, 2, got.Version)
+}
+
+func testVersions(t *testing.T, storage service.Storage) {
+	created := create(t, storage, model.Notification{Text: "versioned", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	assert.Equal(t, 1, created.Version)
+
+	require.NoError(t, storage.UpdateNotificationStatus(created.Id, "completed"))
+	got, err := storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Version)
+
+	require.NoError(t, storage.RescheduleNotification(created.Id, sendAt(time.Hour)))
+	got, err = storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Version)
+
+	all, err := storage.GetAllNotifications()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, 3, all[0].Version)
+}
+
+func testDelete(t *testing.T, storage service.Storage) {
+	created := create(t, storage, model.Notification{Text: "delete", TelegramId: 1, SendAt: sendAt(time.Hour)})
+
+	require.NoError(t, storage.DeleteNotificationById(created.Id))
+
+	_, err := storage.GetNotificationById(created.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+
+	assert.NoError(t, storage.DeleteNotificationById(created.Id))
+}
+
+func testSubscribers(t *testing.T, storage service.Storage) {
+	_, err := storage.GetSubscriberByHandle("alice")
+	assert.ErrorIs(t, err, repository.ErrNoSuchSubscriber)
+
+	require.NoError(t, storage.SaveSubscriber(model.Subscriber{Handle: "alice", TelegramId: 1}))
+
+	subscriber, err := storage.GetSubscriberByHandle("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", subscriber.Handle)
+	assert.Equal(t, 1, subscriber.TelegramId)
+	assert.False(t, subscriber.CreatedAt.IsZero())
+
+	// Registering again from the same chat is a no-op.
+	require.NoError(t, storage.SaveSubscriber(model.Subscriber{Handle: "alice", TelegramId: 1}))
+
+	// Another chat cannot take the handle over.
+	err = storage.SaveSubscriber(model.Subscriber{Handle: "alice", TelegramId: 2})
+	assert.ErrorIs(t, err, repository.ErrHandleTaken)
+
+	subscriber, err = storage.GetSubscriberByHandle("alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, subscriber.TelegramId)
+}
+
+func testReachability(t *testing.T, storage service.Storage) {
+	unreachable, err := storage.IsRecipientUnreachable(1)
+	require.NoError(t, err)
+	assert.False(t, unreachable)
+
+	require.NoError(t, storage.MarkRecipientUnreachable(1, "blocked"))
+	require.NoError(t, storage.MarkRecipientUnreachable(1, "blocked again"))
+
+	unreachable, err = storage.IsRecipientUnreachable(1)
+	require.NoError(t, err)
+	assert.True(t, unreachable)
+
+	unreachable, err = storage.IsRecipientUnreachable(2)
+	require.NoError(t, err)
+	assert.False(t, unreachable)
+
+	require.NoError(t, storage.MarkRecipientReachable(1))
+	require.NoError(t, storage.MarkRecipientReachable(1))
+
+	unreachable, err = storage.IsRecipientUnreachable(1)
+	require.NoError(t, err)
+	assert.False(t, unreachable)
+}
+
+func contactIds(contacts []model.Contact) []int {
+	result := make([]int, 0, len(contacts))
+	for _, contact := range contacts {
+		result = append(result, contact.Id)
+	}
+	return result
+}
+
+func testRecipients(t *testing.T, storage service.Storage) {
+	_, err := storage.GetRecipient(1)
+	assert.ErrorIs(t, err, repository.ErrNoSuchRecipient)
+
+	created, err := storage.CreateRecipient(model.Recipient{
+		Name: "Alice",
+		Contacts: []model.Contact{
+			{Channel: model.ChannelEmail, Address: "alice@example.com"},
+			{Channel: model.ChannelTelegram, Address: "42", Verified: true},
+		},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, created.Id)
+	assert.False(t, created.CreatedAt.IsZero())
+	require.Len(t, created.Contacts, 2)
+	assert.Equal(t, []int{0, 1}, []int{created.Contacts[0].Position, created.Contacts[1].Position})
+
+	other, err := storage.CreateRecipient(model.Recipient{Name: "Bob"})
+	require.NoError(t, err)
+
+	recipient, err := storage.GetRecipient(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", recipient.Name)
+	assert.Equal(t, contactIds(created.Contacts), contactIds(recipient.Contacts))
+	assert.Equal(t, "alice@example.com", recipient.Contacts[0].Address)
+	assert.False(t, recipient.Contacts[0].Verified)
+	assert.True(t, recipient.Contacts[1].Verified)
+
+	require.NoError(t, storage.RenameRecipient(created.Id, "Alice Smith"))
+	assert.ErrorIs(t, storage.RenameRecipient(other.Id+1, "Nobody"), repository.ErrNoSuchRecipient)
+
+	recipients, err := storage.GetAllRecipients()
+	require.NoError(t, err)
+	require.Len(t, recipients, 2)
+	assert.Equal(t, "Alice Smith", recipients[0].Name)
+	assert.Len(t, recipients[0].Contacts, 2)
+	assert.Equal(t, "Bob", recipients[1].Name)
+	assert.Empty(t, recipients[1].Contacts)
+
+	require.NoError(t, storage.DeleteRecipient(created.Id))
+	assert.ErrorIs(t, storage.DeleteRecipient(created.Id), repository.ErrNoSuchRecipient)
+
+	_, err = storage.GetRecipient(created.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchRecipient)
+	assert.ErrorIs(t, storage.VerifyContact(created.Id, created.Contacts[0].Id), repository.ErrNoSuchContact)
+}
+
+func testContacts(t *testing.T, storage service.Storage) {
+	recipient, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+
+	_, err = storage.AddContact(recipient.Id+1, model.Contact{Channel: model.ChannelEmail, Address: "a@example.com"})
+	assert.ErrorIs(t, err, repository.ErrNoSuchRecipient)
+
+	email, err := storage.AddContact(recipient.Id, model.Contact{
+		Channel:          model.ChannelEmail,
+		Address:          "alice@example.com",
+		VerificationCode: "123456",
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, email.Id)
+	assert.Equal(t, 0, email.Position)
+
+	telegram, err := storage.AddContact(recipient.Id, model.Contact{Channel: model.ChannelTelegram, Address: "42"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, telegram.Position)
+
+	_, err = storage.AddContact(recipient.Id, model.Contact{Channel: model.ChannelEmail, Address: "alice@example.com"})
+	assert.ErrorIs(t, err, repository.ErrDuplicateContact)
+
+	stored, err := storage.GetRecipient(recipient.Id)
+	require.NoError(t, err)
+	require.Len(t, stored.Contacts, 2)
+	assert.Equal(t, "123456", stored.Contacts[0].VerificationCode)
+
+	require.NoError(t, storage.VerifyContact(recipient.Id, email.Id))
+	assert.ErrorIs(t, storage.VerifyContact(recipient.Id, email.Id+100), repository.ErrNoSuchContact)
+
+	require.NoError(t, storage.ReorderContacts(recipient.Id, []int{telegram.Id, email.Id}))
+	assert.ErrorIs(t, storage.ReorderContacts(recipient.Id, []int{email.Id + 100}), repository.ErrNoSuchContact)
+
+	stored, err = storage.GetRecipient(recipient.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{telegram.Id, email.Id}, contactIds(stored.Contacts))
+	assert.True(t, stored.Contacts[1].Verified)
+	assert.Empty(t, stored.Contacts[1].VerificationCode)
+
+	require.NoError(t, storage.DeleteContact(recipient.Id, telegram.Id))
+	assert.ErrorIs(t, storage.DeleteContact(recipient.Id, telegram.Id), repository.ErrNoSuchContact)
+
+	phone, err := storage.AddContact(recipient.Id, model.Contact{Channel: model.ChannelPhone, Address: "+15551234567"})
+	require.NoError(t, err)
+
+	stored, err = storage.GetRecipient(recipient.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{email.Id, phone.Id}, contactIds(stored.Contacts))
+}
+
+func testNotificationRecipient(t *testing.T, storage service.Storage) {
+	recipient, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+
+	created := create(t, storage, model.Notification{
+		Text:        "Hello",
+		RecipientId: recipient.Id,
+		Channels:    []string{model.ChannelEmail, model.ChannelTelegram},
+		SendAt:      sendAt(time.Hour),
+	})
+	assert.Equal(t, recipient.Id, created.RecipientId)
+
+	notification, err := storage.GetNotificationById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, recipient.Id, notification.RecipientId)
+	assert.Equal(t, []string{model.ChannelEmail, model.ChannelTelegram}, notification.Channels)
+	assert.Zero(t, notification.TelegramId)
+
+	direct := create(t, storage, model.Notification{Text: "Direct", TelegramId: 1, SendAt: sendAt(time.Hour)})
+
+	notification, err = storage.GetNotificationById(direct.Id)
+	require.NoError(t, err)
+	assert.Zero(t, notification.RecipientId)
+	assert.Empty(t, notification.Channels)
+}
+
+func testDeliveryAttempts(t *testing.T, storage service.Storage) {
+	notification := create(t, storage, model.Notification{Text: "a", TelegramId: 1, SendAt: sendAt(time.Hour)})
+	other := create(t, storage, model.Notification{Text: "b", TelegramId: 1, SendAt: sendAt(time.Hour)})
+
+	attempts, err := storage.GetDeliveryAttempts(notification.Id)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	require.NoError(t, storage.AddDeliveryAttempt(model.DeliveryAttempt{
+		NotificationId: notification.Id,
+		Channel:        model.ChannelTelegram,
+		Address:        "1",
+		Status:         model.DeliveryFailed,
+		Error:          "blocked",
+	}))
+	require.NoError(t, storage.AddDeliveryAttempt(model.DeliveryAttempt{
+		NotificationId: notification.Id,
+		Channel:        model.ChannelEmail,
+		Address:        "a@example.com",
+		Status:         model.DeliverySent,
+	}))
+	require.NoError(t, storage.AddDeliveryAttempt(model.DeliveryAttempt{
+		NotificationId: other.Id,
+		Channel:        model.ChannelTelegram,
+		Address:        "1",
+		Status:         model.DeliverySent,
+	}))
+
+	attempts, err = storage.GetDeliveryAttempts(notification.Id)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, model.ChannelTelegram, attempts[0].Channel)
+	assert.Equal(t, model.DeliveryFailed, attempts[0].Status)
+	assert.Equal(t, "blocked", attempts[0].Error)
+	assert.Equal(t, model.ChannelEmail, attempts[1].Channel)
+	assert.Equal(t, "a@example.com", attempts[1].Address)
+	assert.Equal(t, model.DeliverySent, attempts[1].Status)
+	assert.False(t, attempts[1].CreatedAt.IsZero())
+
+	require.NoError(t, storage.DeleteNotificationById(notification.Id))
+
+	attempts, err = storage.GetDeliveryAttempts(notification.Id)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
+
+func testGroups(t *testing.T, storage service.Storage) {
+	alice, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+	bob, err := storage.CreateRecipient(model.Recipient{Name: "Bob"})
+	require.NoError(t, err)
+
+	group, err := storage.CreateGroup(model.Group{Name: "Team"})
+	require.NoError(t, err)
+	assert.NotZero(t, group.Id)
+	assert.Empty(t, group.Members)
+
+	require.NoError(t, storage.AddGroupMember(group.Id, bob.Id))
+	require.NoError(t, storage.AddGroupMember(group.Id, alice.Id))
+	require.NoError(t, storage.AddGroupMember(group.Id, alice.Id))
+
+	got, err := storage.GetGroup(group.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Team", got.Name)
+	assert.Equal(t, []int{alice.Id, bob.Id}, got.Members)
+
+	assert.ErrorIs(t, storage.AddGroupMember(group.Id+100, alice.Id), repository.ErrNoSuchGroup)
+	assert.ErrorIs(t, storage.AddGroupMember(group.Id, bob.Id+100), repository.ErrNoSuchRecipient)
+
+	other, err := storage.CreateGroup(model.Group{Name: "Topic"})
+	require.NoError(t, err)
+	require.NoError(t, storage.AddGroupMember(other.Id, bob.Id))
+
+	groups, err := storage.GetAllGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, []int{alice.Id, bob.Id}, groups[0].Members)
+	assert.Equal(t, []int{bob.Id}, groups[1].Members)
+
+	require.NoError(t, storage.RemoveGroupMember(group.Id, alice.Id))
+	assert.ErrorIs(t, storage.RemoveGroupMember(group.Id, alice.Id), repository.ErrNotGroupMember)
+
+	require.NoError(t, storage.DeleteRecipient(bob.Id))
+	got, err = storage.GetGroup(group.Id)
+	require.NoError(t, err)
+	assert.Empty(t, got.Members)
+
+	require.NoError(t, storage.DeleteGroup(group.Id))
+	_, err = storage.GetGroup(group.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchGroup)
+	assert.ErrorIs(t, storage.DeleteGroup(group.Id), repository.ErrNoSuchGroup)
+}
+
+func testGroupNotification(t *testing.T, storage service.Storage) {
+	recipient, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+	group, err := storage.CreateGroup(model.Group{Name: "Team"})
+	require.NoError(t, err)
+
+	parent := create(t, storage, model.Notification{Text: "Standup", GroupId: group.Id, SendAt: sendAt(time.Hour)})
+
+	notification, err := storage.GetNotificationById(parent.Id)
+	require.NoError(t, err)
+	assert.Equal(t, group.Id, notification.GroupId)
+	assert.Empty(t, notification.Category)
+	assert.Zero(t, notification.ParentId)
+
+	first := create(t, storage, model.Notification{Text: "Standup", RecipientId: recipient.Id, ParentId: parent.Id, SendAt: sendAt(0)})
+	second := create(t, storage, model.Notification{Text: "Standup", RecipientId: recipient.Id, ParentId: parent.Id, SendAt: sendAt(0)})
+	create(t, storage, model.Notification{Text: "Other", TelegramId: 1, SendAt: sendAt(0)})
+
+	children, err := storage.GetChildNotifications(parent.Id)
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	assert.Equal(t, first.Id, children[0].Id)
+	assert.Equal(t, second.Id, children[1].Id)
+	assert.Equal(t, parent.Id, children[0].ParentId)
+
+	require.NoError(t, storage.DeleteNotificationById(parent.Id))
+	_, err = storage.GetNotificationById(first.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+}
+
+func testPreferences(t *testing.T, storage service.Storage) {
+	recipient, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+
+	preferences, err := storage.GetPreferences(recipient.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.Preferences{
+		RecipientId:      recipient.Id,
+		MutedCategories:  []string{},
+		OptedOutChannels: []string{},
+		DigestCategories: []string{},
+	}, *preferences)
+
+	saved := model.Preferences{
+		RecipientId:      recipient.Id,
+		MutedCategories:  []string{"billing", "news"},
+		OptedOutChannels: []string{model.ChannelEmail},
+		DigestWindow:     60,
+		DigestCategories: []string{"reminders"},
+	}
+	require.NoError(t, storage.SavePreferences(saved))
+
+	preferences, err = storage.GetPreferences(recipient.Id)
+	require.NoError(t, err)
+	assert.Equal(t, saved, *preferences)
+
+	saved.Unsubscribed = true
+	saved.MutedCategories = []string{}
+	require.NoError(t, storage.SavePreferences(saved))
+
+	preferences, err = storage.GetPreferences(recipient.Id)
+	require.NoError(t, err)
+	assert.Equal(t, saved, *preferences)
+
+	categorized := create(t, storage, model.Notification{Text: "Bill", RecipientId: recipient.Id, Category: "billing", SendAt: sendAt(time.Hour)})
+	notification, err := storage.GetNotificationById(categorized.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", notification.Category)
+
+	_, err = storage.GetPreferences(recipient.Id + 100)
+	assert.ErrorIs(t, err, repository.ErrNoSuchRecipient)
+	assert.ErrorIs(t, storage.SavePreferences(model.Preferences{RecipientId: recipient.Id + 100}), repository.ErrNoSuchRecipient)
+
+	require.NoError(t, storage.DeleteRecipient(recipient.Id))
+	_, err = storage.GetPreferences(recipient.Id)
+	assert.ErrorIs(t, err, repository.ErrNoSuchRecipient)
+}
+
+func testDigests(t *testing.T, storage service.Storage) {
+	recipient, err := storage.CreateRecipient(model.Recipient{Name: "Alice"})
+	require.NoError(t, err)
+	other, err := storage.CreateRecipient(model.Recipient{Name: "Bob"})
+	require.NoError(t, err)
+
+	second := create(t, storage, model.Notification{Text: "second", RecipientId: recipient.Id, SendAt: sendAt(20 * time.Second)})
+	leader := create(t, storage, model.Notification{Text: "first", RecipientId: recipient.Id, Category: "reminders", SendAt: sendAt(-time.Second)})
+	create(t, storage, model.Notification{Text: "later", RecipientId: recipient.Id, SendAt: sendAt(time.Hour)})
+	create(t, storage, model.Notification{Text: "routed", RecipientId: recipient.Id, Channels: []string{model.ChannelEmail}, SendAt: sendAt(0)})
+	create(t, storage, model.Notification{Text: "styled", RecipientId: recipient.Id, Options: &model.TelegramOptions{ParseMode: model.ParseModeHTML}, SendAt: sendAt(0)})
+	create(t, storage, model.Notification{Text: "someone else's", RecipientId: other.Id, SendAt: sendAt(0)})
+	sent := create(t, storage, model.Notification{Text: "sent", RecipientId: recipient.Id, SendAt: sendAt(0)})
+	require.NoError(t, storage.UpdateNotificationStatus(sent.Id, "completed"))
+
+	digest, err := storage.AssignDigest(leader.Id, recipient.Id, sendAt(time.Minute), nil)
+	require.NoError(t, err)
+	require.Len(t, digest, 2)
+	assert.Equal(t, leader.Id, digest[0].Id, "digest is ordered by send time")
+	assert.Equal(t, second.Id, digest[1].Id)
+	for _, notification := range digest {
+		assert.Equal(t, leader.Id, notification.DigestId)
+	}
+
+	digest, err = storage.AssignDigest(second.Id, recipient.Id, sendAt(time.Minute), nil)
+	require.NoError(t, err)
+	assert.Empty(t, digest, "notifications in an active digest are not assigned to another one")
+
+	digest, err = storage.AssignDigest(leader.Id, recipient.Id, sendAt(time.Minute), []string{"reminders"})
+	require.NoError(t, err)
+	require.Len(t, digest, 1, "only notifications of the digest categories are assigned")
+	assert.Equal(t, leader.Id, digest[0].Id)
+
+	require.NoError(t, storage.LeaveDigest(second.Id))
+	notification, err := storage.GetNotificationById(second.Id)
+	require.NoError(t, err)
+	assert.Zero(t, notification.DigestId)
+	assert.ErrorIs(t, storage.LeaveDigest(100500), repository.ErrNoSuchNotification)
+
+	_, err = storage.AssignDigest(leader.Id, recipient.Id, sendAt(time.Minute), nil)
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateNotificationStatus(leader.Id, "canceled"))
+
+	digest, err = storage.AssignDigest(second.Id, recipient.Id, sendAt(time.Minute), nil)
+	require.NoError(t, err)
+	require.Len(t, digest, 1, "notifications of a finished digest are free again")
+	assert.Equal(t, second.Id, digest[0].Id)
+
+	require.NoError(t, storage.RescheduleNotification(second.Id, sendAt(time.Minute)))
+	notification, err = storage.GetNotificationById(second.Id)
+	require.NoError(t, err)
+	assert.Zero(t, notification.DigestId, "rescheduling takes the notification out of its digest")
+}
+
+func testCollapse(t *testing.T, storage service.Storage) {
+	collapse := func(text string, at time.Duration, policy string) *model.CollapseResult {
+		t.Helper()
+
+		result, err := storage.CollapseNotification(model.Notification{
+			Text:        text,
+			Status:      "active",
+			TelegramId:  123,
+			CollapseKey: "order-42",
+			SendAt:      sendAt(at),
+		}, policy)
+		require.NoError(t, err)
+		return result
+	}
+
+	first := collapse("first", time.Hour, model.CollapseKeepLast)
+	assert.True(t, first.Created, "nothing to collapse into")
+	assert.Empty(t, first.Canceled)
+	assert.Equal(t, "order-42", first.Notification.CollapseKey)
+
+	kept := collapse("second", 2*time.Hour, model.CollapseKeepFirst)
+	assert.False(t, kept.Created)
+	assert.Equal(t, first.Notification.Id, kept.Notification.Id)
+	assert.Equal(t, "first", kept.Notification.Text)
+
+	merged := collapse("third", 30*time.Minute, model.CollapseMerge)
+	assert.False(t, merged.Created)
+	assert.True(t, merged.Merged)
+	assert.Equal(t, first.Notification.Id, merged.Notification.Id)
+	assert.Equal(t, "third", merged.Notification.Text)
+	assert.Less(t, merged.Notification.SendAt, first.Notification.SendAt, "merge keeps the earlier send time")
+	assert.Equal(t, first.Notification.Version+1, merged.Notification.Version)
+
+	last := collapse("fourth", time.Hour, model.CollapseKeepLast)
+	assert.True(t, last.Created)
+	assert.Equal(t, []int{first.Notification.Id}, last.Canceled)
+
+	notification, err := storage.GetNotificationById(first.Notification.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "canceled", notification.Status)
+
+	other, err := storage.CollapseNotification(model.Notification{
+		Text:        "for someone else",
+		Status:      "active",
+		TelegramId:  456,
+		CollapseKey: "order-42",
+		SendAt:      sendAt(time.Hour),
+	}, model.CollapseKeepFirst)
+	require.NoError(t, err)
+	assert.True(t, other.Created, "collapse keys are scoped to the addressee")
+
+	notification, err = storage.GetNotificationById(last.Notification.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "active", notification.Status)
+}
+
+func testFollowUps(t *testing.T, storage service.Storage) {
+	source := create(t, storage, model.Notification{Text: "standup", TelegramId: 7, SendAt: sendAt(-time.Hour)})
+	require.NoError(t, storage.UpdateNotificationStatus(source.Id, "completed"))
+	create(t, storage, model.Notification{Text: "unrelated", TelegramId: 7, SendAt: sendAt(time.Hour)})
+
+	first := create(t, storage, model.Notification{Text: "standup", TelegramId: 7, SendAt: sendAt(time.Hour), FollowUpOf: source.Id})
+	second := create(t, storage, model.Notification{Text: "standup", TelegramId: 7, SendAt: sendAt(2 * time.Hour), FollowUpOf: source.Id})
+
+	got, err := storage.GetNotificationById(first.Id)
+	require.NoError(t, err)
+	assert.Equal(t, source.Id, got.FollowUpOf)
+
+	followUps, err := storage.GetFollowUps(source.Id)
+	require.NoError(t, err)
+	require.Len(t, followUps, 2)
+	assert.Equal(t, first.Id, followUps[0].Id)
+	assert.Equal(t, second.Id, followUps[1].Id)
+
+	none, err := storage.GetFollowUps(first.Id)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	// Follow-ups outlive the notification they were created from.
+	require.NoError(t, storage.DeleteNotificationById(source.Id))
+	got, err = storage.GetNotificationById(first.Id)
+	require.NoError(t, err)
+	assert.Zero(t, got.FollowUpOf)
+}
+
+func testRetention(t *testing.T, storage service.Storage) {
+	old := sendAt(-40 * 24 * time.Hour)
+	purged := create(t, storage, model.Notification{Text: "a", TelegramId: 1, SendAt: old, Status: "completed"})
+	acme := create(t, storage, model.Notification{Text: "b", TelegramId: 1, SendAt: old, Status: "completed", Tenant: "acme"})
+	active := create(t, storage, model.Notification{Text: "c", TelegramId: 1, SendAt: old})
+	recent := create(t, storage, model.Notification{Text: "d", TelegramId: 1, SendAt: sendAt(-time.Hour), Status: "completed"})
+
+	group, err := storage.CreateGroup(model.Group{Name: "Team"})
+	require.NoError(t, err)
+	done := create(t, storage, model.Notification{Text: "e", GroupId: group.Id, SendAt: old, Status: "completed"})
+	delivered := create(t, storage, model.Notification{Text: "e", TelegramId: 2, ParentId: done.Id, SendAt: sendAt(-time.Hour), Status: "completed"})
+	pending := create(t, storage, model.Notification{Text: "f", GroupId: group.Id, SendAt: old, Status: "completed"})
+	create(t, storage, model.Notification{Text: "f", TelegramId: 2, ParentId: pending.Id, SendAt: sendAt(time.Hour)})
+
+	scope := model.RetentionScope{
+		Except:   []string{"acme"},
+		Statuses: model.FinishedStatuses,
+		Before:   sendAt(-30 * 24 * time.Hour),
+	}
+
+	first, err := storage.PurgeNotifications(scope, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{purged.Id}, first)
+
+	// Deliveries go with their group notification even when they are not
+	// in scope themselves.
+	second, err := storage.PurgeNotifications(scope, 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{done.Id, delivered.Id}, second)
+
+	rest, err := storage.PurgeNotifications(scope, 10)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+
+	for _, id := range []int{purged.Id, done.Id, delivered.Id} {
+		_, err := storage.GetNotificationById(id)
+		assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+	}
+	for _, id := range []int{acme.Id, active.Id, recent.Id, pending.Id} {
+		_, err := storage.GetNotificationById(id)
+		assert.NoError(t, err)
+	}
+
+	require.NoError(t, storage.AddDeliveryAttempt(model.DeliveryAttempt{
+		NotificationId: acme.Id,
+		Channel:        model.ChannelEmail,
+		Address:        "a@example.com",
+		Status:         model.DeliveryFailed,
+		Error:          "bounced",
+	}))
+
+	scope = model.RetentionScope{
+		Tenant:   "acme",
+		Statuses: model.FinishedStatuses,
+		Before:   sendAt(-7 * 24 * time.Hour),
+	}
+	anonymized, err := storage.AnonymizeNotifications(scope, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{acme.Id}, anonymized)
+
+	notification, err := storage.GetNotificationById(acme.Id)
+	require.NoError(t, err)
+	assert.Empty(t, notification.Text)
+	assert.Equal(t, "acme", notification.Tenant)
+	assert.Equal(t, acme.Version+1, notification.Version)
+
+	attempts, err := storage.GetDeliveryAttempts(acme.Id)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Empty(t, attempts[0].Address)
+	assert.Empty(t, attempts[0].Error)
+	assert.Equal(t, model.DeliveryFailed, attempts[0].Status)
+
+	again, err := storage.AnonymizeNotifications(scope, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+}
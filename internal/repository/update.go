@@ -2,10 +2,14 @@ package repository
 
 import "fmt"
 
+// UpdateNotificationStatus sets the status of the notification. Only an
+// active notification can get a terminal status, ErrNotActive is returned
+// for one that was already sent, canceled, failed or expired. Terminal
+// notifications are only made active again by RescheduleNotification.
 func (r *Repository) UpdateNotificationStatus(id int, newStatus string) error {
 	query := `UPDATE notifications
-	SET status = $1
-	WHERE id = $2	`
+	SET status = $1, version = version + 1
+	WHERE id = $2 AND status = 'active'`
 
 	result, err := r.db.Master.Exec(query, newStatus, id)
 	if err != nil {
@@ -18,8 +22,49 @@ func (r *Repository) UpdateNotificationStatus(id int, newStatus string) error {
 	}
 
 	if affected == 0 {
-		return ErrNoSuchNotification
+		return r.notActive(id)
 	}
 
 	return nil
 }
+
+// RescheduleNotification moves the notification to sendAt, makes it active
+// again and drops its claim and digest, so it is picked up by the scheduler
+// once more. Only an active notification, or a completed one being
+// snoozed, can be rescheduled, ErrNotActive is returned for one that was
+// canceled, failed or expired.
+func (r *Repository) RescheduleNotification(id int, sendAt int) error {
+	query := `UPDATE notifications
+	SET send_at = $1, status = 'active', claimed_until = 0, claims = 0, digest_id = NULL, version = version + 1
+	WHERE id = $2 AND status IN ('active', 'completed')`
+
+	result, err := r.db.Master.Exec(query, sendAt, id)
+	if err != nil {
+		return fmt.Errorf("could not reschedule notification: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not reschedule notification: %w", err)
+	}
+
+	if affected == 0 {
+		return r.notActive(id)
+	}
+
+	return nil
+}
+
+// notActive tells why a guarded update of the notification matched no row:
+// ErrNotActive if it exists, ErrNoSuchNotification otherwise.
+func (r *Repository) notActive(id int) error {
+	var exists bool
+	err := r.db.Master.QueryRow("SELECT EXISTS(SELECT 1 FROM notifications WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("could not check notification: %w", err)
+	}
+	if exists {
+		return ErrNotActive
+	}
+	return ErrNoSuchNotification
+}
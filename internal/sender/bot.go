@@ -0,0 +1,217 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	snoozePrefix  = "snooze:"
+	updateTimeout = 60
+)
+
+var snoozeDelays = []struct {
+	label    string
+	duration time.Duration
+}{
+	{label: "10m", duration: 10 * time.Minute},
+	{label: "1h", duration: time.Hour},
+}
+
+// BotService is the part of the notifier business logic available to chat
+// users.
+type BotService interface {
+	RegisterSubscriber(string, int) error
+	GetUpcomingNotifications(int) ([]model.Notification, error)
+	CancelNotification(int, int) error
+	SnoozeNotification(int, int, time.Duration) (*model.Notification, error)
+}
+
+// RunBot receives updates from telegram and handles chat commands and
+// snooze buttons until ctx is canceled.
+func (t *TelegramSender) RunBot(ctx context.Context, service BotService) error {
+	config := tgbotapi.NewUpdate(0)
+	config.Timeout = updateTimeout
+
+	updates := t.botApi.GetUpdatesChan(config)
+	zlog.Logger.Info().Msg("telegram bot started receiving updates")
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.botApi.StopReceivingUpdates()
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			t.handleUpdate(service, update)
+		}
+	}
+}
+
+func (t *TelegramSender) handleUpdate(service BotService, update tgbotapi.Update) {
+	switch {
+	case update.CallbackQuery != nil:
+		t.handleCallback(service, update.CallbackQuery)
+	case update.Message != nil && update.Message.IsCommand():
+		t.handleCommand(service, update.Message)
+	}
+}
+
+func (t *TelegramSender) handleCommand(service BotService, msg *tgbotapi.Message) {
+	chatId := int(msg.Chat.ID)
+
+	var reply string
+	switch msg.Command() {
+	case "start":
+		reply = startCommand(service, chatId, msg)
+	case "list":
+		reply = listCommand(service, chatId)
+	case "cancel":
+		reply = cancelCommand(service, chatId, msg.CommandArguments())
+	default:
+		reply = "Unknown command. Available commands: /start, /list, /cancel <id>"
+	}
+
+	t.reply(msg.Chat.ID, reply)
+}
+
+// startCommand links the sender's telegram username to the chat. Only the
+// username telegram reports is accepted, so nobody can claim someone else's
+// handle.
+func startCommand(service BotService, chatId int, msg *tgbotapi.Message) string {
+	var handle string
+	if msg.From != nil {
+		handle = msg.From.UserName
+	}
+
+	if handle == "" {
+		return "Please set a username in your telegram settings and send /start again"
+	}
+
+	if err := service.RegisterSubscriber(handle, chatId); err != nil {
+		if errors.Is(err, repository.ErrHandleTaken) {
+			return fmt.Sprintf("The handle %s is already linked to another chat", handle)
+		}
+		zlog.Logger.Error().Msg("could not register subscriber: " + err.Error())
+		return "Could not register you, please try again later"
+	}
+
+	return fmt.Sprintf("You are subscribed as %s. Notifications addressed to this handle will be sent to this chat", handle)
+}
+
+func listCommand(service BotService, chatId int) string {
+	notifications, err := service.GetUpcomingNotifications(chatId)
+	if err != nil {
+		zlog.Logger.Error().Msg("could not get upcoming notifications: " + err.Error())
+		return "Could not get your reminders, please try again later"
+	}
+
+	if len(notifications) == 0 {
+		return "You have no upcoming reminders"
+	}
+
+	var b strings.Builder
+	b.WriteString("Upcoming reminders:\n")
+	for _, notif := range notifications {
+		sendAt := time.UnixMilli(int64(notif.SendAt)).Format("2006-01-02 15:04")
+		fmt.Fprintf(&b, "#%d  %s  %s\n", notif.Id, sendAt, notif.Text)
+	}
+
+	return b.String()
+}
+
+func cancelCommand(service BotService, chatId int, args string) string {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args), "#"))
+	if err != nil {
+		return "Please provide a reminder id: /cancel <id>"
+	}
+
+	if err := service.CancelNotification(chatId, id); err != nil {
+		if errors.Is(err, repository.ErrNoSuchNotification) {
+			return fmt.Sprintf("Reminder #%d not found", id)
+		}
+		if errors.Is(err, repository.ErrNotActive) {
+			return fmt.Sprintf("Reminder #%d was already sent or canceled", id)
+		}
+		zlog.Logger.Error().Msg("could not cancel notification: " + err.Error())
+		return "Could not cancel the reminder, please try again later"
+	}
+
+	return fmt.Sprintf("Reminder #%d was canceled", id)
+}
+
+func (t *TelegramSender) handleCallback(service BotService, query *tgbotapi.CallbackQuery) {
+	answer := "Unknown action"
+
+	if id, delay, ok := parseSnoozeCallbackData(query.Data); ok && query.Message != nil {
+		notification, err := service.SnoozeNotification(int(query.Message.Chat.ID), id, delay)
+		switch {
+		case errors.Is(err, repository.ErrNoSuchNotification):
+			answer = "Reminder not found"
+		case errors.Is(err, model.ErrNotSnoozable):
+			answer = "The reminder can no longer be snoozed"
+		case err != nil:
+			zlog.Logger.Error().Msg("could not snooze notification: " + err.Error())
+			answer = "Could not snooze the reminder"
+		default:
+			sendAt := time.UnixMilli(int64(notification.SendAt)).Format("15:04")
+			answer = "Snoozed until " + sendAt
+		}
+	}
+
+	if _, err := t.botApi.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+		zlog.Logger.Error().Msg("could not answer callback query: " + err.Error())
+	}
+}
+
+func (t *TelegramSender) reply(chatId int64, text string) {
+	if _, err := t.botApi.Send(tgbotapi.NewMessage(chatId, text)); err != nil {
+		zlog.Logger.Error().Msg("could not reply to telegram user: " + err.Error())
+	}
+}
+
+func snoozeCallbackData(id int, delay time.Duration) string {
+	return fmt.Sprintf("%s%d:%d", snoozePrefix, id, int(delay.Minutes()))
+}
+
+func parseSnoozeCallbackData(data string) (int, time.Duration, bool) {
+	rest, ok := strings.CutPrefix(data, snoozePrefix)
+	if !ok {
+		return 0, 0, false
+	}
+
+	idPart, minutesPart, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	minutes, err := strconv.Atoi(minutesPart)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	delay := time.Duration(minutes) * time.Minute
+	for _, allowed := range snoozeDelays {
+		if allowed.duration == delay {
+			return id, delay, true
+		}
+	}
+
+	return 0, 0, false
+}
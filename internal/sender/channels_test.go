@@ -0,0 +1,139 @@
+package sender
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender_Send(t *testing.T) {
+	var payload WebhookPayload
+	var notificationId string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notificationId = r.Header.Get("X-Notification-Id")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sendAt := time.Now().Truncate(time.Millisecond)
+	err := NewWebhookSenderWithClient(server.Client()).Send(server.URL, model.Notification{
+		Id:     7,
+		Text:   "hello",
+		SendAt: int(sendAt.UnixMilli()),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "7", notificationId)
+	assert.Equal(t, 7, payload.Id)
+	assert.Equal(t, "hello", payload.Text)
+	assert.True(t, sendAt.Equal(payload.SendAt))
+}
+
+func TestWebhookSender_ClassifiesResponses(t *testing.T) {
+	var status atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSenderWithClient(server.Client())
+	send := func(code int) error {
+		status.Store(int32(code))
+		return sender.Send(server.URL, model.Notification{Id: 1, Text: "hello"})
+	}
+
+	var retryAfter *RetryAfterError
+	assert.True(t, errors.As(send(http.StatusTooManyRequests), &retryAfter))
+	assert.Equal(t, 30*time.Second, retryAfter.After)
+
+	var temporary *TemporaryError
+	assert.True(t, errors.As(send(http.StatusBadGateway), &temporary))
+	assert.ErrorIs(t, send(http.StatusNotFound), ErrRejected)
+
+	server.Close()
+	assert.True(t, errors.As(send(http.StatusOK), &temporary))
+}
+
+func TestWebhookSender_RefusesInternalAddresses(t *testing.T) {
+	var called atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	sender := NewWebhookSender(time.Second)
+	for _, url := range []string{server.URL, "http://localhost:" + port} {
+		err := sender.Send(url, model.Notification{Id: 1, Text: "hello"})
+		assert.ErrorIs(t, err, ErrRejected, url)
+	}
+	assert.False(t, called.Load())
+}
+
+func TestSMSSender_Send(t *testing.T) {
+	var body map[string]string
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	err := NewSMSSender(server.URL, "secret", time.Second).Send("+14155550123", model.Notification{Text: "hello"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, map[string]string{"to": "+14155550123", "text": "hello"}, body)
+}
+
+func TestEmailSender_Send(t *testing.T) {
+	sender := NewEmailSender("smtp.example.com:587", "notifier@example.com", "user", "password")
+
+	var to []string
+	var msg string
+	sender.sendMail = func(addr string, auth smtp.Auth, from string, recipients []string, body []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.NotNil(t, auth)
+		assert.Equal(t, "notifier@example.com", from)
+		to = recipients
+		msg = string(body)
+		return nil
+	}
+
+	require.NoError(t, sender.Send("alice@example.com", model.Notification{Text: "line 1\nline 2"}))
+	assert.Equal(t, []string{"alice@example.com"}, to)
+	assert.Contains(t, msg, "To: alice@example.com\r\n")
+	assert.Contains(t, msg, "\r\n\r\nline 1\r\nline 2")
+	assert.NotContains(t, msg, "List-Unsubscribe")
+
+	link := "https://notifier.example.com/api/v1/unsubscribe?token=signed"
+	require.NoError(t, sender.Send("alice@example.com", model.Notification{Text: "Bill: " + link, UnsubscribeURL: link}))
+	assert.Contains(t, msg, "List-Unsubscribe: <"+link+">\r\n")
+	assert.Contains(t, msg, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+
+	sender.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+		return &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+	}
+	assert.ErrorIs(t, sender.Send("alice@example.com", model.Notification{Text: "hello"}), ErrRejected)
+
+	sender.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+		return &textproto.Error{Code: 421, Msg: "try again later"}
+	}
+	var temporary *TemporaryError
+	assert.True(t, errors.As(sender.Send("alice@example.com", model.Notification{Text: "hello"}), &temporary))
+}
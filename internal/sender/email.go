@@ -0,0 +1,82 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+const emailSubject = "Notification"
+
+// EmailSender delivers notifications as plain text emails through an SMTP
+// server.
+type EmailSender struct {
+	address string
+	from    string
+	auth    smtp.Auth
+	// sendMail is smtp.SendMail, replaced in tests.
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailSender sends mail through the SMTP server at address (host:port)
+// as from. PLAIN authentication is used if username is not empty.
+func NewEmailSender(address, from, username, password string) *EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(address)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailSender{
+		address:  address,
+		from:     from,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (e *EmailSender) Send(to string, notification model.Notification) error {
+	err := e.sendMail(e.address, e.auth, e.from, []string{to}, e.buildMessage(to, notification))
+	if err != nil {
+		return fmt.Errorf("could not send email: %w", classifySMTPError(err))
+	}
+
+	return nil
+}
+
+// buildMessage renders the email. A notification with an unsubscribe link
+// announces it for one click unsubscribe (RFC 8058): mail clients POST
+// List-Unsubscribe=One-Click to the link.
+func (e *EmailSender) buildMessage(to string, notification model.Notification) []byte {
+	var msg strings.Builder
+	msg.WriteString("From: " + e.from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", emailSubject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	if notification.UnsubscribeURL != "" {
+		msg.WriteString("List-Unsubscribe: <" + notification.UnsubscribeURL + ">\r\n")
+		msg.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Text, "\n", "\r\n"))
+	return []byte(msg.String())
+}
+
+// classifySMTPError treats permanent 5xx replies as a rejected message,
+// anything else may succeed later.
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return fmt.Errorf("%w: %s", ErrRejected, protoErr.Error())
+	}
+	return &TemporaryError{Err: err}
+}
@@ -0,0 +1,70 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	// ErrRecipientBlocked means the user blocked the bot, nothing can be
+	// delivered to them until they start it again.
+	ErrRecipientBlocked = errors.New("recipient blocked the bot")
+	// ErrRecipientNotFound means the chat does not exist.
+	ErrRecipientNotFound = errors.New("recipient chat not found")
+	// ErrRejected means the message itself was rejected, e.g. because of
+	// malformed markup or an invalid address. Sending it again will fail
+	// the same way.
+	ErrRejected = errors.New("message rejected")
+)
+
+// RetryAfterError is returned when telegram or another channel rate
+// limited the sender. The message should be sent again not earlier than
+// After.
+type RetryAfterError struct {
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %s", e.After)
+}
+
+// TemporaryError wraps network failures and server errors of telegram and
+// other channels, the message may be delivered if it is sent again.
+type TemporaryError struct {
+	Err error
+}
+
+func (e *TemporaryError) Error() string {
+	return "temporary delivery failure: " + e.Err.Error()
+}
+
+func (e *TemporaryError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError converts errors of the bot api into the errors above.
+func classifyError(err error) error {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return &TemporaryError{Err: err}
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		return &RetryAfterError{After: time.Duration(apiErr.RetryAfter) * time.Second}
+	case apiErr.Code == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrRecipientBlocked, apiErr.Message)
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(message, "chat not found"):
+		return fmt.Errorf("%w: %s", ErrRecipientNotFound, apiErr.Message)
+	case apiErr.Code >= http.StatusInternalServerError:
+		return &TemporaryError{Err: apiErr}
+	default:
+		return fmt.Errorf("%w: %d %s", ErrRejected, apiErr.Code, apiErr.Message)
+	}
+}
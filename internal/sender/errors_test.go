@@ -0,0 +1,36 @@
+package sender
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tooManyRequests := &tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests: retry after 5",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5},
+	}
+
+	var retryAfter *RetryAfterError
+	assert.True(t, errors.As(classifyError(tooManyRequests), &retryAfter))
+	assert.Equal(t, 5*time.Second, retryAfter.After)
+
+	blocked := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	assert.ErrorIs(t, classifyError(blocked), ErrRecipientBlocked)
+
+	notFound := &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}
+	assert.ErrorIs(t, classifyError(notFound), ErrRecipientNotFound)
+
+	badMarkup := &tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities"}
+	assert.ErrorIs(t, classifyError(badMarkup), ErrRejected)
+
+	var temporary *TemporaryError
+	assert.True(t, errors.As(classifyError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), &temporary))
+	assert.True(t, errors.As(classifyError(&tgbotapi.Error{Code: 502, Message: "Bad Gateway"}), &temporary))
+}
@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRetryAfter is used when a rate limited response has no valid
+// Retry-After header.
+const defaultRetryAfter = time.Minute
+
+// postJSON sends payload to url and classifies the response like
+// classifyError does for telegram: network failures and 5xx are temporary,
+// 429 is rate limiting and other non-2xx responses reject the message, as
+// does a destination the client refuses to connect to.
+func postJSON(client *http.Client, url string, header http.Header, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if errors.Is(err, ErrRejected) {
+		return err
+	}
+	if err != nil {
+		return &TemporaryError{Err: err}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RetryAfterError{After: retryAfter(resp.Header.Get("Retry-After"))}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &TemporaryError{Err: fmt.Errorf("%s responded %s", url, resp.Status)}
+	default:
+		return fmt.Errorf("%w: %s responded %s", ErrRejected, url, resp.Status)
+	}
+}
+
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -4,33 +4,145 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/Komilov31/delayed-notifier/internal/model"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type TelegramSender struct {
 	botApi *tgbotapi.BotAPI
+	// snooze adds snooze buttons to notifications, see EnableSnooze.
+	snooze bool
 }
 
-func New() *TelegramSender {
-	botApi, err := tgbotapi.NewBotAPI(os.Getenv("BOT_TOKEN"))
+// New connects to the bot api at apiURL, e.g. https://api.telegram.org,
+// with the token from BOT_TOKEN. An empty apiURL means the official api.
+func New(apiURL string) *TelegramSender {
+	sender, err := NewWithEndpoint(os.Getenv("BOT_TOKEN"), apiURL)
 	if err != nil {
 		log.Fatal("could not connect to telegram api: ", err)
 	}
 
+	return sender
+}
+
+func NewWithEndpoint(token, apiURL string) (*TelegramSender, error) {
+	endpoint := tgbotapi.APIEndpoint
+	if apiURL != "" {
+		endpoint = strings.TrimRight(apiURL, "/") + "/bot%s/%s"
+	}
+
+	botApi, err := tgbotapi.NewBotAPIWithAPIEndpoint(token, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
 	botApi.Debug = false
 
 	return &TelegramSender{
 		botApi: botApi,
-	}
+	}, nil
 }
 
-func (t *TelegramSender) SendToTelegram(telegramId int, text string) error {
-	msg := tgbotapi.NewMessage(int64(telegramId), text)
-	_, err := t.botApi.Send(msg)
+// EnableSnooze makes the sender add snooze buttons to notifications. The
+// buttons are handled by RunBot, so it is only enabled along with it and
+// before anything is sent.
+func (t *TelegramSender) EnableSnooze() {
+	t.snooze = true
+}
+
+// SendToTelegram sends the notification. Messages that are not stored
+// notifications, e.g. verification codes, have no id and get no snooze
+// buttons.
+func (t *TelegramSender) SendToTelegram(notification model.Notification) error {
+	options := notification.Options
+	if t.snooze && notification.Id != 0 {
+		options = withSnoozeButtons(notification.Id, options)
+	}
+
+	_, err := t.botApi.Send(buildMessage(int64(notification.TelegramId), notification.Text, options))
 	if err != nil {
-		return fmt.Errorf("could not send message to telegram user: %s", err.Error())
+		return fmt.Errorf("could not send message to telegram user: %w", classifyError(err))
 	}
 
 	return nil
 }
+
+func buildMessage(chatId int64, text string, options *model.TelegramOptions) tgbotapi.Chattable {
+	if options == nil {
+		return tgbotapi.NewMessage(chatId, text)
+	}
+
+	markup := buildKeyboard(options.Buttons)
+
+	if attachment := options.Attachment; attachment != nil {
+		file := tgbotapi.FileURL(attachment.URL)
+
+		if attachment.Type == model.AttachmentDocument {
+			doc := tgbotapi.NewDocument(chatId, file)
+			doc.Caption = text
+			doc.ParseMode = options.ParseMode
+			doc.DisableNotification = options.DisableNotification
+			doc.ReplyMarkup = markup
+			return doc
+		}
+
+		photo := tgbotapi.NewPhoto(chatId, file)
+		photo.Caption = text
+		photo.ParseMode = options.ParseMode
+		photo.DisableNotification = options.DisableNotification
+		photo.ReplyMarkup = markup
+		return photo
+	}
+
+	msg := tgbotapi.NewMessage(chatId, text)
+	msg.ParseMode = options.ParseMode
+	msg.DisableNotification = options.DisableNotification
+	msg.DisableWebPagePreview = options.DisableWebPagePreview
+	msg.ReplyMarkup = markup
+	return msg
+}
+
+// withSnoozeButtons returns a copy of options with a row of snooze buttons
+// appended, the original options are left untouched.
+func withSnoozeButtons(id int, options *model.TelegramOptions) *model.TelegramOptions {
+	var withSnooze model.TelegramOptions
+	if options != nil {
+		withSnooze = *options
+	}
+
+	row := make([]model.Button, 0, len(snoozeDelays))
+	for _, delay := range snoozeDelays {
+		row = append(row, model.Button{
+			Text:         "Snooze " + delay.label,
+			CallbackData: snoozeCallbackData(id, delay.duration),
+		})
+	}
+
+	withSnooze.Buttons = append(append([][]model.Button{}, withSnooze.Buttons...), row)
+	return &withSnooze
+}
+
+// buildKeyboard returns nil when there are no buttons, so that no empty
+// reply_markup is sent.
+func buildKeyboard(buttons [][]model.Button) interface{} {
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, row := range buttons {
+		keyboardRow := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			if button.URL != "" {
+				keyboardRow = append(keyboardRow, tgbotapi.NewInlineKeyboardButtonURL(button.Text, button.URL))
+				continue
+			}
+			keyboardRow = append(keyboardRow, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.CallbackData))
+		}
+		rows = append(rows, keyboardRow)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,46 @@
+package sender
+
+import (
+	"testing"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/sender/telegramtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramSender_SnoozeButtons(t *testing.T) {
+	tests := []struct {
+		name         string
+		snooze       bool
+		notification model.Notification
+		want         string
+	}{
+		{"enabled", true, model.Notification{Id: 7, TelegramId: 42, Text: "hello"}, "snooze:7:10"},
+		{"updates not handled", false, model.Notification{Id: 7, TelegramId: 42, Text: "hello"}, ""},
+		{"no notification id", true, model.Notification{TelegramId: 42, Text: "Your code: 123456"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegram := telegramtest.NewServer()
+			defer telegram.Close()
+
+			tg, err := NewWithEndpoint("test-token", telegram.URL)
+			require.NoError(t, err)
+			if tt.snooze {
+				tg.EnableSnooze()
+			}
+
+			require.NoError(t, tg.SendToTelegram(tt.notification))
+
+			messages := telegram.Messages()
+			require.Len(t, messages, 1)
+			if tt.want == "" {
+				assert.NotContains(t, messages[0].ReplyMarkup, "snooze:")
+				return
+			}
+			assert.Contains(t, messages[0].ReplyMarkup, tt.want)
+		})
+	}
+}
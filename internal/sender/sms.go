@@ -0,0 +1,40 @@
+package sender
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// SMSSender delivers notifications to phone numbers through an HTTP SMS
+// gateway. The gateway receives {"to": "+14155550123", "text": "..."} and
+// the token as a bearer token.
+type SMSSender struct {
+	client  *http.Client
+	gateway string
+	token   string
+}
+
+func NewSMSSender(gateway, token string, timeout time.Duration) *SMSSender {
+	return &SMSSender{
+		client:  &http.Client{Timeout: timeout},
+		gateway: gateway,
+		token:   token,
+	}
+}
+
+func (s *SMSSender) Send(phone string, notification model.Notification) error {
+	header := http.Header{}
+	if s.token != "" {
+		header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	return postJSON(s.client, s.gateway, header, struct {
+		To   string `json:"to"`
+		Text string `json:"text"`
+	}{
+		To:   phone,
+		Text: notification.Text,
+	})
+}
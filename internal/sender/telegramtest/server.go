@@ -0,0 +1,205 @@
+// Package telegramtest provides a fake Telegram Bot API server for tests.
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Message is a message the bot sent through the fake server.
+type Message struct {
+	Method              string
+	ChatID              int64
+	Text                string
+	ParseMode           string
+	ReplyMarkup         string
+	DisableNotification bool
+	File                string
+}
+
+// Failure is an error response returned instead of handling a send request.
+type Failure struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+// Server is a fake bot api. Pass URL to sender.NewWithEndpoint.
+type Server struct {
+	URL string
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	messages  []Message
+	failures  []Failure
+	updates   []tgbotapi.Update
+	callbacks []string
+	nextId    int
+}
+
+func NewServer() *Server {
+	s := &Server{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Messages returns the messages sent so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// AnsweredCallbacks returns texts of answered callback queries.
+func (s *Server) AnsweredCallbacks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.callbacks...)
+}
+
+// FailNext makes the next send request fail with failure. Calls queue up,
+// each failure is used once.
+func (s *Server) FailNext(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure)
+}
+
+// PushUpdate queues an update returned by the next getUpdates call.
+func (s *Server) PushUpdate(update tgbotapi.Update) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextId++
+	update.UpdateID = s.nextId
+	s.updates = append(s.updates, update)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Paths look like /bot<token>/<method>.
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: " + err.Error()})
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, tgbotapi.User{ID: 1, IsBot: true, FirstName: "Fake", UserName: "fake_bot"})
+	case "sendMessage", "sendPhoto", "sendDocument":
+		s.handleSend(w, r, method)
+	case "getUpdates":
+		s.handleGetUpdates(w, r)
+	case "answerCallbackQuery":
+		s.mu.Lock()
+		s.callbacks = append(s.callbacks, r.FormValue("text"))
+		s.mu.Unlock()
+		writeResult(w, true)
+	default:
+		writeError(w, Failure{Code: http.StatusNotFound, Description: "Not Found: method " + method})
+	}
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request, method string) {
+	s.mu.Lock()
+	if len(s.failures) > 0 {
+		failure := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		writeError(w, failure)
+		return
+	}
+
+	chatId, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	msg := Message{
+		Method:              method,
+		ChatID:              chatId,
+		Text:                r.FormValue("text"),
+		ParseMode:           r.FormValue("parse_mode"),
+		ReplyMarkup:         r.FormValue("reply_markup"),
+		DisableNotification: r.FormValue("disable_notification") == "true",
+	}
+
+	switch method {
+	case "sendPhoto":
+		msg.Text = r.FormValue("caption")
+		msg.File = r.FormValue("photo")
+	case "sendDocument":
+		msg.Text = r.FormValue("caption")
+		msg.File = r.FormValue("document")
+	}
+
+	s.messages = append(s.messages, msg)
+	messageId := len(s.messages)
+	s.mu.Unlock()
+
+	writeResult(w, tgbotapi.Message{
+		MessageID: messageId,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatId, Type: "private"},
+		Text:      msg.Text,
+	})
+}
+
+func (s *Server) handleGetUpdates(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+
+	s.mu.Lock()
+	var updates []tgbotapi.Update
+	for _, update := range s.updates {
+		if update.UpdateID >= offset {
+			updates = append(updates, update)
+		}
+	}
+	s.mu.Unlock()
+
+	// Imitate long polling a little so that the client does not spin.
+	if len(updates) == 0 {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+		}
+		updates = []tgbotapi.Update{}
+	}
+
+	writeResult(w, updates)
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: body})
+}
+
+func writeError(w http.ResponseWriter, failure Failure) {
+	response := tgbotapi.APIResponse{
+		Ok:          false,
+		ErrorCode:   failure.Code,
+		Description: failure.Description,
+	}
+	if failure.RetryAfter > 0 {
+		response.Parameters = &tgbotapi.ResponseParameters{RetryAfter: failure.RetryAfter}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(failure.Code)
+	json.NewEncoder(w).Encode(response)
+}
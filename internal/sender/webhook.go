@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// WebhookSender delivers notifications by POSTing a WebhookPayload to the
+// contact's url.
+type WebhookSender struct {
+	client *http.Client
+}
+
+// WebhookPayload is the JSON body sent to webhooks.
+type WebhookPayload struct {
+	Id          int       `json:"id"`
+	Text        string    `json:"text"`
+	RecipientId int       `json:"recipient_id,omitempty"`
+	SendAt      time.Time `json:"send_at"`
+}
+
+// NewWebhookSender returns a sender that only connects to public
+// addresses, see model.IsPublicIP. The address is checked when it is
+// dialed, so neither a name resolving to an internal address nor a
+// redirect reaches internal services.
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return NewWebhookSenderWithClient(&http.Client{Timeout: timeout, Transport: transport})
+}
+
+// NewWebhookSenderWithClient returns a sender that uses client as is,
+// without the address checks of NewWebhookSender. It is meant for tests
+// against local servers.
+func NewWebhookSenderWithClient(client *http.Client) *WebhookSender {
+	return &WebhookSender{client: client}
+}
+
+func (w *WebhookSender) Send(url string, notification model.Notification) error {
+	header := http.Header{}
+	if notification.Id != 0 {
+		header.Set("X-Notification-Id", strconv.Itoa(notification.Id))
+	}
+
+	return postJSON(w.client, url, header, WebhookPayload{
+		Id:          notification.Id,
+		Text:        notification.Text,
+		RecipientId: notification.RecipientId,
+		SendAt:      time.UnixMilli(int64(notification.SendAt)).UTC(),
+	})
+}
+
+// dialPublicOnly refuses connections to addresses that are not public.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !model.IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrRejected, host)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// refreshCache copies the stored notification to the cache and returns it,
+// or nil if it could not be read. It must be called after the storage write,
+// never before: a failed write then can not leave the cache ahead of the
+// storage.
+func (s *Service) refreshCache(id int) *model.Notification {
+	notification, err := s.storage.GetNotificationById(id)
+	if err != nil {
+		zlog.Logger.Warn().Msgf("could not read notification %d to refresh cache: %s", id, err.Error())
+		s.invalidateCache(id)
+		return nil
+	}
+
+	s.cacheNotification(notification)
+	return notification
+}
+
+// cacheNotification does not fail the caller: the storage already holds the
+// notification, a stale entry is dropped and anything left is repaired by
+// ReconcileCache.
+func (s *Service) cacheNotification(notification *model.Notification) {
+	if err := s.cache.SetNotification(*notification); err != nil {
+		zlog.Logger.Warn().Msgf("could not cache notification %d: %s", notification.Id, err.Error())
+		s.invalidateCache(notification.Id)
+	}
+}
+
+func (s *Service) invalidateCache(id int) {
+	if err := s.cache.DeleteNotification(id); err != nil {
+		zlog.Logger.Error().Msgf("could not invalidate cached notification %d: %s", id, err.Error())
+	}
+}
+
+// WarmupCache loads active notifications into the cache, so the first
+// polls of pending notifications after a start do not all go to the
+// storage. Finished ones are read on demand. The storage is walked by id
+// reconcileBatch notifications at a time. It stops early when ctx is done.
+func (s *Service) WarmupCache(ctx context.Context) error {
+	warmed, afterId := 0, 0
+	for ctx.Err() == nil {
+		notifications, err := s.storage.GetNotificationsAfter(afterId, s.reconcileBatch)
+		if err != nil {
+			return fmt.Errorf("could not get notifications from db: " + err.Error())
+		}
+
+		active := make([]model.Notification, 0, len(notifications))
+		for _, notification := range notifications {
+			if notification.Status == "active" {
+				active = append(active, notification)
+			}
+		}
+		if len(active) > 0 {
+			if err := s.cache.SetNotifications(active); err != nil {
+				return fmt.Errorf("could not warm up cache: " + err.Error())
+			}
+			warmed += len(active)
+		}
+
+		if len(notifications) < s.reconcileBatch {
+			break
+		}
+		afterId = notifications[len(notifications)-1].Id
+	}
+
+	zlog.Logger.Info().Msgf("warmed up cache with %d active notifications", warmed)
+	return nil
+}
+
+// ReconcileCache compares cached statuses with the storage every
+// reconcileInterval and repairs entries that drifted, e.g. after a cache
+// write failed. A pass reads reconcileBatch notifications where the last
+// one stopped, so the storage is walked by id a batch at a time and the
+// walk starts over past the last notification. It returns when ctx is
+// done.
+func (s *Service) ReconcileCache(ctx context.Context) error {
+	for {
+		repaired, err := s.reconcileCache()
+		if err != nil {
+			zlog.Logger.Error().Msg("could not reconcile cache: " + err.Error())
+		} else if repaired > 0 {
+			zlog.Logger.Warn().Msgf("repaired %d drifted cache entries", repaired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.reconcileInterval):
+		}
+	}
+}
+
+// reconcileCache runs a single pass and returns the number of repaired
+// entries. Absent entries are left alone, they are filled on the next read.
+func (s *Service) reconcileCache() (int, error) {
+	notifications, err := s.storage.GetNotificationsAfter(s.reconcileCursor, s.reconcileBatch)
+	if err != nil {
+		return 0, fmt.Errorf("could not get notifications from db: " + err.Error())
+	}
+
+	// A short batch is the end of the walk. The cursor only moves once the
+	// batch is reconciled, a failed one is retried on the next pass.
+	next := 0
+	if len(notifications) == s.reconcileBatch {
+		next = notifications[len(notifications)-1].Id
+	}
+
+	repaired := 0
+	for _, notification := range notifications {
+		cached, err := s.cache.GetNotification(notification.Id)
+		if err == redis.Nil {
+			continue
+		}
+		// An id cached as missing is left with a nil cached notification
+		// and always counts as drift.
+		if err != nil && !errors.Is(err, repository.ErrNoSuchNotification) {
+			return repaired, fmt.Errorf("could not get notification from cache: " + err.Error())
+		}
+
+		if inSync(cached, notification) {
+			continue
+		}
+
+		// The row may have changed since the list was read, compare with
+		// the current one before overwriting anything.
+		current, err := s.storage.GetNotificationById(notification.Id)
+		if err != nil {
+			return repaired, err
+		}
+
+		if inSync(cached, *current) {
+			continue
+		}
+
+		// A cached version ahead of the storage can not be replaced with
+		// SetNotification, drop it first.
+		if cached != nil && cached.Version > current.Version {
+			if err := s.cache.DeleteNotification(current.Id); err != nil {
+				return repaired, fmt.Errorf("could not delete cached notification: " + err.Error())
+			}
+		}
+
+		if err := s.cache.SetNotification(*current); err != nil {
+			return repaired, fmt.Errorf("could not set cached notification: " + err.Error())
+		}
+		repaired++
+	}
+
+	s.reconcileCursor = next
+	return repaired, nil
+}
+
+func inSync(cached *model.Notification, stored model.Notification) bool {
+	return cached != nil && cached.Version == stored.Version && cached.Status == stored.Status
+}
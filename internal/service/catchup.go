@@ -0,0 +1,159 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// CatchUpConfig describes how missed notifications are caught up, see
+// model.CatchUpSendAll. A notification is missed when it is overdue by more
+// than OverdueAfter the first time it is claimed. MaxAge applies to model.CatchUpDropOlder and
+// Window to model.CatchUpSpread.
+type CatchUpConfig struct {
+	Policy       string
+	OverdueAfter time.Duration
+	MaxAge       time.Duration
+	Window       time.Duration
+}
+
+// DefaultCatchUpConfig is used when the service is created without
+// WithCatchUp.
+var DefaultCatchUpConfig = CatchUpConfig{
+	Policy:       model.CatchUpSendAll,
+	OverdueAfter: time.Minute,
+	MaxAge:       time.Hour,
+	Window:       10 * time.Minute,
+}
+
+// addressee identifies whom a notification is sent to, see
+// model.CatchUpLatest.
+type addressee struct {
+	telegramId  int
+	recipientId int
+	groupId     int
+}
+
+func addresseeOf(notification model.Notification) addressee {
+	return addressee{notification.TelegramId, notification.RecipientId, notification.GroupId}
+}
+
+// GetCatchUpReport returns what the last catch-up of missed notifications
+// did, nil if none were missed since the start.
+func (s *Service) GetCatchUpReport() *dto.CatchUpReport {
+	report := s.lastCatchUp.Load()
+	if report == nil {
+		return nil
+	}
+
+	copied := *report
+	return &copied
+}
+
+// splitMissed separates claimed notifications that are overdue by more than
+// the catch-up threshold from the ones claimed in time. One claimed again
+// after its claim lease ran out is a retry of a send that did not finish,
+// not a missed one, however late it is.
+func (s *Service) splitMissed(claimed []model.Notification, now time.Time) (ready, missed []model.Notification) {
+	threshold := now.Add(-s.catchUp.OverdueAfter).UnixMilli()
+	for _, notification := range claimed {
+		if notification.Claims <= 1 && int64(notification.SendAt) < threshold {
+			missed = append(missed, notification)
+		} else {
+			ready = append(ready, notification)
+		}
+	}
+	return ready, missed
+}
+
+// catchUpMissed applies the catch-up policy to missed notifications and
+// records what it did, see GetCatchUpReport. Notifications past their own
+// expiry expire whatever the policy.
+func (s *Service) catchUpMissed(missed []model.Notification, now time.Time) error {
+	sort.Slice(missed, func(i, j int) bool {
+		if missed[i].SendAt != missed[j].SendAt {
+			return missed[i].SendAt < missed[j].SendAt
+		}
+		return missed[i].Id < missed[j].Id
+	})
+
+	report := dto.CatchUpReport{
+		Policy:      s.catchUp.Policy,
+		StartedAt:   now,
+		OldestDueAt: time.UnixMilli(int64(missed[0].SendAt)),
+		Missed:      len(missed),
+	}
+
+	var pending []model.Notification
+	for _, notification := range missed {
+		if notification.Expired(now) {
+			s.expireMissed(notification, &report)
+			continue
+		}
+		pending = append(pending, notification)
+	}
+
+	switch s.catchUp.Policy {
+	case model.CatchUpLatest:
+		latest := make(map[addressee]int, len(pending))
+		for _, notification := range pending {
+			latest[addresseeOf(notification)] = notification.Id
+		}
+
+		kept := pending[:0]
+		for _, notification := range pending {
+			if latest[addresseeOf(notification)] != notification.Id {
+				s.expireMissed(notification, &report)
+				continue
+			}
+			kept = append(kept, notification)
+		}
+		pending = kept
+	case model.CatchUpDropOlder:
+		oldest := now.Add(-s.catchUp.MaxAge).UnixMilli()
+		kept := pending[:0]
+		for _, notification := range pending {
+			if int64(notification.SendAt) < oldest {
+				s.expireMissed(notification, &report)
+				continue
+			}
+			kept = append(kept, notification)
+		}
+		pending = kept
+	case model.CatchUpSpread:
+		// The oldest is sent right away, the others follow at even
+		// intervals within the window.
+		for i := 1; i < len(pending); i++ {
+			delay := s.catchUp.Window * time.Duration(i) / time.Duration(len(pending))
+			if err := s.reschedule(pending[i].Id, delay); err != nil {
+				zlog.Logger.Error().Msg(err.Error())
+				continue
+			}
+			report.Rescheduled++
+		}
+		pending = pending[:min(len(pending), 1)]
+	}
+
+	for _, notification := range pending {
+		if err := s.queue.Publish(notification); err != nil {
+			return err
+		}
+		report.Sent++
+	}
+
+	s.lastCatchUp.Store(&report)
+	zlog.Logger.Warn().Msgf("caught up %d missed notifications due since %s with policy %s: %d sent, %d expired, %d rescheduled",
+		report.Missed, report.OldestDueAt.Format(time.RFC3339), report.Policy, report.Sent, report.Expired, report.Rescheduled)
+	return nil
+}
+
+func (s *Service) expireMissed(notification model.Notification, report *dto.CatchUpReport) {
+	if err := s.setStatus(notification.Id, "expired"); err != nil {
+		zlog.Logger.Error().Msg(err.Error())
+		return
+	}
+	report.Expired++
+}
@@ -4,16 +4,46 @@ import (
 	"github.com/Komilov31/delayed-notifier/internal/model"
 )
 
+// CreateNotification schedules the notification. One with a collapse key
+// may be collapsed into an earlier one instead, see WithCollapsePolicy.
 func (s *Service) CreateNotification(notification model.Notification) (*model.Notification, error) {
 	notification.Status = "active"
+	if notification.CollapseKey != "" {
+		return s.createCollapsed(notification)
+	}
+
 	notif, err := s.storage.CreateNotification(notification)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.cache.Set(notif.Id, notif.Status); err != nil {
+	s.cacheNotification(notif)
+	s.publish(model.EventCreated, *notif)
+
+	return notif, nil
+}
+
+// createCollapsed creates the notification applying the collapse policy
+// and returns it, or the earlier notification it was collapsed into.
+func (s *Service) createCollapsed(notification model.Notification) (*model.Notification, error) {
+	result, err := s.storage.CollapseNotification(notification, s.collapsePolicy)
+	if err != nil {
 		return nil, err
 	}
 
+	for _, id := range result.Canceled {
+		s.changed(id, model.EventCanceled)
+	}
+
+	notif := &result.Notification
+	switch {
+	case result.Created:
+		s.cacheNotification(notif)
+		s.publish(model.EventCreated, *notif)
+	case result.Merged:
+		s.cacheNotification(notif)
+		s.publish(model.EventUpdated, *notif)
+	}
+
 	return notif, nil
 }
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// collectDigest assigns the notifications of the recipient due within its
+// digest window to the digest of the notification and returns them ordered
+// by send time, none if the notification is in the digest of another one.
+// Expired notifications and ones of muted categories are not sent, ones that
+// do not fit in a single message are left for a later digest.
+func (s *Service) collectDigest(notification model.Notification, preferences model.Preferences) ([]model.Notification, error) {
+	from := max(int64(notification.SendAt), time.Now().UnixMilli())
+	dueBefore := int(from) + preferences.DigestWindow*1000
+
+	members, err := s.storage.AssignDigest(notification.Id, notification.RecipientId, dueBefore, preferences.DigestCategories)
+	if err != nil {
+		return nil, fmt.Errorf("could not assign digest in db: " + err.Error())
+	}
+
+	leader := slices.IndexFunc(members, func(member model.Notification) bool {
+		return member.Id == notification.Id
+	})
+	if leader < 0 {
+		zlog.Logger.Debug().Msgf("notification %d is sent in another digest", notification.Id)
+		return nil, nil
+	}
+
+	batch := []model.Notification{members[leader]}
+
+	limit := notification.Options.TextLimit()
+	for _, member := range members {
+		if member.Id == notification.Id {
+			continue
+		}
+
+		if member.Expired(time.Now()) {
+			if err := s.expire(member); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if reason := preferences.Blocks(member); reason != "" {
+			if err := s.suppress(member, reason); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if next := append(batch, member); utf8.RuneCountInString(s.digestText(notification.Options, next)) <= limit {
+			batch = next
+			continue
+		}
+
+		if err := s.storage.LeaveDigest(member.Id); err != nil {
+			return nil, fmt.Errorf("could not leave digest in db: " + err.Error())
+		}
+	}
+
+	sort.Slice(batch, func(i, j int) bool {
+		if batch[i].SendAt != batch[j].SendAt {
+			return batch[i].SendAt < batch[j].SendAt
+		}
+		return batch[i].Id < batch[j].Id
+	})
+	return batch, nil
+}
+
+// digestText renders the notifications as one message sent with the given
+// options: the text of a single notification as it is, the texts of several
+// as a numbered list. The list markup and texts written for another parse
+// mode are escaped so that Telegram accepts the message.
+func (s *Service) digestText(options *model.TelegramOptions, batch []model.Notification) string {
+	if len(batch) == 1 {
+		return s.withUnsubscribeLink(batch[0])
+	}
+
+	var text strings.Builder
+	text.WriteString(options.Escape(fmt.Sprintf("You have %d notifications:", len(batch))))
+	for i, notification := range batch {
+		member := s.withUnsubscribeLink(notification)
+		if notification.Options.Mode() != options.Mode() {
+			member = options.Escape(member)
+		}
+		fmt.Fprintf(&text, "\n\n%s%s", options.Escape(fmt.Sprintf("%d. ", i+1)), member)
+	}
+	return text.String()
+}
+
+// setStatuses sets the status of every notification of the batch, the one
+// handled last, so that a digest interrupted half way is finished when the
+// handled notification is picked up again.
+func (s *Service) setStatuses(handled int, batch []model.Notification, status string) error {
+	for _, notification := range batch {
+		if notification.Id == handled {
+			continue
+		}
+		if err := s.setStatus(notification.Id, status); err != nil {
+			return err
+		}
+	}
+
+	return s.setStatus(handled, status)
+}
@@ -0,0 +1,629 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/handler"
+	"github.com/Komilov31/delayed-notifier/internal/memory"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/sender"
+	"github.com/Komilov31/delayed-notifier/internal/sender/telegramtest"
+	"github.com/Komilov31/delayed-notifier/internal/service"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	waitFor = 5 * time.Second
+	tick    = 10 * time.Millisecond
+)
+
+type testEnv struct {
+	telegram *telegramtest.Server
+	sender   *sender.TelegramSender
+	storage  *memory.Storage
+	queue    *memory.Queue
+	service  *service.Service
+	router   *gin.Engine
+}
+
+func newTestEnv(t *testing.T, opts ...service.Option) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	telegram := telegramtest.NewServer()
+	t.Cleanup(telegram.Close)
+
+	tg, err := sender.NewWithEndpoint("test-token", telegram.URL)
+	require.NoError(t, err)
+	tg.EnableSnooze()
+
+	storage := memory.NewStorage()
+	queue := memory.NewQueue()
+	opts = append([]service.Option{service.WithPollInterval(time.Hour)}, opts...)
+	svc := service.New(storage, memory.NewCache(), queue, tg, opts...)
+
+	h := handler.New(svc)
+	router := gin.New()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	return &testEnv{
+		telegram: telegram,
+		sender:   tg,
+		storage:  storage,
+		queue:    queue,
+		service:  svc,
+		router:   router,
+	}
+}
+
+// start runs the scheduler and the consumer. The poll interval is an hour,
+// so ready notifications are published exactly once, right away.
+func (e *testEnv) start(t *testing.T) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, e.service.ConsumeMessages(ctx))
+	go e.service.PublishReadyNotifications(ctx)
+}
+
+func (e *testEnv) createNotification(t *testing.T, notification dto.CreateNotificationRequest) int {
+	t.Helper()
+
+	body, err := json.Marshal(notification)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var created dto.NotificationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created.Id
+}
+
+func (e *testEnv) status(t *testing.T, id int) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/"+strconv.Itoa(id), nil)
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var notification dto.NotificationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notification))
+	return notification.Status
+}
+
+func TestE2E_NotificationDelivered(t *testing.T) {
+	env := newTestEnv(t)
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "<b>Meeting</b> in 5 minutes",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+		Options: &model.TelegramOptions{
+			ParseMode: model.ParseModeHTML,
+			Buttons:   [][]model.Button{{{Text: "Join", URL: "https://example.com/meet"}}},
+		},
+	})
+	assert.Equal(t, "active", env.status(t, id))
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "sendMessage", messages[0].Method)
+	assert.Equal(t, int64(42), messages[0].ChatID)
+	assert.Equal(t, "<b>Meeting</b> in 5 minutes", messages[0].Text)
+	assert.Equal(t, model.ParseModeHTML, messages[0].ParseMode)
+	assert.Contains(t, messages[0].ReplyMarkup, "https://example.com/meet")
+	assert.Contains(t, messages[0].ReplyMarkup, "snooze:"+strconv.Itoa(id))
+}
+
+func TestE2E_PhotoAttachment(t *testing.T) {
+	env := newTestEnv(t)
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Your order",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+		Options: &model.TelegramOptions{
+			Attachment: &model.Attachment{Type: model.AttachmentPhoto, URL: "https://example.com/order.png"},
+		},
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "sendPhoto", messages[0].Method)
+	assert.Equal(t, "Your order", messages[0].Text)
+	assert.Equal(t, "https://example.com/order.png", messages[0].File)
+}
+
+func TestE2E_RateLimitedNotificationRescheduled(t *testing.T) {
+	env := newTestEnv(t)
+	env.telegram.FailNext(telegramtest.Failure{
+		Code:        http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after 30",
+		RetryAfter:  30,
+	})
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Rate limited",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool {
+		notification, err := env.storage.GetNotificationById(id)
+		return err == nil && int64(notification.SendAt) >= time.Now().Add(25*time.Second).UnixMilli()
+	}, waitFor, tick)
+
+	assert.Equal(t, "active", env.status(t, id))
+	assert.Empty(t, env.telegram.Messages())
+}
+
+func TestE2E_BlockedRecipientFails(t *testing.T) {
+	env := newTestEnv(t)
+	env.telegram.FailNext(telegramtest.Failure{
+		Code:        http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	})
+
+	first := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "First",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, first) == "failed" }, waitFor, tick)
+
+	unreachable, err := env.storage.IsRecipientUnreachable(42)
+	require.NoError(t, err)
+	assert.True(t, unreachable)
+	assert.Empty(t, env.telegram.Messages())
+}
+
+func TestE2E_ChatNotFoundFails(t *testing.T) {
+	env := newTestEnv(t)
+	env.telegram.FailNext(telegramtest.Failure{
+		Code:        http.StatusBadRequest,
+		Description: "Bad Request: chat not found",
+	})
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Nobody here",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, id) == "failed" }, waitFor, tick)
+
+	unreachable, err := env.storage.IsRecipientUnreachable(42)
+	require.NoError(t, err)
+	assert.False(t, unreachable)
+}
+
+func TestE2E_SnoozeButton(t *testing.T) {
+	env := newTestEnv(t)
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Snooze me",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go env.sender.RunBot(ctx, env.service)
+
+	env.telegram.PushUpdate(tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "callback",
+			From:    &tgbotapi.User{ID: 42},
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 42}},
+			Data:    "snooze:" + strconv.Itoa(id) + ":10",
+		},
+	})
+
+	assert.Eventually(t, func() bool {
+		callbacks := env.telegram.AnsweredCallbacks()
+		return len(callbacks) == 1 && strings.HasPrefix(callbacks[0], "Snoozed until")
+	}, waitFor, tick)
+	assert.Equal(t, "active", env.status(t, id))
+}
+
+func TestE2E_StreamEvents(t *testing.T) {
+	env := newTestEnv(t)
+
+	events, unsubscribe := env.service.SubscribeEvents(model.EventFilter{TelegramId: 42})
+	defer unsubscribe()
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Stream me",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	var types []string
+	for len(types) < 2 {
+		select {
+		case event := <-events:
+			assert.Equal(t, id, event.Notification.Id)
+			types = append(types, event.Type)
+		case <-time.After(waitFor):
+			t.Fatalf("got events %v, want created and sent", types)
+		}
+	}
+
+	assert.Equal(t, []string{model.EventCreated, model.EventSent}, types)
+}
+
+func TestE2E_RecipientResolvedAtSendTime(t *testing.T) {
+	env := newTestEnv(t)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/v1/recipients", `{"name":"Alice","contacts":[{"channel":"email","address":"alice@example.com"},{"channel":"telegram","address":"42"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var recipient dto.RecipientResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &recipient))
+	require.Len(t, recipient.Contacts, 2)
+	telegramContact := recipient.Contacts[1]
+
+	// The code is sent in the background.
+	require.Eventually(t, func() bool { return len(env.telegram.Messages()) == 1 }, waitFor, tick)
+	messages := env.telegram.Messages()
+	assert.Equal(t, int64(42), messages[0].ChatID)
+	code := messages[0].Text[strings.LastIndex(messages[0].Text, " ")+1:]
+
+	contactPath := "/api/v1/recipients/" + strconv.Itoa(recipient.Id) + "/contacts/" + strconv.Itoa(telegramContact.Id)
+	w = serve(http.MethodPost, contactPath+"/verify", `{"code":"`+code+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "For Alice",
+		RecipientId: recipient.Id,
+		SendAt:      time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	messages = env.telegram.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, int64(42), messages[1].ChatID)
+	assert.Equal(t, "For Alice", messages[1].Text)
+}
+
+func TestE2E_FallbackToWebhook(t *testing.T) {
+	var payloads []sender.WebhookPayload
+	var mu sync.Mutex
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload sender.WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	env := newTestEnv(t, service.WithChannelSender(model.ChannelWebhook, sender.NewWebhookSenderWithClient(&http.Client{Timeout: time.Second})))
+	env.telegram.FailNext(telegramtest.Failure{
+		Code:        http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	})
+
+	recipient, err := env.storage.CreateRecipient(model.Recipient{
+		Name: "Alice",
+		Contacts: []model.Contact{
+			{Channel: model.ChannelTelegram, Address: "42", Verified: true},
+			{Channel: model.ChannelWebhook, Address: webhook.URL, Verified: true},
+		},
+	})
+	require.NoError(t, err)
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "Fall back",
+		RecipientId: recipient.Id,
+		SendAt:      time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	mu.Lock()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, id, payloads[0].Id)
+	assert.Equal(t, "Fall back", payloads[0].Text)
+	mu.Unlock()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/"+strconv.Itoa(id)+"/history", nil)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var history []dto.DeliveryAttemptResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history, 2)
+	assert.Equal(t, model.ChannelTelegram, history[0].Channel)
+	assert.Equal(t, model.DeliveryFailed, history[0].Status)
+	assert.Equal(t, model.ChannelWebhook, history[1].Channel)
+	assert.Equal(t, model.DeliverySent, history[1].Status)
+}
+
+func TestE2E_GroupFanOut(t *testing.T) {
+	env := newTestEnv(t, service.WithPollInterval(20*time.Millisecond))
+
+	group, err := env.storage.CreateGroup(model.Group{Name: "Team"})
+	require.NoError(t, err)
+	for i, chat := range []string{"42", "43"} {
+		recipient, err := env.storage.CreateRecipient(model.Recipient{
+			Name:     "Member " + strconv.Itoa(i),
+			Contacts: []model.Contact{{Channel: model.ChannelTelegram, Address: chat, Verified: true}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.storage.AddGroupMember(group.Id, recipient.Id))
+	}
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:    "Standup",
+		GroupId: group.Id,
+		SendAt:  time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	deliveries := func() dto.GroupDeliveriesResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/"+strconv.Itoa(id)+"/deliveries", nil)
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response dto.GroupDeliveriesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	assert.Eventually(t, func() bool { return deliveries().Delivered == 2 }, waitFor, tick)
+
+	response := deliveries()
+	assert.Equal(t, 2, response.Total)
+	require.Len(t, response.Deliveries, 2)
+	for _, delivery := range response.Deliveries {
+		assert.Equal(t, id, delivery.ParentId)
+		assert.Equal(t, "completed", delivery.Status)
+	}
+	assert.Equal(t, "completed", env.status(t, id))
+
+	var chats []int64
+	for _, message := range env.telegram.Messages() {
+		assert.Equal(t, "Standup", message.Text)
+		chats = append(chats, message.ChatID)
+	}
+	assert.ElementsMatch(t, []int64{42, 43}, chats)
+}
+
+func TestE2E_UnsubscribeLink(t *testing.T) {
+	env := newTestEnv(t,
+		service.WithPollInterval(20*time.Millisecond),
+		service.WithUnsubscribeLinks(unsubscribe.NewSigner([]byte("secret")), "http://notifier.test/api/v1/unsubscribe"),
+	)
+
+	recipient, err := env.storage.CreateRecipient(model.Recipient{
+		Name:     "Alice",
+		Contacts: []model.Contact{{Channel: model.ChannelTelegram, Address: "42", Verified: true}},
+	})
+	require.NoError(t, err)
+
+	first := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "Your bill. Unsubscribe: {unsubscribe_url}",
+		RecipientId: recipient.Id,
+		Category:    "billing",
+		SendAt:      time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, first) == "completed" }, waitFor, tick)
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	_, link, ok := strings.Cut(messages[0].Text, "Unsubscribe: http://notifier.test")
+	require.True(t, ok, messages[0].Text)
+
+	// Opening the link, e.g. by a link preview, only asks to confirm.
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `method="post"`)
+
+	stored, err := env.storage.GetPreferences(recipient.Id)
+	require.NoError(t, err)
+	assert.Empty(t, stored.MutedCategories)
+
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, link, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var preferences dto.PreferencesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+	assert.Equal(t, []string{"billing"}, preferences.MutedCategories)
+	assert.False(t, preferences.Unsubscribed)
+
+	second := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "Another bill",
+		RecipientId: recipient.Id,
+		Category:    "billing",
+		SendAt:      time.Now().Add(time.Second),
+	})
+	assert.Eventually(t, func() bool { return env.status(t, second) == "canceled" }, waitFor, tick)
+	assert.Len(t, env.telegram.Messages(), 1)
+}
+
+func TestE2E_Digest(t *testing.T) {
+	env := newTestEnv(t)
+
+	recipient, err := env.storage.CreateRecipient(model.Recipient{
+		Name:     "Alice",
+		Contacts: []model.Contact{{Channel: model.ChannelTelegram, Address: "42", Verified: true}},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/recipients/"+strconv.Itoa(recipient.Id)+"/preferences",
+		strings.NewReader(`{"digest_window": 60}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var ids []int
+	for i, text := range []string{"Stand-up", "Lunch", "Review"} {
+		ids = append(ids, env.createNotification(t, dto.CreateNotificationRequest{
+			Text:        text,
+			RecipientId: recipient.Id,
+			SendAt:      time.Now().Add(time.Second + time.Duration(i)*100*time.Millisecond),
+		}))
+	}
+
+	env.start(t)
+
+	for _, id := range ids {
+		assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+	}
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, int64(42), messages[0].ChatID)
+	assert.Equal(t, "You have 3 notifications:\n\n1. Stand-up\n\n2. Lunch\n\n3. Review", messages[0].Text)
+}
+
+func TestE2E_CollapseKey(t *testing.T) {
+	env := newTestEnv(t)
+
+	var ids []int
+	for _, text := range []string{"Your order is waiting", "Your order is still waiting"} {
+		ids = append(ids, env.createNotification(t, dto.CreateNotificationRequest{
+			Text:        text,
+			TelegramId:  42,
+			CollapseKey: "order-42",
+			SendAt:      time.Now().Add(time.Second),
+		}))
+	}
+	assert.Equal(t, "canceled", env.status(t, ids[0]), "the newer notification replaces the earlier one")
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool { return env.status(t, ids[1]) == "completed" }, waitFor, tick)
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Your order is still waiting", messages[0].Text)
+}
+
+func TestE2E_CollapsedAfterQueued(t *testing.T) {
+	env := newTestEnv(t, service.WithPollInterval(20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go env.service.PublishReadyNotifications(ctx)
+
+	first := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "Your order is waiting",
+		TelegramId:  42,
+		CollapseKey: "order-42",
+		SendAt:      time.Now().Add(time.Second),
+	})
+	require.Eventually(t, func() bool { return env.queue.Len() == 1 }, waitFor, tick, "the first notification is claimed and queued")
+
+	second := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:        "Your order is still waiting",
+		TelegramId:  42,
+		CollapseKey: "order-42",
+		SendAt:      time.Now().Add(time.Second),
+	})
+	assert.Equal(t, "canceled", env.status(t, first))
+
+	require.NoError(t, env.service.ConsumeMessages(ctx))
+	assert.Eventually(t, func() bool { return env.status(t, second) == "completed" }, waitFor, tick)
+	assert.Equal(t, "canceled", env.status(t, first), "the queued message of the collapsed notification is not sent")
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Your order is still waiting", messages[0].Text)
+}
+
+func TestE2E_ResendCompleted(t *testing.T) {
+	env := newTestEnv(t, service.WithPollInterval(20*time.Millisecond))
+
+	id := env.createNotification(t, dto.CreateNotificationRequest{
+		Text:       "Standup in 5 minutes",
+		TelegramId: 42,
+		SendAt:     time.Now().Add(time.Second),
+	})
+
+	env.start(t)
+	assert.Eventually(t, func() bool { return env.status(t, id) == "completed" }, waitFor, tick)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/"+strconv.Itoa(id)+"/resend", nil)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var followUp dto.NotificationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &followUp))
+	assert.NotEqual(t, id, followUp.Id)
+	assert.Equal(t, id, followUp.FollowUpOf)
+
+	assert.Eventually(t, func() bool { return env.status(t, followUp.Id) == "completed" }, waitFor, tick)
+	assert.Equal(t, "completed", env.status(t, id), "the original is left as it was")
+
+	messages := env.telegram.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, messages[0].Text, messages[1].Text)
+}
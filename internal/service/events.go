@@ -0,0 +1,29 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// changed follows every storage write of the notification: it refreshes the
+// cache and tells stream subscribers what happened.
+func (s *Service) changed(id int, eventType string) {
+	if notification := s.refreshCache(id); notification != nil {
+		s.publish(eventType, *notification)
+	}
+}
+
+func (s *Service) publish(eventType string, notification model.Notification) {
+	s.events.Publish(model.Event{
+		Type:         eventType,
+		Notification: notification,
+		Time:         time.Now(),
+	})
+}
+
+// SubscribeEvents returns changes of notifications matching filter. The
+// returned function must be called once the caller stops reading.
+func (s *Service) SubscribeEvents(filter model.EventFilter) (<-chan model.Event, func()) {
+	return s.events.Subscribe(filter)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// FollowUpNotification creates a notification with the content and the
+// addressee of source, sent at sendAt and linked to it by FollowUpOf. An
+// expiry keeps its distance to the send time.
+func (s *Service) FollowUpNotification(source model.Notification, sendAt time.Time) (*model.Notification, error) {
+	followUp := model.Notification{
+		Text:        source.Text,
+		TelegramId:  source.TelegramId,
+		RecipientId: source.RecipientId,
+		GroupId:     source.GroupId,
+		Channels:    source.Channels,
+		Category:    source.Category,
+		Tenant:      source.Tenant,
+		CollapseKey: source.CollapseKey,
+		Options:     source.Options,
+		SendAt:      int(sendAt.UnixMilli()),
+		FollowUpOf:  source.Id,
+	}
+	if source.ExpiresAt != 0 {
+		followUp.ExpiresAt = followUp.SendAt + source.ExpiresAt - source.SendAt
+	}
+
+	return s.CreateNotification(followUp)
+}
+
+// GetFollowUps returns the notifications snoozed or resent from the
+// notification ordered by id.
+func (s *Service) GetFollowUps(id int) ([]model.Notification, error) {
+	if _, err := s.GetNotification(id); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetFollowUps(id)
+}
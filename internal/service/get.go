@@ -1,36 +1,69 @@
 package service
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
 
 	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
 	"github.com/go-redis/redis/v8"
+	"github.com/wb-go/wbf/zlog"
 )
 
 func (s *Service) GetAllNotifications() ([]model.Notification, error) {
 	return s.storage.GetAllNotifications()
 }
 
-func (s *Service) GetNotificationStatus(id int) (*dto.NotificationStatus, error) {
-	statusString, err := s.cache.Get(strconv.Itoa(id))
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("could not get notif status from redis: " + err.Error())
+func (s *Service) GetUpcomingNotifications(telegramId int) ([]model.Notification, error) {
+	return s.storage.GetUpcomingNotifications(telegramId)
+}
+
+// GetNotification reads the notification through the cache. Unknown ids are
+// cached as missing too, so polling for them does not reach the storage.
+func (s *Service) GetNotification(id int) (*model.Notification, error) {
+	notification, err := s.cache.GetNotification(id)
+	if err == nil || errors.Is(err, repository.ErrNoSuchNotification) {
+		return notification, err
+	}
+	if err != redis.Nil {
+		return nil, fmt.Errorf("could not get notification from redis: " + err.Error())
 	}
 
-	if err == redis.Nil {
-		notification, err := s.storage.GetNotificationById(id)
-		if err != nil {
-			return nil, err
+	notification, err = s.storage.GetNotificationById(id)
+	if errors.Is(err, repository.ErrNoSuchNotification) {
+		if err := s.cache.SetMissing(id); err != nil {
+			zlog.Logger.Warn().Msgf("could not cache missing notification %d: %s", id, err.Error())
 		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		statusString = notification.Status
+	s.cacheNotification(notification)
+	return notification, nil
+}
+
+// GetDeliveryHistory returns the contacts the notification was sent to,
+// oldest first.
+func (s *Service) GetDeliveryHistory(id int) ([]model.DeliveryAttempt, error) {
+	if _, err := s.GetNotification(id); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetDeliveryAttempts(id)
+}
+
+func (s *Service) GetNotificationStatus(id int) (*dto.NotificationStatus, error) {
+	notification, err := s.GetNotification(id)
+	if err != nil {
+		return nil, err
 	}
 
 	var status dto.NotificationStatus
 	status.Id = id
-	status.Status = statusString
+	status.Status = notification.Status
 
 	return &status, nil
 }
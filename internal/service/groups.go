@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func (s *Service) CreateGroup(name string) (*model.Group, error) {
+	return s.storage.CreateGroup(model.Group{Name: name})
+}
+
+func (s *Service) GetGroup(id int) (*model.Group, error) {
+	return s.storage.GetGroup(id)
+}
+
+func (s *Service) GetAllGroups() ([]model.Group, error) {
+	return s.storage.GetAllGroups()
+}
+
+// DeleteGroup deletes the group and its memberships. Notifications still
+// addressed to it fail when they are due, deliveries already created for
+// its members are sent.
+func (s *Service) DeleteGroup(id int) error {
+	return s.storage.DeleteGroup(id)
+}
+
+// AddGroupMember subscribes the recipient to the group and returns the
+// group.
+func (s *Service) AddGroupMember(groupId, recipientId int) (*model.Group, error) {
+	if err := s.storage.AddGroupMember(groupId, recipientId); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetGroup(groupId)
+}
+
+func (s *Service) RemoveGroupMember(groupId, recipientId int) error {
+	return s.storage.RemoveGroupMember(groupId, recipientId)
+}
+
+// GetGroupDeliveries returns the per-recipient deliveries of a group
+// notification with their statuses summed up. A notification that is not
+// due yet has none.
+func (s *Service) GetGroupDeliveries(id int) (*model.GroupDeliveries, []model.Notification, error) {
+	if _, err := s.GetNotification(id); err != nil {
+		return nil, nil, err
+	}
+
+	deliveries, err := s.storage.GetChildNotifications(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats := model.NewGroupDeliveries(deliveries)
+	return &stats, deliveries, nil
+}
+
+// fanOut creates a notification for each member of the group the
+// notification is addressed to, due right away, and completes the group
+// notification. The deliveries are sent like any other notification, so
+// each member gets its own retries and fallbacks. Members that already have
+// a delivery, e.g. when the message is handled again after a crash, are
+// skipped.
+func (s *Service) fanOut(notification model.Notification) error {
+	group, err := s.storage.GetGroup(notification.GroupId)
+	if errors.Is(err, repository.ErrNoSuchGroup) {
+		if err := s.setStatus(notification.Id, "failed"); err != nil {
+			return err
+		}
+		return fmt.Errorf("notification %d failed: group %d does not exist", notification.Id, notification.GroupId)
+	}
+	if err != nil {
+		return fmt.Errorf("could not get group from db: " + err.Error())
+	}
+
+	if len(group.Members) == 0 {
+		if err := s.setStatus(notification.Id, "failed"); err != nil {
+			return err
+		}
+		return fmt.Errorf("notification %d failed: group %d has no members", notification.Id, notification.GroupId)
+	}
+
+	existing, err := s.storage.GetChildNotifications(notification.Id)
+	if err != nil {
+		return fmt.Errorf("could not get group deliveries from db: " + err.Error())
+	}
+	created := make(map[int]bool, len(existing))
+	for _, delivery := range existing {
+		created[delivery.RecipientId] = true
+	}
+
+	for _, recipientId := range group.Members {
+		if created[recipientId] {
+			continue
+		}
+
+		delivery := model.Notification{
+			Text:        notification.Text,
+			RecipientId: recipientId,
+			Channels:    notification.Channels,
+			Category:    notification.Category,
+			Tenant:      notification.Tenant,
+			ParentId:    notification.Id,
+			SendAt:      int(time.Now().UnixMilli()),
+			ExpiresAt:   notification.ExpiresAt,
+			Options:     notification.Options,
+		}
+		if _, err := s.CreateNotification(delivery); err != nil {
+			return fmt.Errorf("could not create group delivery: " + err.Error())
+		}
+	}
+
+	if err := s.setStatus(notification.Id, "completed"); err != nil {
+		return err
+	}
+
+	zlog.Logger.Info().Msgf("notification %d fanned out to %d members of group %d", notification.Id, len(group.Members), group.Id)
+	return nil
+}
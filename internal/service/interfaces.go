@@ -8,23 +8,81 @@ import (
 
 type Storage interface {
 	CreateNotification(model.Notification) (*model.Notification, error)
+	CollapseNotification(model.Notification, string) (*model.CollapseResult, error)
 	DeleteNotificationById(int) error
+	PurgeNotifications(model.RetentionScope, int) ([]int, error)
+	AnonymizeNotifications(model.RetentionScope, int) ([]int, error)
 	GetNotificationById(int) (*model.Notification, error)
 	GetAllNotifications() ([]model.Notification, error)
+	GetNotificationsAfter(int, int) ([]model.Notification, error)
 	GetReadyNotifications() ([]model.Notification, error)
+	ClaimReadyNotifications(int) ([]model.Notification, error)
+	GetUpcomingNotifications(int) ([]model.Notification, error)
+	GetChildNotifications(int) ([]model.Notification, error)
+	GetFollowUps(int) ([]model.Notification, error)
 	UpdateNotificationStatus(int, string) error
+	RescheduleNotification(int, int) error
+	AssignDigest(int, int, int, []string) ([]model.Notification, error)
+	LeaveDigest(int) error
+	SaveSubscriber(model.Subscriber) error
+	GetSubscriberByHandle(string) (*model.Subscriber, error)
+	MarkRecipientUnreachable(int, string) error
+	MarkRecipientReachable(int) error
+	IsRecipientUnreachable(int) (bool, error)
+	CreateRecipient(model.Recipient) (*model.Recipient, error)
+	GetRecipient(int) (*model.Recipient, error)
+	GetAllRecipients() ([]model.Recipient, error)
+	RenameRecipient(int, string) error
+	DeleteRecipient(int) error
+	AddContact(int, model.Contact) (*model.Contact, error)
+	DeleteContact(int, int) error
+	VerifyContact(int, int) error
+	ReorderContacts(int, []int) error
+	GetPreferences(int) (*model.Preferences, error)
+	SavePreferences(model.Preferences) error
+	AddDeliveryAttempt(model.DeliveryAttempt) error
+	GetDeliveryAttempts(int) ([]model.DeliveryAttempt, error)
+	CreateGroup(model.Group) (*model.Group, error)
+	GetGroup(int) (*model.Group, error)
+	GetAllGroups() ([]model.Group, error)
+	DeleteGroup(int) error
+	AddGroupMember(int, int) error
+	RemoveGroupMember(int, int) error
 }
 
+// Cache keeps notifications in front of the storage, which stays the source
+// of truth. Entries carry model.Notification.Version: SetNotification keeps
+// the cached entry if it is newer, so a writer that read the storage earlier
+// can not overwrite a fresher notification. GetNotification reports a
+// missing entry with redis.Nil and an id remembered by SetMissing with
+// repository.ErrNoSuchNotification.
 type Cache interface {
-	Get(string) (string, error)
-	Set(int, interface{}) error
+	GetNotification(id int) (*model.Notification, error)
+	SetNotification(model.Notification) error
+	SetNotifications([]model.Notification) error
+	SetMissing(id int) error
+	DeleteNotification(id int) error
 }
 
 type Queue interface {
 	Publish(model.Notification) error
-	Consume(ctx context.Context) (<-chan []byte, error)
+	Consume(ctx context.Context) (<-chan model.QueueMessage, error)
+	SetPrefetch(int) error
+}
+
+// Events delivers notification change events, see package events.
+type Events interface {
+	Publish(model.Event)
+	Subscribe(model.EventFilter) (<-chan model.Event, func())
 }
 
 type Sender interface {
-	SendToTelegram(int, string) error
+	SendToTelegram(model.Notification) error
+}
+
+// ChannelSender delivers notifications to the addresses of one contact
+// channel other than telegram, e.g. sender.EmailSender. Errors are
+// classified like those of Sender.
+type ChannelSender interface {
+	Send(address string, notification model.Notification) error
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// PoolConfig describes the consumer worker pool. Workers is the number of
+// goroutines reading the delivery channel, Concurrency is the upper bound
+// Workers can be scaled to and Prefetch is the AMQP prefetch count.
+type PoolConfig struct {
+	Workers     int
+	Concurrency int
+	Prefetch    int
+}
+
+// DefaultPoolConfig is used when the service is created without WithPoolConfig.
+var DefaultPoolConfig = PoolConfig{
+	Workers:     3,
+	Concurrency: 16,
+	Prefetch:    10,
+}
+
+// Validate returns ErrInvalidPoolConfig unless Workers is between 1 and
+// Concurrency and Prefetch is not negative.
+func (c PoolConfig) Validate() error {
+	if c.Workers < 1 || c.Workers > c.Concurrency || c.Prefetch < 0 {
+		return ErrInvalidPoolConfig
+	}
+	return nil
+}
+
+type workerPool struct {
+	mu       sync.Mutex
+	config   PoolConfig
+	messages <-chan model.QueueMessage
+	handle   func(model.QueueMessage)
+	stops    []chan struct{}
+	wg       sync.WaitGroup
+
+	busy      atomic.Int64
+	processed atomic.Uint64
+}
+
+func newWorkerPool(config PoolConfig, handle func(model.QueueMessage)) *workerPool {
+	return &workerPool{
+		config: config,
+		handle: handle,
+	}
+}
+
+// start attaches the pool to the delivery channel and spawns the configured
+// number of workers.
+func (p *workerPool) start(messages <-chan model.QueueMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = messages
+	p.scale(p.config.Workers)
+}
+
+// resize changes the number of running workers. Removed workers finish the
+// message they are currently handling before exiting, so nothing that was
+// already taken from the channel is lost.
+func (p *workerPool) resize(workers int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.config.Workers = workers
+	if p.messages != nil {
+		p.scale(workers)
+	}
+}
+
+func (p *workerPool) setConcurrency(concurrency int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.Concurrency = concurrency
+}
+
+func (p *workerPool) setPrefetch(prefetch int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.Prefetch = prefetch
+}
+
+func (p *workerPool) getConfig() PoolConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config
+}
+
+// scale must be called with p.mu held.
+func (p *workerPool) scale(workers int) {
+	for len(p.stops) < workers {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1)
+		go p.work(len(p.stops)-1, stop)
+	}
+
+	for len(p.stops) > workers {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+func (p *workerPool) work(i int, stop <-chan struct{}) {
+	defer p.wg.Done()
+	zlog.Logger.Info().Msgf("consumer with index %d started", i)
+
+	for {
+		select {
+		case <-stop:
+			zlog.Logger.Info().Msgf("consumer with index %d stopped", i)
+			return
+		case msg, ok := <-p.messages:
+			if !ok {
+				return
+			}
+
+			p.busy.Add(1)
+			p.handle(msg)
+			p.busy.Add(-1)
+			p.processed.Add(1)
+		}
+	}
+}
+
+// wait blocks until every worker has exited. Workers exit once the delivery
+// channel is closed.
+func (p *workerPool) wait() {
+	p.wg.Wait()
+}
+
+func (p *workerPool) stats() dto.WorkerPoolStats {
+	p.mu.Lock()
+	running := len(p.stops)
+	config := p.config
+	p.mu.Unlock()
+
+	busy := int(p.busy.Load())
+	var utilization float64
+	if running > 0 {
+		utilization = float64(busy) / float64(running)
+	}
+
+	return dto.WorkerPoolStats{
+		Workers:     running,
+		Concurrency: config.Concurrency,
+		Prefetch:    config.Prefetch,
+		Busy:        busy,
+		Utilization: utilization,
+		Processed:   p.processed.Load(),
+	}
+}
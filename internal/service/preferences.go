@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// unsubscribePlaceholder in the text of a notification for a recipient is
+// replaced with its unsubscribe link when the notification is sent.
+const unsubscribePlaceholder = "{unsubscribe_url}"
+
+func (s *Service) GetPreferences(recipientId int) (*model.Preferences, error) {
+	return s.storage.GetPreferences(recipientId)
+}
+
+// UpdatePreferences replaces the preferences of the recipient.
+func (s *Service) UpdatePreferences(preferences model.Preferences) (*model.Preferences, error) {
+	if err := s.storage.SavePreferences(preferences); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetPreferences(preferences.RecipientId)
+}
+
+// VerifyUnsubscribe checks the token of an unsubscribe link without
+// processing it, see Unsubscribe.
+func (s *Service) VerifyUnsubscribe(token string) (*unsubscribe.Token, error) {
+	if s.unsubscribe == nil {
+		return nil, unsubscribe.ErrInvalidToken
+	}
+
+	verified, err := s.unsubscribe.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verified, nil
+}
+
+// Unsubscribe processes the token of an unsubscribe link: it mutes the
+// category of the token or, without one, unsubscribes the recipient from
+// everything. Processing a link twice changes nothing.
+func (s *Service) Unsubscribe(token string) (*model.Preferences, error) {
+	verified, err := s.VerifyUnsubscribe(token)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := s.storage.GetPreferences(verified.RecipientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if verified.Category == "" {
+		preferences.Unsubscribed = true
+	} else if !slices.Contains(preferences.MutedCategories, verified.Category) {
+		preferences.MutedCategories = append(preferences.MutedCategories, verified.Category)
+	}
+
+	if err := s.storage.SavePreferences(*preferences); err != nil {
+		return nil, err
+	}
+
+	zlog.Logger.Info().Msgf("recipient %d unsubscribed, category: %q", verified.RecipientId, verified.Category)
+	return preferences, nil
+}
+
+// recipientPreferences returns the preferences of the recipient of the
+// notification. Notifications for a telegram chat and for a deleted
+// recipient get the defaults, the latter fail for lack of contacts.
+func (s *Service) recipientPreferences(notification model.Notification) (model.Preferences, error) {
+	if notification.RecipientId == 0 {
+		return model.Preferences{}, nil
+	}
+
+	preferences, err := s.storage.GetPreferences(notification.RecipientId)
+	if errors.Is(err, repository.ErrNoSuchRecipient) {
+		return model.Preferences{}, nil
+	}
+	if err != nil {
+		return model.Preferences{}, fmt.Errorf("could not get recipient preferences from db: " + err.Error())
+	}
+
+	return *preferences, nil
+}
+
+// suppress cancels a notification the recipient does not want.
+func (s *Service) suppress(notification model.Notification, reason string) error {
+	if err := s.setStatus(notification.Id, "canceled"); err != nil {
+		return err
+	}
+
+	zlog.Logger.Info().Msgf("notification %d was not sent: %s", notification.Id, reason)
+	return nil
+}
+
+// withUnsubscribeLink replaces unsubscribePlaceholder in the text with a
+// link that mutes the category of the notification, or unsubscribes from
+// everything if it has none. The link is escaped for the parse mode of the
+// notification. Without unsubscribe links or a recipient the placeholder is
+// removed.
+func (s *Service) withUnsubscribeLink(notification model.Notification) string {
+	if !strings.Contains(notification.Text, unsubscribePlaceholder) {
+		return notification.Text
+	}
+
+	link := s.unsubscribeLink(notification)
+	if link != "" {
+		link = notification.Options.Escape(link)
+	}
+
+	return strings.ReplaceAll(notification.Text, unsubscribePlaceholder, link)
+}
+
+// listUnsubscribe returns the link announced by mail senders in the
+// List-Unsubscribe header: the unsubscribe link of the first notification
+// of the batch that has one in its text, empty if none has.
+func (s *Service) listUnsubscribe(batch []model.Notification) string {
+	for _, notification := range batch {
+		if strings.Contains(notification.Text, unsubscribePlaceholder) {
+			return s.unsubscribeLink(notification)
+		}
+	}
+	return ""
+}
+
+// unsubscribeLink returns the unescaped unsubscribe link of the
+// notification, empty without unsubscribe links or a recipient.
+func (s *Service) unsubscribeLink(notification model.Notification) string {
+	if s.unsubscribe == nil || notification.RecipientId == 0 {
+		return ""
+	}
+
+	token := s.unsubscribe.Sign(unsubscribe.Token{
+		RecipientId: notification.RecipientId,
+		Category:    notification.Category,
+	})
+	return s.unsubscribeURL + "?token=" + url.QueryEscape(token)
+}
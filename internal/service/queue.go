@@ -2,14 +2,22 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
 	"github.com/wb-go/wbf/zlog"
 )
 
-const (
-	workersNum = 3
+// claimLease is how long a published notification is not published again.
+// If it is still active after that, e.g. the message was lost, it is
+// published once more.
+const claimLease = 5 * time.Minute
+
+var (
+	ErrInvalidPoolConfig = errors.New("workers must be between 1 and concurrency, prefetch must not be negative")
 )
 
 func (s *Service) PublishReadyNotifications(ctx context.Context) error {
@@ -18,23 +26,48 @@ func (s *Service) PublishReadyNotifications(ctx context.Context) error {
 		case <-ctx.Done():
 			return nil
 		default:
-			notifications, err := s.storage.GetReadyNotifications()
-			if err != nil {
+			if err := s.publishReady(); err != nil {
 				return err
 			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
 
-			for _, notif := range notifications {
-				if (int64(notif.SendAt) - time.Now().UnixMilli()) <= time.Minute.Milliseconds()/2 {
-					if err := s.queue.Publish(notif); err != nil {
-						return err
-					}
-					zlog.Logger.Info().Msg("successfully published message")
-				}
+// publishReady claims ready notifications and publishes them to the queue.
+// Missed ones are caught up according to the catch-up policy.
+func (s *Service) publishReady() error {
+	now := time.Now()
+	leaseUntil := int(now.Add(claimLease).UnixMilli())
+	claimed, err := s.storage.ClaimReadyNotifications(leaseUntil)
+	if err != nil {
+		return err
+	}
+
+	notifications, missed := s.splitMissed(claimed, now)
+	for _, notif := range notifications {
+		if notif.Expired(now) {
+			if err := s.expire(notif); err != nil {
+				zlog.Logger.Error().Msg(err.Error())
 			}
+			continue
 		}
 
-		time.Sleep(time.Minute)
+		if err := s.queue.Publish(notif); err != nil {
+			return err
+		}
+		zlog.Logger.Info().Msg("successfully published message")
 	}
+
+	if len(missed) == 0 {
+		return nil
+	}
+	return s.catchUpMissed(missed, now)
 }
 
 func (s *Service) ConsumeMessages(ctx context.Context) error {
@@ -43,18 +76,57 @@ func (s *Service) ConsumeMessages(ctx context.Context) error {
 		return err
 	}
 
-	for i := range workersNum {
-		go func(i int) {
-			zlog.Logger.Info().Msgf("consumer with index %d started", i)
-			for msg := range messages {
-				var notification model.Notification
-				if err := s.handleMessage(msg, notification); err != nil {
-					zlog.Logger.Error().Msg(err.Error())
-					continue
-				}
-			}
-		}(i)
+	s.pool.start(messages)
+	return nil
+}
+
+// consume handles the message and only then acknowledges it, so a message
+// being handled when the service stops is delivered again. One that could
+// not be handled is acknowledged too: its notification stays claimed and is
+// published again once the claim lease runs out.
+func (s *Service) consume(msg model.QueueMessage) {
+	var notification model.Notification
+	if err := s.handleMessage(msg.Body, notification); err != nil {
+		zlog.Logger.Error().Msg(err.Error())
 	}
 
-	return nil
+	if err := msg.Ack(); err != nil {
+		zlog.Logger.Error().Msg("could not acknowledge message: " + err.Error())
+	}
+}
+
+func (s *Service) GetWorkerPoolStats() dto.WorkerPoolStats {
+	return s.pool.stats()
+}
+
+func (s *Service) UpdateWorkerPool(update dto.WorkerPoolUpdate) (*dto.WorkerPoolStats, error) {
+	config := s.pool.getConfig()
+
+	if update.Concurrency != nil {
+		config.Concurrency = *update.Concurrency
+	}
+	if update.Workers != nil {
+		config.Workers = *update.Workers
+	}
+	if update.Prefetch != nil {
+		config.Prefetch = *update.Prefetch
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if update.Prefetch != nil {
+		if err := s.queue.SetPrefetch(config.Prefetch); err != nil {
+			return nil, fmt.Errorf("could not update queue prefetch: %w", err)
+		}
+		s.pool.setPrefetch(config.Prefetch)
+	}
+
+	s.pool.setConcurrency(config.Concurrency)
+	s.pool.resize(config.Workers)
+	zlog.Logger.Info().Msgf("worker pool resized to %d workers", config.Workers)
+
+	stats := s.pool.stats()
+	return &stats, nil
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/wb-go/wbf/zlog"
+)
+
+var (
+	ErrInvalidVerificationCode = errors.New("invalid verification code")
+	ErrInvalidContactOrder     = errors.New("contact order must list every contact of the recipient exactly once")
+)
+
+const verificationCodeDigits = 6
+
+// CreateRecipient saves the recipient with its contacts in the given order.
+// Contacts start unverified, see AddContact.
+func (s *Service) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	for i := range recipient.Contacts {
+		if err := prepareContact(&recipient.Contacts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.storage.CreateRecipient(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, contact := range created.Contacts {
+		s.sendVerificationCode(contact)
+	}
+
+	return created, nil
+}
+
+func (s *Service) GetRecipient(id int) (*model.Recipient, error) {
+	return s.storage.GetRecipient(id)
+}
+
+func (s *Service) GetAllRecipients() ([]model.Recipient, error) {
+	return s.storage.GetAllRecipients()
+}
+
+func (s *Service) RenameRecipient(id int, name string) (*model.Recipient, error) {
+	if err := s.storage.RenameRecipient(id, name); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetRecipient(id)
+}
+
+// DeleteRecipient deletes the recipient and its contacts. Notifications
+// still addressed to it fail when they are due.
+func (s *Service) DeleteRecipient(id int) error {
+	return s.storage.DeleteRecipient(id)
+}
+
+// AddContact appends the contact to the recipient's contacts, so it is the
+// least preferred one. The contact is unverified until the code sent to it
+// is passed to VerifyContact. Codes for channels without a sender are
+// stored but not delivered.
+func (s *Service) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	if err := prepareContact(&contact); err != nil {
+		return nil, err
+	}
+
+	created, err := s.storage.AddContact(recipientId, contact)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sendVerificationCode(*created)
+	return created, nil
+}
+
+func (s *Service) DeleteContact(recipientId, contactId int) error {
+	return s.storage.DeleteContact(recipientId, contactId)
+}
+
+// VerifyContact marks the contact as verified if code is the one sent to
+// it. Verifying a verified contact again succeeds.
+func (s *Service) VerifyContact(recipientId, contactId int, code string) (*model.Contact, error) {
+	recipient, err := s.storage.GetRecipient(recipientId)
+	if errors.Is(err, repository.ErrNoSuchRecipient) {
+		return nil, repository.ErrNoSuchContact
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contact := findContact(recipient.Contacts, contactId)
+	if contact == nil {
+		return nil, repository.ErrNoSuchContact
+	}
+	if contact.Verified {
+		return contact, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code), []byte(contact.VerificationCode)) != 1 {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if err := s.storage.VerifyContact(recipientId, contactId); err != nil {
+		return nil, err
+	}
+
+	contact.Verified = true
+	contact.VerificationCode = ""
+	return contact, nil
+}
+
+// ReorderContacts sets the preference order of the recipient's contacts,
+// contactIds must list each of them once, most preferred first.
+func (s *Service) ReorderContacts(recipientId int, contactIds []int) (*model.Recipient, error) {
+	recipient, err := s.storage.GetRecipient(recipientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contactIds) != len(recipient.Contacts) {
+		return nil, ErrInvalidContactOrder
+	}
+	seen := make(map[int]bool, len(contactIds))
+	for _, id := range contactIds {
+		if seen[id] || findContact(recipient.Contacts, id) == nil {
+			return nil, ErrInvalidContactOrder
+		}
+		seen[id] = true
+	}
+
+	if err := s.storage.ReorderContacts(recipientId, contactIds); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetRecipient(recipientId)
+}
+
+// isRecipientChat reports whether the chat is a telegram contact of the
+// recipient.
+func (s *Service) isRecipientChat(recipientId, telegramId int) (bool, error) {
+	recipient, err := s.storage.GetRecipient(recipientId)
+	if errors.Is(err, repository.ErrNoSuchRecipient) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, contact := range recipient.Contacts {
+		if contact.Channel == model.ChannelTelegram && contact.TelegramId() == telegramId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sendVerificationCode sends the code to the contact in the background if
+// its channel has a sender, so a slow or unreachable contact does not hold
+// up the request. A failure only logs and the contact stays unverified.
+func (s *Service) sendVerificationCode(contact model.Contact) {
+	if !s.canSend(contact.Channel) {
+		return
+	}
+
+	go func() {
+		err := s.deliver(model.Notification{Text: "Your verification code: " + contact.VerificationCode}, contact)
+		if err != nil {
+			zlog.Logger.Warn().Msgf("could not send verification code to contact %d: %s", contact.Id, err.Error())
+		}
+	}()
+}
+
+func prepareContact(contact *model.Contact) error {
+	if err := contact.Validate(); err != nil {
+		return err
+	}
+
+	code, err := verificationCode()
+	if err != nil {
+		return fmt.Errorf("could not generate verification code: " + err.Error())
+	}
+
+	contact.Verified = false
+	contact.VerificationCode = code
+	return nil
+}
+
+func verificationCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < verificationCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+func findContact(contacts []model.Contact, id int) *model.Contact {
+	for i := range contacts {
+		if contacts[i].Id == id {
+			return &contacts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// retentionStats counts purged and anonymized notifications, published at
+// /debug/vars.
+var retentionStats = expvar.NewMap("retention")
+
+// RetentionPolicy deletes finished notifications of the tenant PurgeAfter
+// their send time and clears their text AnonymizeAfter it, zero keeps them.
+// The policy without a tenant applies to the tenants without a policy and
+// to notifications without a tenant. Statuses default to
+// model.FinishedStatuses.
+type RetentionPolicy struct {
+	Tenant         string
+	Statuses       []string
+	PurgeAfter     time.Duration
+	AnonymizeAfter time.Duration
+}
+
+// RetentionConfig describes the janitor enforcing the retention policies
+// every Interval, BatchSize notifications at a time.
+type RetentionConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Policies  []RetentionPolicy
+}
+
+// DefaultRetentionConfig is used when the service is created without
+// WithRetention. It has no policies, so nothing is removed.
+var DefaultRetentionConfig = RetentionConfig{
+	Interval:  time.Hour,
+	BatchSize: 500,
+}
+
+// DeleteNotification deletes the notification for good, with its delivery
+// history and, for a group notification, its deliveries.
+func (s *Service) DeleteNotification(id int) error {
+	if _, err := s.GetNotification(id); err != nil {
+		return err
+	}
+
+	deliveries, err := s.storage.GetChildNotifications(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.DeleteNotificationById(id); err != nil {
+		return err
+	}
+
+	s.invalidateCache(id)
+	for _, delivery := range deliveries {
+		s.invalidateCache(delivery.Id)
+	}
+	return nil
+}
+
+// EnforceRetention applies the retention policies every interval, see
+// WithRetention. It returns when ctx is done.
+func (s *Service) EnforceRetention(ctx context.Context) error {
+	for {
+		purged, anonymized, err := s.enforceRetention(ctx)
+		if err != nil {
+			zlog.Logger.Error().Msg("could not enforce retention: " + err.Error())
+		}
+		if purged > 0 || anonymized > 0 {
+			zlog.Logger.Info().Msgf("retention purged %d and anonymized %d notifications", purged, anonymized)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.retention.Interval):
+		}
+	}
+}
+
+// enforceRetention runs a single pass and returns the number of purged and
+// anonymized notifications.
+func (s *Service) enforceRetention(ctx context.Context) (purged, anonymized int, err error) {
+	now := time.Now()
+	for _, policy := range s.retention.Policies {
+		if policy.PurgeAfter > 0 {
+			n, err := s.inBatches(ctx, s.storage.PurgeNotifications, s.retentionScope(policy, policy.PurgeAfter, now))
+			purged += n
+			retentionStats.Add("purged", int64(n))
+			if err != nil {
+				return purged, anonymized, err
+			}
+		}
+
+		if policy.AnonymizeAfter > 0 {
+			n, err := s.inBatches(ctx, s.storage.AnonymizeNotifications, s.retentionScope(policy, policy.AnonymizeAfter, now))
+			anonymized += n
+			retentionStats.Add("anonymized", int64(n))
+			if err != nil {
+				return purged, anonymized, err
+			}
+		}
+	}
+
+	return purged, anonymized, nil
+}
+
+// inBatches applies a retention action to the scope a batch at a time
+// until nothing is left, dropping the cache entries of the notifications
+// it changed, and returns their number.
+func (s *Service) inBatches(ctx context.Context, apply func(model.RetentionScope, int) ([]int, error), scope model.RetentionScope) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		ids, err := apply(scope, s.retention.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			s.invalidateCache(id)
+		}
+		total += len(ids)
+	}
+
+	return total, nil
+}
+
+func (s *Service) retentionScope(policy RetentionPolicy, age time.Duration, now time.Time) model.RetentionScope {
+	scope := model.RetentionScope{
+		Tenant:   policy.Tenant,
+		Statuses: policy.Statuses,
+		Before:   int(now.Add(-age).UnixMilli()),
+	}
+	if len(scope.Statuses) == 0 {
+		scope.Statuses = model.FinishedStatuses
+	}
+
+	if policy.Tenant == "" {
+		for _, other := range s.retention.Policies {
+			if other.Tenant != "" {
+				scope.Except = append(scope.Except, other.Tenant)
+			}
+		}
+	}
+	return scope
+}
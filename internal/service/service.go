@@ -1,17 +1,178 @@
 package service
 
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/dto"
+	"github.com/Komilov31/delayed-notifier/internal/events"
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
+)
+
 type Service struct {
 	storage Storage
 	cache   Cache
 	queue   Queue
 	sender  Sender
+	events  Events
+	// channels holds the senders of contact channels other than telegram,
+	// see WithChannelSender.
+	channels map[string]ChannelSender
+	pool     *workerPool
+	// unsubscribe signs the tokens of unsubscribe links, nil disables them,
+	// see WithUnsubscribeLinks.
+	unsubscribe    *unsubscribe.Signer
+	unsubscribeURL string
+	// collapsePolicy applies to notifications created with a collapse key,
+	// see WithCollapsePolicy.
+	collapsePolicy string
+	// catchUp applies to notifications missed by the scheduler, see
+	// WithCatchUp. lastCatchUp is what it did last time.
+	catchUp     CatchUpConfig
+	lastCatchUp atomic.Pointer[dto.CatchUpReport]
+	// retention is enforced by EnforceRetention, see WithRetention.
+	retention RetentionConfig
+
+	sendRetryDelay    time.Duration
+	pollInterval      time.Duration
+	reconcileInterval time.Duration
+	// reconcileBatch notifications are compared with the cache per pass,
+	// starting after reconcileCursor, see ReconcileCache.
+	reconcileBatch  int
+	reconcileCursor int
+}
+
+type Option func(*Service)
+
+// WithPoolConfig overrides DefaultPoolConfig for the consumer worker pool.
+// An invalid config, see PoolConfig.Validate, keeps the default.
+func WithPoolConfig(config PoolConfig) Option {
+	return func(s *Service) {
+		if config.Validate() == nil {
+			s.pool.config = config
+		}
+	}
+}
+
+// WithPollInterval sets how often ready notifications are looked up in the
+// storage and published to the queue.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.pollInterval = interval
+	}
 }
 
-func New(storage Storage, cache Cache, queue Queue, sender Sender) *Service {
-	return &Service{
+// WithReconcileInterval sets how often cached statuses are compared with the
+// storage, see ReconcileCache. A non-positive interval keeps the default.
+func WithReconcileInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		if interval > 0 {
+			s.reconcileInterval = interval
+		}
+	}
+}
+
+// WithReconcileBatch sets how many notifications a pass of ReconcileCache
+// compares with the cache and WarmupCache reads at a time. A non-positive
+// size keeps the default.
+func WithReconcileBatch(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.reconcileBatch = size
+		}
+	}
+}
+
+// WithEvents replaces the default in-process event bus, e.g. with one shared
+// between replicas.
+func WithEvents(events Events) Option {
+	return func(s *Service) {
+		s.events = events
+	}
+}
+
+// WithChannelSender delivers notifications to contacts of the channel
+// through sender. Contacts of channels without a sender are skipped.
+func WithChannelSender(channel string, sender ChannelSender) Option {
+	return func(s *Service) {
+		s.channels[channel] = sender
+	}
+}
+
+// WithUnsubscribeLinks enables unsubscribe links signed by signer. A link
+// is baseURL with the token in the token query parameter, see Unsubscribe.
+func WithUnsubscribeLinks(signer *unsubscribe.Signer, baseURL string) Option {
+	return func(s *Service) {
+		s.unsubscribe = signer
+		s.unsubscribeURL = baseURL
+	}
+}
+
+// WithCollapsePolicy sets what happens to an active notification when a
+// newer one with the same collapse key is created for the same addressee,
+// see model.CollapseKeepFirst. The default is model.CollapseKeepLast.
+func WithCollapsePolicy(policy string) Option {
+	return func(s *Service) {
+		s.collapsePolicy = policy
+	}
+}
+
+// WithCatchUp overrides DefaultCatchUpConfig for notifications that are
+// already overdue when the scheduler claims them. Non-positive durations
+// keep the defaults.
+func WithCatchUp(config CatchUpConfig) Option {
+	return func(s *Service) {
+		s.catchUp.Policy = config.Policy
+		if config.OverdueAfter > 0 {
+			s.catchUp.OverdueAfter = config.OverdueAfter
+		}
+		if config.MaxAge > 0 {
+			s.catchUp.MaxAge = config.MaxAge
+		}
+		if config.Window > 0 {
+			s.catchUp.Window = config.Window
+		}
+	}
+}
+
+// WithRetention overrides DefaultRetentionConfig. A non-positive interval
+// or batch size keeps the default.
+func WithRetention(config RetentionConfig) Option {
+	return func(s *Service) {
+		s.retention.Policies = config.Policies
+		if config.Interval > 0 {
+			s.retention.Interval = config.Interval
+		}
+		if config.BatchSize > 0 {
+			s.retention.BatchSize = config.BatchSize
+		}
+	}
+}
+
+func New(storage Storage, cache Cache, queue Queue, sender Sender, opts ...Option) *Service {
+	s := &Service{
 		storage: storage,
 		cache:   cache,
 		queue:   queue,
 		sender:  sender,
+		events:  events.NewBus(),
+
+		channels:       make(map[string]ChannelSender),
+		collapsePolicy: model.CollapseKeepLast,
+		catchUp:        DefaultCatchUpConfig,
+		retention:      DefaultRetentionConfig,
+
+		sendRetryDelay:    time.Second,
+		pollInterval:      time.Minute,
+		reconcileInterval: 5 * time.Minute,
+		reconcileBatch:    1000,
+	}
+	s.pool = newWorkerPool(DefaultPoolConfig, s.consume)
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
@@ -2,9 +2,18 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Komilov31/delayed-notifier/internal/dto"
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/sender"
+	"github.com/Komilov31/delayed-notifier/internal/unsubscribe"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,6 +29,14 @@ func (m *MockStorage) CreateNotification(notification model.Notification) (*mode
 	return args.Get(0).(*model.Notification), args.Error(1)
 }
 
+func (m *MockStorage) CollapseNotification(notification model.Notification, policy string) (*model.CollapseResult, error) {
+	args := m.Called(notification, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CollapseResult), args.Error(1)
+}
+
 func (m *MockStorage) DeleteNotificationById(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -38,6 +55,14 @@ func (m *MockStorage) GetAllNotifications() ([]model.Notification, error) {
 	return args.Get(0).([]model.Notification), args.Error(1)
 }
 
+func (m *MockStorage) GetNotificationsAfter(afterId, limit int) ([]model.Notification, error) {
+	args := m.Called(afterId, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
 func (m *MockStorage) GetReadyNotifications() ([]model.Notification, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -46,26 +71,239 @@ func (m *MockStorage) GetReadyNotifications() ([]model.Notification, error) {
 	return args.Get(0).([]model.Notification), args.Error(1)
 }
 
+func (m *MockStorage) ClaimReadyNotifications(leaseUntil int) ([]model.Notification, error) {
+	args := m.Called(leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
+func (m *MockStorage) GetUpcomingNotifications(telegramId int) ([]model.Notification, error) {
+	args := m.Called(telegramId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
 func (m *MockStorage) UpdateNotificationStatus(id int, status string) error {
 	args := m.Called(id, status)
 	return args.Error(0)
 }
 
+func (m *MockStorage) RescheduleNotification(id int, sendAt int) error {
+	args := m.Called(id, sendAt)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AssignDigest(leaderId, recipientId, dueBefore int, categories []string) ([]model.Notification, error) {
+	args := m.Called(leaderId, recipientId, dueBefore, categories)
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
+func (m *MockStorage) LeaveDigest(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) SaveSubscriber(subscriber model.Subscriber) error {
+	args := m.Called(subscriber)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetSubscriberByHandle(handle string) (*model.Subscriber, error) {
+	args := m.Called(handle)
+	return args.Get(0).(*model.Subscriber), args.Error(1)
+}
+
+func (m *MockStorage) MarkRecipientUnreachable(telegramId int, reason string) error {
+	args := m.Called(telegramId, reason)
+	return args.Error(0)
+}
+
+func (m *MockStorage) MarkRecipientReachable(telegramId int) error {
+	args := m.Called(telegramId)
+	return args.Error(0)
+}
+
+func (m *MockStorage) IsRecipientUnreachable(telegramId int) (bool, error) {
+	args := m.Called(telegramId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorage) CreateRecipient(recipient model.Recipient) (*model.Recipient, error) {
+	args := m.Called(recipient)
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockStorage) GetRecipient(id int) (*model.Recipient, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Recipient), args.Error(1)
+}
+
+func (m *MockStorage) GetAllRecipients() ([]model.Recipient, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Recipient), args.Error(1)
+}
+
+func (m *MockStorage) RenameRecipient(id int, name string) error {
+	args := m.Called(id, name)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteRecipient(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AddContact(recipientId int, contact model.Contact) (*model.Contact, error) {
+	args := m.Called(recipientId, contact)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Contact), args.Error(1)
+}
+
+func (m *MockStorage) DeleteContact(recipientId, contactId int) error {
+	args := m.Called(recipientId, contactId)
+	return args.Error(0)
+}
+
+func (m *MockStorage) VerifyContact(recipientId, contactId int) error {
+	args := m.Called(recipientId, contactId)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ReorderContacts(recipientId int, contactIds []int) error {
+	args := m.Called(recipientId, contactIds)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetDeliveryAttempts(notificationId int) ([]model.DeliveryAttempt, error) {
+	args := m.Called(notificationId)
+	return args.Get(0).([]model.DeliveryAttempt), args.Error(1)
+}
+
+func (m *MockStorage) GetPreferences(recipientId int) (*model.Preferences, error) {
+	args := m.Called(recipientId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Preferences), args.Error(1)
+}
+
+func (m *MockStorage) SavePreferences(preferences model.Preferences) error {
+	args := m.Called(preferences)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetChildNotifications(parentId int) ([]model.Notification, error) {
+	args := m.Called(parentId)
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
+func (m *MockStorage) PurgeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	args := m.Called(scope, limit)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockStorage) AnonymizeNotifications(scope model.RetentionScope, limit int) ([]int, error) {
+	args := m.Called(scope, limit)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockStorage) GetFollowUps(id int) ([]model.Notification, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.Notification), args.Error(1)
+}
+
+func (m *MockStorage) CreateGroup(group model.Group) (*model.Group, error) {
+	args := m.Called(group)
+	return args.Get(0).(*model.Group), args.Error(1)
+}
+
+func (m *MockStorage) GetGroup(id int) (*model.Group, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Group), args.Error(1)
+}
+
+func (m *MockStorage) GetAllGroups() ([]model.Group, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Group), args.Error(1)
+}
+
+func (m *MockStorage) DeleteGroup(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AddGroupMember(groupId, recipientId int) error {
+	args := m.Called(groupId, recipientId)
+	return args.Error(0)
+}
+
+func (m *MockStorage) RemoveGroupMember(groupId, recipientId int) error {
+	args := m.Called(groupId, recipientId)
+	return args.Error(0)
+}
+
 // MockCache is a mock implementation of Cache
 type MockCache struct {
 	mock.Mock
 }
 
-func (m *MockCache) Get(key string) (string, error) {
-	args := m.Called(key)
-	return args.String(0), args.Error(1)
+func (m *MockCache) GetNotification(id int) (*model.Notification, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Notification), args.Error(1)
+}
+
+func (m *MockCache) SetNotification(notification model.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+func (m *MockCache) SetNotifications(notifications []model.Notification) error {
+	args := m.Called(notifications)
+	return args.Error(0)
+}
+
+func (m *MockCache) SetMissing(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
 }
 
-func (m *MockCache) Set(key int, value interface{}) error {
-	args := m.Called(key, value)
+func (m *MockCache) DeleteNotification(id int) error {
+	args := m.Called(id)
 	return args.Error(0)
 }
 
+// cached matches the notification passed to MockCache.SetNotification.
+func cached(id int, status string, version int) interface{} {
+	return mock.MatchedBy(func(n model.Notification) bool {
+		return n.Id == id && n.Status == status && n.Version == version
+	})
+}
+
+// queued returns the queue message of the notification and expects it to
+// be re-read from the storage unchanged once, see Service.isCurrent.
+func queued(storage *MockStorage, notification model.Notification) []byte {
+	storage.On("GetNotificationById", notification.Id).Return(&notification, nil).Once()
+	msg, _ := json.Marshal(notification)
+	return msg
+}
+
 // MockQueue is a mock implementation of Queue
 type MockQueue struct {
 	mock.Mock
@@ -76,9 +314,14 @@ func (m *MockQueue) Publish(notification model.Notification) error {
 	return args.Error(0)
 }
 
-func (m *MockQueue) Consume(ctx context.Context) (<-chan []byte, error) {
+func (m *MockQueue) Consume(ctx context.Context) (<-chan model.QueueMessage, error) {
 	args := m.Called(ctx)
-	return args.Get(0).(<-chan []byte), args.Error(1)
+	return args.Get(0).(<-chan model.QueueMessage), args.Error(1)
+}
+
+func (m *MockQueue) SetPrefetch(prefetch int) error {
+	args := m.Called(prefetch)
+	return args.Error(0)
 }
 
 // MockSender is a mock implementation of Sender
@@ -86,8 +329,18 @@ type MockSender struct {
 	mock.Mock
 }
 
-func (m *MockSender) SendToTelegram(id int, text string) error {
-	args := m.Called(id, text)
+func (m *MockSender) SendToTelegram(notification model.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+// MockChannelSender is a mock implementation of ChannelSender
+type MockChannelSender struct {
+	mock.Mock
+}
+
+func (m *MockChannelSender) Send(address string, notification model.Notification) error {
+	args := m.Called(address, notification)
 	return args.Error(0)
 }
 
@@ -110,13 +363,14 @@ func TestService_CreateNotification_Success(t *testing.T) {
 		TelegramId: notification.TelegramId,
 		SendAt:     notification.SendAt,
 		Status:     "active",
+		Version:    1,
 	}
 
 	mockStorage.On("CreateNotification", mock.MatchedBy(func(n model.Notification) bool {
 		return n.Text == notification.Text && n.Status == "active"
 	})).Return(expectedNotification, nil)
 
-	mockCache.On("Set", expectedNotification.Id, expectedNotification.Status).Return(nil)
+	mockCache.On("SetNotification", cached(expectedNotification.Id, expectedNotification.Status, 1)).Return(nil)
 
 	result, err := service.CreateNotification(notification)
 
@@ -126,6 +380,94 @@ func TestService_CreateNotification_Success(t *testing.T) {
 	mockCache.AssertExpectations(t)
 }
 
+func TestService_CreateNotification_CollapseKeepLast(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender))
+
+	notification := model.Notification{Text: "Your order is waiting", RecipientId: 7, CollapseKey: "order-42", SendAt: 1234567890}
+	created := &model.Notification{Id: 2, Text: notification.Text, RecipientId: 7, CollapseKey: "order-42", Status: "active", Version: 1}
+
+	mockStorage.On("CollapseNotification", mock.MatchedBy(func(n model.Notification) bool {
+		return n.CollapseKey == "order-42" && n.Status == "active"
+	}), model.CollapseKeepLast).Return(&model.CollapseResult{Notification: *created, Created: true, Canceled: []int{1}}, nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
+	mockCache.On("SetNotification", cached(2, "active", 1)).Return(nil)
+
+	events, stop := service.SubscribeEvents(model.EventFilter{})
+	defer stop()
+
+	result, err := service.CreateNotification(notification)
+
+	assert.NoError(t, err)
+	assert.Equal(t, created, result)
+	assert.Equal(t, model.EventCanceled, (<-events).Type)
+	assert.Equal(t, model.EventCreated, (<-events).Type)
+	mockStorage.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_CreateNotification_CollapseKeepFirst(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender), WithCollapsePolicy(model.CollapseKeepFirst))
+
+	earlier := model.Notification{Id: 1, Text: "Your order is waiting", RecipientId: 7, CollapseKey: "order-42", Status: "active", Version: 1}
+	mockStorage.On("CollapseNotification", mock.AnythingOfType("model.Notification"), model.CollapseKeepFirst).
+		Return(&model.CollapseResult{Notification: earlier}, nil)
+
+	result, err := service.CreateNotification(model.Notification{Text: "Still waiting", RecipientId: 7, CollapseKey: "order-42"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &earlier, result)
+	mockCache.AssertNotCalled(t, "SetNotification", mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_FollowUpNotification(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender))
+
+	source := model.Notification{
+		Id:          1,
+		Text:        "Standup",
+		Status:      "completed",
+		RecipientId: 7,
+		Channels:    []string{model.ChannelEmail},
+		Category:    "meetings",
+		SendAt:      1_000_000,
+		ExpiresAt:   1_600_000,
+		DigestId:    1,
+		Version:     3,
+	}
+	sendAt := time.UnixMilli(5_000_000)
+
+	expected := model.Notification{
+		Text:        "Standup",
+		Status:      "active",
+		RecipientId: 7,
+		Channels:    []string{model.ChannelEmail},
+		Category:    "meetings",
+		SendAt:      5_000_000,
+		ExpiresAt:   5_600_000,
+		FollowUpOf:  1,
+	}
+	created := expected
+	created.Id = 2
+	created.Version = 1
+	mockStorage.On("CreateNotification", expected).Return(&created, nil)
+	mockCache.On("SetNotification", cached(2, "active", 1)).Return(nil)
+
+	result, err := service.FollowUpNotification(source, sendAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &created, result)
+	mockStorage.AssertExpectations(t)
+}
+
 func TestService_CreateNotification_StorageError(t *testing.T) {
 	mockStorage := new(MockStorage)
 	mockCache := new(MockCache)
@@ -146,7 +488,7 @@ func TestService_CreateNotification_StorageError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	mockStorage.AssertExpectations(t)
-	mockCache.AssertNotCalled(t, "Set")
+	mockCache.AssertNotCalled(t, "SetNotification")
 }
 
 func TestService_CreateNotification_CacheError(t *testing.T) {
@@ -168,16 +510,19 @@ func TestService_CreateNotification_CacheError(t *testing.T) {
 		TelegramId: notification.TelegramId,
 		SendAt:     notification.SendAt,
 		Status:     "active",
+		Version:    1,
 	}
 
 	mockStorage.On("CreateNotification", mock.AnythingOfType("model.Notification")).Return(expectedNotification, nil)
 
-	mockCache.On("Set", expectedNotification.Id, expectedNotification.Status).Return(assert.AnError)
+	mockCache.On("SetNotification", cached(expectedNotification.Id, expectedNotification.Status, 1)).Return(assert.AnError)
+	mockCache.On("DeleteNotification", expectedNotification.Id).Return(nil)
 
+	// The notification is stored, a cache failure only invalidates the entry.
 	result, err := service.CreateNotification(notification)
 
-	assert.Error(t, err)
-	assert.Nil(t, result)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedNotification, result)
 	mockStorage.AssertExpectations(t)
 	mockCache.AssertExpectations(t)
 }
@@ -226,7 +571,7 @@ func TestService_GetNotificationStatus_CacheHit(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Get", "1").Return("active", nil)
+	mockCache.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "active", Version: 1}, nil)
 
 	result, err := service.GetNotificationStatus(1)
 
@@ -250,10 +595,12 @@ func TestService_GetNotificationStatus_CacheMiss(t *testing.T) {
 		TelegramId: 123,
 		SendAt:     1234567890,
 		Status:     "active",
+		Version:    3,
 	}
 
-	mockCache.On("Get", "1").Return("", redis.Nil)
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), redis.Nil)
 	mockStorage.On("GetNotificationById", 1).Return(notification, nil)
+	mockCache.On("SetNotification", cached(1, "active", 3)).Return(nil)
 
 	result, err := service.GetNotificationStatus(1)
 
@@ -271,7 +618,7 @@ func TestService_GetNotificationStatus_CacheError(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Get", "1").Return("", assert.AnError)
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), assert.AnError)
 
 	result, err := service.GetNotificationStatus(1)
 
@@ -288,7 +635,7 @@ func TestService_GetNotificationStatus_StorageError(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Get", "1").Return("", redis.Nil)
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), redis.Nil)
 	mockStorage.On("GetNotificationById", 1).Return((*model.Notification)(nil), assert.AnError)
 
 	result, err := service.GetNotificationStatus(1)
@@ -306,8 +653,9 @@ func TestService_UpdateNotificationStatus_Success(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Set", 1, "canceled").Return(nil)
 	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
 
 	err := service.UpdateNotificationStatus(1, "canceled")
 
@@ -323,13 +671,16 @@ func TestService_UpdateNotificationStatus_CacheError(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Set", 1, "canceled").Return(assert.AnError)
+	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(assert.AnError)
+	mockCache.On("DeleteNotification", 1).Return(nil)
 
 	err := service.UpdateNotificationStatus(1, "canceled")
 
-	assert.Error(t, err)
+	assert.NoError(t, err)
 	mockCache.AssertExpectations(t)
-	mockStorage.AssertNotCalled(t, "UpdateNotificationStatus")
+	mockStorage.AssertExpectations(t)
 }
 
 func TestService_UpdateNotificationStatus_StorageError(t *testing.T) {
@@ -339,12 +690,1309 @@ func TestService_UpdateNotificationStatus_StorageError(t *testing.T) {
 	mockSender := new(MockSender)
 	service := New(mockStorage, mockCache, mockQueue, mockSender)
 
-	mockCache.On("Set", 1, "canceled").Return(nil)
 	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(assert.AnError)
 
 	err := service.UpdateNotificationStatus(1, "canceled")
 
 	assert.Error(t, err)
-	mockCache.AssertExpectations(t)
 	mockStorage.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "SetNotification")
+	mockCache.AssertNotCalled(t, "DeleteNotification")
+}
+
+func TestService_UpdateWorkerPool_ScalesWorkers(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender, WithPoolConfig(PoolConfig{Workers: 2, Concurrency: 4, Prefetch: 1}))
+
+	messages := make(chan model.QueueMessage)
+	mockQueue.On("Consume", mock.Anything).Return((<-chan model.QueueMessage)(messages), nil)
+	mockQueue.On("SetPrefetch", 8).Return(nil)
+
+	err := service.ConsumeMessages(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, service.GetWorkerPoolStats().Workers)
+
+	workers, prefetch := 4, 8
+	stats, err := service.UpdateWorkerPool(dto.WorkerPoolUpdate{Workers: &workers, Prefetch: &prefetch})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Workers)
+	assert.Equal(t, 8, stats.Prefetch)
+
+	workers = 1
+	stats, err = service.UpdateWorkerPool(dto.WorkerPoolUpdate{Workers: &workers})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Workers)
+
+	close(messages)
+	service.pool.wait()
+	mockQueue.AssertExpectations(t)
+}
+
+func TestService_ConsumeMessages_AcksAfterHandling(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, new(MockCache), mockQueue, mockSender, WithPoolConfig(PoolConfig{Workers: 1, Concurrency: 1}))
+
+	messages := make(chan model.QueueMessage)
+	mockQueue.On("Consume", mock.Anything).Return((<-chan model.QueueMessage)(messages), nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+
+	assert.NoError(t, service.ConsumeMessages(context.Background()))
+
+	acked := make(chan struct{})
+	body, _ := json.Marshal(model.Notification{Id: 1, Status: "active", Version: 1})
+	messages <- model.QueueMessage{Body: body, Ack: func() error {
+		mockStorage.AssertCalled(t, "GetNotificationById", 1)
+		close(acked)
+		return nil
+	}}
+
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		t.Fatal("message was not acknowledged")
+	}
+
+	close(messages)
+	service.pool.wait()
+}
+
+func TestService_WithPoolConfig_Invalid(t *testing.T) {
+	service := New(new(MockStorage), new(MockCache), new(MockQueue), new(MockSender), WithPoolConfig(PoolConfig{Concurrency: 4}))
+
+	assert.Equal(t, DefaultPoolConfig, service.pool.getConfig())
+}
+
+func TestService_UpdateWorkerPool_InvalidConfig(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender, WithPoolConfig(PoolConfig{Workers: 2, Concurrency: 4}))
+
+	workers := 5
+	stats, err := service.UpdateWorkerPool(dto.WorkerPoolUpdate{Workers: &workers})
+
+	assert.ErrorIs(t, err, ErrInvalidPoolConfig)
+	assert.Nil(t, stats)
+	mockQueue.AssertNotCalled(t, "SetPrefetch")
+}
+
+func TestService_SnoozeNotification_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := &model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "completed", Version: 2}
+
+	mockStorage.On("GetNotificationById", 1).Return(notification, nil).Once()
+	mockStorage.On("RescheduleNotification", 1, mock.AnythingOfType("int")).Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, TelegramId: 123, Status: "active", Version: 3}, nil)
+	mockCache.On("SetNotification", cached(1, "active", 3)).Return(nil)
+
+	result, err := service.SnoozeNotification(123, 1, 10*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "active", result.Status)
+	assert.InDelta(t, time.Now().Add(10*time.Minute).UnixMilli(), result.SendAt, float64(time.Second.Milliseconds()))
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_SnoozeNotification_OtherChat(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := &model.Notification{Id: 1, Text: "Test", TelegramId: 456, Status: "active"}
+
+	mockStorage.On("GetNotificationById", 1).Return(notification, nil)
+
+	result, err := service.SnoozeNotification(123, 1, 10*time.Minute)
+
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+	assert.Nil(t, result)
+	mockStorage.AssertNotCalled(t, "RescheduleNotification")
+	mockCache.AssertNotCalled(t, "SetNotification")
+}
+
+func TestService_SnoozeNotification_Canceled(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	for _, status := range []string{"canceled", "failed", "expired"} {
+		notification := &model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: status}
+		mockStorage.On("GetNotificationById", 1).Return(notification, nil).Once()
+		// The status is checked by the guarded update, not by a read.
+		mockStorage.On("RescheduleNotification", 1, mock.AnythingOfType("int")).Return(repository.ErrNotActive).Once()
+
+		result, err := service.SnoozeNotification(123, 1, 10*time.Minute)
+
+		assert.ErrorIs(t, err, model.ErrNotSnoozable, status)
+		assert.Nil(t, result)
+	}
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "SetNotification")
+}
+
+func TestService_CancelNotification_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := &model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+
+	mockStorage.On("GetNotificationById", 1).Return(notification, nil).Once()
+	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, TelegramId: 123, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
+
+	err := service.CancelNotification(123, 1)
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_RegisterSubscriber_NormalizesHandle(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	mockStorage.On("SaveSubscriber", model.Subscriber{Handle: "alice", TelegramId: 123}).Return(nil)
+	mockStorage.On("MarkRecipientReachable", 123).Return(nil)
+
+	err := service.RegisterSubscriber(" @Alice", 123)
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "completed", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", model.DeliveryAttempt{
+		NotificationId: 1,
+		Channel:        model.ChannelTelegram,
+		Address:        "123",
+		Status:         model.DeliverySent,
+	}).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_ChangedAfterQueued(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored *model.Notification
+		err    error
+	}{
+		{"canceled", &model.Notification{Id: 1, Status: "canceled", Version: 3}, nil},
+		{"merged", &model.Notification{Id: 1, Text: "Merged", Status: "active", Version: 3}, nil},
+		{"deleted", nil, repository.ErrNoSuchNotification},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStorage)
+			mockSender := new(MockSender)
+			service := New(mockStorage, new(MockCache), new(MockQueue), mockSender)
+
+			msg, _ := json.Marshal(model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active", Version: 2})
+			mockStorage.On("GetNotificationById", 1).Return(tt.stored, tt.err)
+
+			err := service.handleMessage(msg, model.Notification{})
+
+			assert.NoError(t, err)
+			mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+			mockStorage.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestService_HandleMessage_CanceledWhileSending(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockSender := new(MockSender)
+	service := New(mockStorage, new(MockCache), new(MockQueue), mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(repository.ErrNotActive)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_Expired(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	due := time.Now().Add(-time.Hour)
+	notification := model.Notification{
+		Id:         1,
+		Text:       "Meeting starts",
+		TelegramId: 123,
+		Status:     "active",
+		SendAt:     int(due.UnixMilli()),
+		ExpiresAt:  int(due.Add(10 * time.Minute).UnixMilli()),
+	}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("UpdateNotificationStatus", 1, "expired").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "expired", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "expired", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+}
+
+func TestService_PublishReady_CatchUpLatest(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	service := New(mockStorage, mockCache, mockQueue, new(MockSender),
+		WithCatchUp(CatchUpConfig{Policy: model.CatchUpLatest}))
+
+	now := time.Now()
+	due := func(d time.Duration) int { return int(now.Add(d).UnixMilli()) }
+	ready := model.Notification{Id: 1, TelegramId: 1, Status: "active", SendAt: due(10 * time.Second)}
+	older := model.Notification{Id: 2, TelegramId: 1, Status: "active", SendAt: due(-2 * time.Hour)}
+	latest := model.Notification{Id: 3, TelegramId: 1, Status: "active", SendAt: due(-time.Hour)}
+	other := model.Notification{Id: 4, TelegramId: 2, Status: "active", SendAt: due(-3 * time.Hour)}
+
+	mockStorage.On("ClaimReadyNotifications", mock.AnythingOfType("int")).
+		Return([]model.Notification{latest, ready, other, older}, nil)
+	mockQueue.On("Publish", ready).Return(nil)
+	mockQueue.On("Publish", latest).Return(nil)
+	mockQueue.On("Publish", other).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 2, "expired").Return(nil)
+	mockStorage.On("GetNotificationById", 2).Return(&model.Notification{Id: 2, Status: "expired", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(2, "expired", 2)).Return(nil)
+
+	assert.Nil(t, service.GetCatchUpReport())
+	assert.NoError(t, service.publishReady())
+
+	mockStorage.AssertExpectations(t)
+	mockQueue.AssertExpectations(t)
+	report := service.GetCatchUpReport()
+	if !assert.NotNil(t, report) {
+		return
+	}
+	assert.Equal(t, model.CatchUpLatest, report.Policy)
+	assert.Equal(t, time.UnixMilli(int64(other.SendAt)), report.OldestDueAt)
+	assert.Equal(t, 3, report.Missed)
+	assert.Equal(t, 2, report.Sent)
+	assert.Equal(t, 1, report.Expired)
+	assert.Zero(t, report.Rescheduled)
+}
+
+func TestService_PublishReady_CatchUpDropOlder(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	service := New(mockStorage, mockCache, mockQueue, new(MockSender),
+		WithCatchUp(CatchUpConfig{Policy: model.CatchUpDropOlder, MaxAge: time.Hour}))
+
+	now := time.Now()
+	recent := model.Notification{Id: 1, TelegramId: 1, Status: "active", SendAt: int(now.Add(-10 * time.Minute).UnixMilli())}
+	old := model.Notification{Id: 2, TelegramId: 1, Status: "active", SendAt: int(now.Add(-2 * time.Hour).UnixMilli())}
+
+	mockStorage.On("ClaimReadyNotifications", mock.AnythingOfType("int")).Return([]model.Notification{recent, old}, nil)
+	mockQueue.On("Publish", recent).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 2, "expired").Return(nil)
+	mockStorage.On("GetNotificationById", 2).Return(&model.Notification{Id: 2, Status: "expired", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(2, "expired", 2)).Return(nil)
+
+	assert.NoError(t, service.publishReady())
+
+	mockStorage.AssertExpectations(t)
+	mockQueue.AssertExpectations(t)
+	report := service.GetCatchUpReport()
+	if !assert.NotNil(t, report) {
+		return
+	}
+	assert.Equal(t, 1, report.Sent)
+	assert.Equal(t, 1, report.Expired)
+}
+
+func TestService_PublishReady_RetryIsNotMissed(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	service := New(mockStorage, mockCache, mockQueue, new(MockSender),
+		WithCatchUp(CatchUpConfig{Policy: model.CatchUpDropOlder, OverdueAfter: time.Minute, MaxAge: time.Minute}))
+
+	// Claimed again after the lease of a send that did not finish ran out.
+	retried := model.Notification{Id: 1, TelegramId: 1, Status: "active", SendAt: int(time.Now().Add(-10 * time.Minute).UnixMilli()), Claims: 2}
+
+	mockStorage.On("ClaimReadyNotifications", mock.AnythingOfType("int")).Return([]model.Notification{retried}, nil)
+	mockQueue.On("Publish", retried).Return(nil)
+
+	assert.NoError(t, service.publishReady())
+
+	mockQueue.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+	assert.Nil(t, service.GetCatchUpReport())
+}
+
+func TestService_PublishReady_CatchUpSpread(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	service := New(mockStorage, mockCache, mockQueue, new(MockSender),
+		WithCatchUp(CatchUpConfig{Policy: model.CatchUpSpread, Window: 9 * time.Minute}))
+
+	now := time.Now()
+	var missed []model.Notification
+	for id := 1; id <= 3; id++ {
+		missed = append(missed, model.Notification{Id: id, TelegramId: 1, Status: "active", SendAt: int(now.Add(-time.Duration(4-id) * time.Hour).UnixMilli())})
+	}
+
+	mockStorage.On("ClaimReadyNotifications", mock.AnythingOfType("int")).Return(missed, nil)
+	mockQueue.On("Publish", missed[0]).Return(nil)
+	for id, delay := range map[int]time.Duration{2: 3 * time.Minute, 3: 6 * time.Minute} {
+		mockStorage.On("RescheduleNotification", id, mock.MatchedBy(func(sendAt int) bool {
+			at := time.UnixMilli(int64(sendAt))
+			return !at.Before(now.Add(delay).Truncate(time.Millisecond)) && at.Before(now.Add(delay+time.Minute))
+		})).Return(nil)
+		mockStorage.On("GetNotificationById", id).Return(&model.Notification{Id: id, Status: "active", Version: 2}, nil)
+		mockCache.On("SetNotification", cached(id, "active", 2)).Return(nil)
+	}
+
+	assert.NoError(t, service.publishReady())
+
+	mockStorage.AssertExpectations(t)
+	mockQueue.AssertExpectations(t)
+	report := service.GetCatchUpReport()
+	if !assert.NotNil(t, report) {
+		return
+	}
+	assert.Equal(t, 3, report.Missed)
+	assert.Equal(t, 1, report.Sent)
+	assert.Equal(t, 2, report.Rescheduled)
+}
+
+func TestService_HandleMessage_RateLimited(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(&sender.RetryAfterError{After: time.Hour})
+	mockStorage.On("RescheduleNotification", 1, mock.MatchedBy(func(sendAt int) bool {
+		return int64(sendAt) >= time.Now().Add(59*time.Minute).UnixMilli()
+	})).Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "active", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "active", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.Error(t, err)
+	mockStorage.AssertExpectations(t)
+	mockSender.AssertNumberOfCalls(t, "SendToTelegram", 1)
+	mockStorage.AssertNotCalled(t, "UpdateNotificationStatus")
+}
+
+func TestService_HandleMessage_RateLimitedCanceled(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	// The notification is canceled while it is being sent: the retry does
+	// not revive it.
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(&sender.RetryAfterError{After: time.Hour})
+	mockStorage.On("RescheduleNotification", 1, mock.AnythingOfType("int")).Return(repository.ErrNotActive)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.Error(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "SetNotification", mock.Anything)
+}
+
+func TestService_HandleMessage_Blocked(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(fmt.Errorf("%w: bot was blocked by the user", sender.ErrRecipientBlocked))
+	mockStorage.On("MarkRecipientUnreachable", 123, mock.AnythingOfType("string")).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.ErrorIs(t, err, sender.ErrRecipientBlocked)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_UnreachableRecipient(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(true, nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.Error(t, err)
+	mockSender.AssertNotCalled(t, "SendToTelegram")
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_ChatNotFound(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(sender.ErrRecipientNotFound)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.ErrorIs(t, err, sender.ErrRecipientNotFound)
+	mockStorage.AssertNotCalled(t, "MarkRecipientUnreachable")
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_TemporaryErrorRetried(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+	service.sendRetryDelay = time.Millisecond
+
+	notification := model.Notification{Id: 1, Text: "Test", TelegramId: 123, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("IsRecipientUnreachable", 123).Return(false, nil)
+	mockSender.On("SendToTelegram", notification).Return(&sender.TemporaryError{Err: assert.AnError}).Once()
+	mockSender.On("SendToTelegram", notification).Return(nil).Once()
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "completed", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockSender.AssertNumberOfCalls(t, "SendToTelegram", 2)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_ReconcileCache_RepairsDrift(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	mockStorage.On("GetNotificationsAfter", 0, 1000).Return([]model.Notification{
+		{Id: 1, Status: "canceled", Version: 2},
+		{Id: 2, Status: "active", Version: 1},
+		{Id: 3, Status: "completed", Version: 4},
+	}, nil)
+	mockCache.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "active", Version: 1}, nil)
+	mockCache.On("GetNotification", 2).Return((*model.Notification)(nil), redis.Nil)
+	mockCache.On("GetNotification", 3).Return(&model.Notification{Id: 3, Status: "completed", Version: 4}, nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
+
+	repaired, err := service.reconcileCache()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repaired)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "SetNotification", cached(2, "active", 1))
+}
+
+func TestService_ReconcileCache_Batches(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender), WithReconcileBatch(2))
+
+	mockStorage.On("GetNotificationsAfter", 0, 2).Return([]model.Notification{
+		{Id: 1, Status: "active", Version: 1},
+		{Id: 2, Status: "active", Version: 1},
+	}, nil).Once()
+	mockStorage.On("GetNotificationsAfter", 2, 2).Return([]model.Notification{
+		{Id: 3, Status: "active", Version: 1},
+	}, nil).Once()
+	mockStorage.On("GetNotificationsAfter", 0, 2).Return([]model.Notification{}, nil).Once()
+	for _, id := range []int{1, 2, 3} {
+		mockCache.On("GetNotification", id).Return(&model.Notification{Id: id, Status: "active", Version: 1}, nil)
+	}
+
+	for range 3 {
+		_, err := service.reconcileCache()
+		assert.NoError(t, err)
+	}
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "GetAllNotifications")
+}
+
+func TestService_ReconcileCache_CachedVersionAhead(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Status: "active", Version: 3}
+
+	mockStorage.On("GetNotificationsAfter", 0, 1000).Return([]model.Notification{notification}, nil)
+	mockCache.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 5}, nil)
+	mockStorage.On("GetNotificationById", 1).Return(&notification, nil)
+	mockCache.On("DeleteNotification", 1).Return(nil)
+	mockCache.On("SetNotification", cached(1, "active", 3)).Return(nil)
+
+	repaired, err := service.reconcileCache()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repaired)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_ReconcileCache_RowChangedMeanwhile(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	// The list is read before a concurrent cancel, which already reached
+	// the cache by the time it is compared.
+	mockStorage.On("GetNotificationsAfter", 0, 1000).Return([]model.Notification{{Id: 1, Status: "active", Version: 1}}, nil)
+	mockCache.On("GetNotification", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+
+	repaired, err := service.reconcileCache()
+
+	assert.NoError(t, err)
+	assert.Zero(t, repaired)
+	mockCache.AssertNotCalled(t, "DeleteNotification", mock.Anything)
+	mockCache.AssertNotCalled(t, "SetNotification", mock.Anything)
+}
+
+func TestService_GetNotificationStatus_CachedMissing(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
+
+	result, err := service.GetNotificationStatus(1)
+
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+	assert.Nil(t, result)
+	mockStorage.AssertNotCalled(t, "GetNotificationById", mock.Anything)
+}
+
+func TestService_GetNotificationStatus_CachesMissing(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), redis.Nil)
+	mockStorage.On("GetNotificationById", 1).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
+	mockCache.On("SetMissing", 1).Return(nil)
+
+	result, err := service.GetNotificationStatus(1)
+
+	assert.ErrorIs(t, err, repository.ErrNoSuchNotification)
+	assert.Nil(t, result)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_ReconcileCache_CachedAsMissing(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Status: "active", Version: 1}
+
+	mockStorage.On("GetNotificationsAfter", 0, 1000).Return([]model.Notification{notification}, nil)
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
+	mockStorage.On("GetNotificationById", 1).Return(&notification, nil)
+	mockCache.On("SetNotification", cached(1, "active", 1)).Return(nil)
+
+	repaired, err := service.reconcileCache()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repaired)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_WarmupCache(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender, WithReconcileBatch(2))
+
+	first := []model.Notification{
+		{Id: 1, Status: "active", Version: 1},
+		{Id: 2, Status: "completed", Version: 2},
+	}
+	last := []model.Notification{
+		{Id: 5, Status: "active", Version: 1},
+	}
+
+	// Only active notifications are cached, a short batch ends the walk.
+	mockStorage.On("GetNotificationsAfter", 0, 2).Return(first, nil).Once()
+	mockStorage.On("GetNotificationsAfter", 2, 2).Return(last, nil).Once()
+	mockCache.On("SetNotifications", first[:1]).Return(nil).Once()
+	mockCache.On("SetNotifications", last).Return(nil).Once()
+
+	assert.NoError(t, service.WarmupCache(context.Background()))
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_ResolvesRecipient(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", RecipientId: 7, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelEmail, Address: "alice@example.com", Verified: true},
+		{Id: 2, Channel: model.ChannelTelegram, Address: "100"},
+		{Id: 3, Channel: model.ChannelTelegram, Address: "200", Verified: true},
+		{Id: 4, Channel: model.ChannelTelegram, Address: "300", Verified: true},
+	}}, nil)
+	mockStorage.On("IsRecipientUnreachable", 200).Return(true, nil)
+	mockStorage.On("IsRecipientUnreachable", 300).Return(false, nil)
+
+	resolved := notification
+	resolved.TelegramId = 300
+	mockSender.On("SendToTelegram", resolved).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "completed", 2)).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_RecipientWithoutVerifiedContact(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Test", RecipientId: 7, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "100"},
+	}}, nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.Error(t, err)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_AddContact_SendsVerificationCode(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockSender := new(MockSender)
+	service := New(mockStorage, new(MockCache), new(MockQueue), mockSender)
+
+	stored := &model.Contact{Id: 1, Channel: model.ChannelTelegram, Address: "42", VerificationCode: "123456"}
+	mockStorage.On("AddContact", 7, mock.AnythingOfType("model.Contact")).Return(stored, nil)
+	sent := make(chan model.Notification, 1)
+	mockSender.On("SendToTelegram", mock.AnythingOfType("model.Notification")).Return(nil).
+		Run(func(args mock.Arguments) { sent <- args.Get(0).(model.Notification) })
+
+	_, err := service.AddContact(7, model.Contact{Channel: model.ChannelTelegram, Address: "42", Verified: true})
+	assert.NoError(t, err)
+
+	saved := mockStorage.Calls[0].Arguments.Get(1).(model.Contact)
+	assert.False(t, saved.Verified)
+	assert.Regexp(t, `^[0-9]{6}$`, saved.VerificationCode)
+
+	// The code is sent in the background.
+	select {
+	case notification := <-sent:
+		assert.Equal(t, 42, notification.TelegramId)
+		assert.Contains(t, notification.Text, "123456")
+	case <-time.After(time.Second):
+		t.Fatal("verification code was not sent")
+	}
+}
+
+func TestService_AddContact_Invalid(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := New(mockStorage, new(MockCache), new(MockQueue), new(MockSender))
+
+	_, err := service.AddContact(7, model.Contact{Channel: model.ChannelEmail, Address: "alice"})
+
+	assert.ErrorIs(t, err, model.ErrInvalidContact)
+	mockStorage.AssertNotCalled(t, "AddContact", mock.Anything, mock.Anything)
+}
+
+func TestService_VerifyContact(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := New(mockStorage, new(MockCache), new(MockQueue), new(MockSender))
+
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "42", VerificationCode: "123456"},
+	}}, nil)
+	mockStorage.On("VerifyContact", 7, 1).Return(nil)
+
+	_, err := service.VerifyContact(7, 1, "654321")
+	assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+
+	_, err = service.VerifyContact(7, 2, "123456")
+	assert.ErrorIs(t, err, repository.ErrNoSuchContact)
+
+	contact, err := service.VerifyContact(7, 1, "123456")
+	assert.NoError(t, err)
+	assert.True(t, contact.Verified)
+	mockStorage.AssertNumberOfCalls(t, "VerifyContact", 1)
+}
+
+func TestService_ReorderContacts_InvalidOrder(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := New(mockStorage, new(MockCache), new(MockQueue), new(MockSender))
+
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{{Id: 1}, {Id: 2}}}, nil)
+
+	for _, order := range [][]int{{1}, {1, 1}, {1, 3}, {1, 2, 3}} {
+		_, err := service.ReorderContacts(7, order)
+		assert.ErrorIs(t, err, ErrInvalidContactOrder, "order %v", order)
+	}
+	mockStorage.AssertNotCalled(t, "ReorderContacts", mock.Anything, mock.Anything)
+}
+
+func TestService_HandleMessage_FallsBackToNextChannel(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	mockEmail := new(MockChannelSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender, WithChannelSender(model.ChannelEmail, mockEmail))
+
+	notification := model.Notification{Id: 1, Text: "Test", RecipientId: 7, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "100", Verified: true},
+		{Id: 2, Channel: model.ChannelPhone, Address: "+14155550123", Verified: true},
+		{Id: 3, Channel: model.ChannelEmail, Address: "alice@example.com", Verified: true},
+	}}, nil)
+	mockStorage.On("IsRecipientUnreachable", 100).Return(false, nil)
+
+	toTelegram := notification
+	toTelegram.TelegramId = 100
+	blocked := fmt.Errorf("%w: bot was blocked by the user", sender.ErrRecipientBlocked)
+	mockSender.On("SendToTelegram", toTelegram).Return(blocked)
+	mockStorage.On("MarkRecipientUnreachable", 100, blocked.Error()).Return(nil)
+	mockEmail.On("Send", "alice@example.com", notification).Return(nil)
+
+	mockStorage.On("AddDeliveryAttempt", model.DeliveryAttempt{
+		NotificationId: 1,
+		Channel:        model.ChannelTelegram,
+		Address:        "100",
+		Status:         model.DeliveryFailed,
+		Error:          blocked.Error(),
+	}).Return(nil).Once()
+	mockStorage.On("AddDeliveryAttempt", model.DeliveryAttempt{
+		NotificationId: 1,
+		Channel:        model.ChannelEmail,
+		Address:        "alice@example.com",
+		Status:         model.DeliverySent,
+	}).Return(nil).Once()
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "completed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockEmail.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_NotificationChannels(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	mockEmail := new(MockChannelSender)
+	mockSMS := new(MockChannelSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender,
+		WithChannelSender(model.ChannelEmail, mockEmail),
+		WithChannelSender(model.ChannelPhone, mockSMS),
+	)
+
+	notification := model.Notification{
+		Id:          1,
+		Text:        "Test",
+		RecipientId: 7,
+		Channels:    []string{model.ChannelPhone, model.ChannelEmail},
+		Status:      "active",
+	}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "100", Verified: true},
+		{Id: 2, Channel: model.ChannelEmail, Address: "alice@example.com", Verified: true},
+		{Id: 3, Channel: model.ChannelPhone, Address: "+14155550123", Verified: true},
+	}}, nil)
+	mockSMS.On("Send", "+14155550123", notification).Return(fmt.Errorf("%w: 400 Bad Request", sender.ErrRejected))
+	mockEmail.On("Send", "alice@example.com", notification).Return(fmt.Errorf("%w: 550 mailbox unavailable", sender.ErrRejected))
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.ErrorIs(t, err, sender.ErrRejected)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockStorage.AssertNumberOfCalls(t, "AddDeliveryAttempt", 2)
+	assert.Equal(t, model.ChannelPhone, mockStorage.Calls[3].Arguments.Get(0).(model.DeliveryAttempt).Channel)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_FansOutToGroupMembers(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{
+		Id:       1,
+		Text:     "Standup",
+		GroupId:  4,
+		Channels: []string{model.ChannelTelegram},
+		Status:   "active",
+	}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetGroup", 4).Return(&model.Group{Id: 4, Members: []int{7, 8}}, nil)
+	mockStorage.On("GetChildNotifications", 1).Return([]model.Notification{{Id: 2, RecipientId: 7, ParentId: 1}}, nil)
+	mockStorage.On("CreateNotification", mock.MatchedBy(func(n model.Notification) bool {
+		return n.RecipientId == 8 && n.ParentId == 1 && n.Text == "Standup" &&
+			n.Status == "active" && assert.ObjectsAreEqual(notification.Channels, n.Channels)
+	})).Return(&model.Notification{Id: 3, RecipientId: 8, ParentId: 1, Status: "active", Version: 1}, nil)
+	mockCache.On("SetNotification", cached(3, "active", 1)).Return(nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "completed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "completed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "completed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertNumberOfCalls(t, "CreateNotification", 1)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_EmptyGroupFails(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	msg := queued(mockStorage, model.Notification{Id: 1, Text: "Standup", GroupId: 4, Status: "active"})
+
+	mockStorage.On("GetGroup", 4).Return(&model.Group{Id: 4, Members: []int{}}, nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "failed").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "failed", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "failed", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.Error(t, err)
+	mockStorage.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_MutedCategory(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	msg := queued(mockStorage, model.Notification{Id: 1, Text: "Bill", RecipientId: 7, Category: "billing", Status: "active"})
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7, MutedCategories: []string{"billing"}}, nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockStorage.AssertNotCalled(t, "GetRecipient", mock.Anything)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_OptedOutChannels(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	mockEmail := new(MockChannelSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender, WithChannelSender(model.ChannelEmail, mockEmail))
+
+	notification := model.Notification{Id: 1, Text: "Test", RecipientId: 7, Status: "active"}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{
+		RecipientId:      7,
+		OptedOutChannels: []string{model.ChannelTelegram, model.ChannelEmail},
+	}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "100", Verified: true},
+		{Id: 2, Channel: model.ChannelEmail, Address: "alice@example.com", Verified: true},
+	}}, nil)
+	mockStorage.On("UpdateNotificationStatus", 1, "canceled").Return(nil)
+	mockStorage.On("GetNotificationById", 1).Return(&model.Notification{Id: 1, Status: "canceled", Version: 2}, nil)
+	mockCache.On("SetNotification", cached(1, "canceled", 2)).Return(nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockEmail.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_HandleMessage_Digest(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	mockQueue := new(MockQueue)
+	mockSender := new(MockSender)
+	service := New(mockStorage, mockCache, mockQueue, mockSender)
+
+	notification := model.Notification{Id: 1, Text: "Pay the bill", RecipientId: 7, Category: "billing", Status: "active", SendAt: 1000}
+	msg := queued(mockStorage, notification)
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7, DigestWindow: 60, MutedCategories: []string{"news"}}, nil)
+	mockStorage.On("AssignDigest", 1, 7, mock.AnythingOfType("int"), []string(nil)).Return([]model.Notification{
+		{Id: 2, Text: "Call mom", RecipientId: 7, Status: "active", SendAt: 500, DigestId: 1},
+		{Id: 1, Text: "Pay the bill", RecipientId: 7, Category: "billing", Status: "active", SendAt: 1000, DigestId: 1},
+		{Id: 3, Text: "Read the news", RecipientId: 7, Category: "news", Status: "active", SendAt: 2000, DigestId: 1},
+	}, nil)
+	mockStorage.On("GetRecipient", 7).Return(&model.Recipient{Id: 7, Contacts: []model.Contact{
+		{Id: 1, Channel: model.ChannelTelegram, Address: "100", Verified: true},
+	}}, nil)
+	mockStorage.On("IsRecipientUnreachable", 100).Return(false, nil)
+	mockSender.On("SendToTelegram", mock.MatchedBy(func(n model.Notification) bool {
+		return n.Id == 1 && n.TelegramId == 100 && n.Text == "You have 2 notifications:\n\n1. Call mom\n\n2. Pay the bill"
+	})).Return(nil)
+	mockStorage.On("AddDeliveryAttempt", mock.Anything).Return(nil)
+	for id, status := range map[int]string{1: "completed", 2: "completed", 3: "canceled"} {
+		mockStorage.On("UpdateNotificationStatus", id, status).Return(nil)
+		mockStorage.On("GetNotificationById", id).Return(&model.Notification{Id: id, Status: status, Version: 2}, nil)
+		mockCache.On("SetNotification", cached(id, status, 2)).Return(nil)
+	}
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockSender.AssertNumberOfCalls(t, "SendToTelegram", 1)
+	mockStorage.AssertNumberOfCalls(t, "AddDeliveryAttempt", 2)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_DigestText_MarkdownV2(t *testing.T) {
+	service := New(new(MockStorage), new(MockCache), new(MockQueue), new(MockSender))
+
+	markdown := &model.TelegramOptions{ParseMode: model.ParseModeMarkdownV2}
+	batch := []model.Notification{
+		{Id: 1, Text: "*Pay* the bill", Options: markdown},
+		{Id: 2, Text: "Call mom (today!)"},
+	}
+
+	assert.Equal(t,
+		"You have 2 notifications:\n\n1\\. *Pay* the bill\n\n2\\. Call mom \\(today\\!\\)",
+		service.digestText(markdown, batch))
+	assert.Equal(t,
+		"You have 2 notifications:\n\n1. *Pay* the bill\n\n2. Call mom (today!)",
+		service.digestText(nil, []model.Notification{{Id: 1, Text: "*Pay* the bill"}, batch[1]}))
+}
+
+func TestService_HandleMessage_InAnotherDigest(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockSender := new(MockSender)
+	service := New(mockStorage, new(MockCache), new(MockQueue), mockSender)
+
+	msg := queued(mockStorage, model.Notification{Id: 2, Text: "Call mom", RecipientId: 7, Status: "active"})
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7, DigestWindow: 60}, nil)
+	mockStorage.On("AssignDigest", 2, 7, mock.AnythingOfType("int"), []string(nil)).Return([]model.Notification{}, nil)
+
+	err := service.handleMessage(msg, model.Notification{})
+
+	assert.NoError(t, err)
+	mockSender.AssertNotCalled(t, "SendToTelegram", mock.Anything)
+	mockStorage.AssertNotCalled(t, "UpdateNotificationStatus", mock.Anything, mock.Anything)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestService_Unsubscribe(t *testing.T) {
+	mockStorage := new(MockStorage)
+	signer := unsubscribe.NewSigner([]byte("secret"))
+	service := New(mockStorage, new(MockCache), new(MockQueue), new(MockSender),
+		WithUnsubscribeLinks(signer, "https://notifier.example.com/api/v1/unsubscribe"))
+
+	mockStorage.On("GetPreferences", 7).Return(&model.Preferences{RecipientId: 7, MutedCategories: []string{"news"}}, nil)
+	mockStorage.On("SavePreferences", model.Preferences{RecipientId: 7, MutedCategories: []string{"news", "billing"}}).Return(nil)
+
+	preferences, err := service.Unsubscribe(signer.Sign(unsubscribe.Token{RecipientId: 7, Category: "billing"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"news", "billing"}, preferences.MutedCategories)
+	assert.False(t, preferences.Unsubscribed)
+	mockStorage.AssertExpectations(t)
+
+	_, err = service.Unsubscribe("forged.token")
+	assert.ErrorIs(t, err, unsubscribe.ErrInvalidToken)
+
+	_, err = New(mockStorage, new(MockCache), new(MockQueue), new(MockSender)).Unsubscribe(signer.Sign(unsubscribe.Token{RecipientId: 7}))
+	assert.ErrorIs(t, err, unsubscribe.ErrInvalidToken)
+}
+
+func TestService_WithUnsubscribeLink(t *testing.T) {
+	signer := unsubscribe.NewSigner([]byte("secret"))
+	service := New(new(MockStorage), new(MockCache), new(MockQueue), new(MockSender),
+		WithUnsubscribeLinks(signer, "https://notifier.example.com/api/v1/unsubscribe"))
+
+	text := service.withUnsubscribeLink(model.Notification{Text: "Bill. Unsubscribe: {unsubscribe_url}", RecipientId: 7, Category: "billing"})
+
+	prefix := "Bill. Unsubscribe: https://notifier.example.com/api/v1/unsubscribe?token="
+	assert.True(t, strings.HasPrefix(text, prefix), text)
+	token, err := url.QueryUnescape(strings.TrimPrefix(text, prefix))
+	assert.NoError(t, err)
+	verified, err := signer.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, unsubscribe.Token{RecipientId: 7, Category: "billing"}, verified)
+
+	assert.Equal(t, "Hi ", service.withUnsubscribeLink(model.Notification{Text: "Hi {unsubscribe_url}", TelegramId: 1}))
+	assert.Equal(t, "Hi", service.withUnsubscribeLink(model.Notification{Text: "Hi", RecipientId: 7}))
+}
+
+func TestService_ListUnsubscribe(t *testing.T) {
+	signer := unsubscribe.NewSigner([]byte("secret"))
+	service := New(new(MockStorage), new(MockCache), new(MockQueue), new(MockSender),
+		WithUnsubscribeLinks(signer, "https://notifier.example.com/api/v1/unsubscribe"))
+
+	batch := []model.Notification{
+		{Id: 1, Text: "Hi", RecipientId: 7},
+		{Id: 2, Text: "Bill. Unsubscribe: {unsubscribe_url}", RecipientId: 7, Category: "billing"},
+	}
+	link := "https://notifier.example.com/api/v1/unsubscribe?token=" +
+		url.QueryEscape(signer.Sign(unsubscribe.Token{RecipientId: 7, Category: "billing"}))
+
+	// The announced link is the one in the text, not escaped for a parse
+	// mode.
+	assert.Equal(t, link, service.listUnsubscribe(batch))
+	assert.Empty(t, service.listUnsubscribe(batch[:1]))
+}
+
+func TestService_WithUnsubscribeLink_ParseModes(t *testing.T) {
+	signer := unsubscribe.NewSigner([]byte("secret"))
+	service := New(new(MockStorage), new(MockCache), new(MockQueue), new(MockSender),
+		WithUnsubscribeLinks(signer, "https://notifier.example.com/api/v1/unsubscribe?list=a&b"))
+
+	notification := model.Notification{Text: "{unsubscribe_url}", RecipientId: 7}
+	link := "https://notifier.example.com/api/v1/unsubscribe?list=a&b?token=" + url.QueryEscape(signer.Sign(unsubscribe.Token{RecipientId: 7}))
+
+	notification.Options = &model.TelegramOptions{ParseMode: model.ParseModeMarkdownV2}
+	escaped := service.withUnsubscribeLink(notification)
+	assert.Contains(t, escaped, `https://notifier\.example\.com/api/v1/unsubscribe?list\=a&b?token\=`)
+	assert.Equal(t, link, strings.ReplaceAll(escaped, `\`, ""))
+
+	notification.Options = &model.TelegramOptions{ParseMode: model.ParseModeHTML}
+	assert.Equal(t, strings.ReplaceAll(link, "&", "&amp;"), service.withUnsubscribeLink(notification))
+}
+
+func TestService_DeleteNotification(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender))
+
+	mockCache.On("GetNotification", 1).Return(&model.Notification{Id: 1, GroupId: 3, Status: "completed"}, nil)
+	mockStorage.On("GetChildNotifications", 1).Return([]model.Notification{{Id: 2, ParentId: 1}}, nil)
+	mockStorage.On("DeleteNotificationById", 1).Return(nil)
+	mockCache.On("DeleteNotification", 1).Return(nil)
+	mockCache.On("DeleteNotification", 2).Return(nil)
+
+	assert.NoError(t, service.DeleteNotification(1))
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestService_DeleteNotification_NotFound(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender))
+
+	mockCache.On("GetNotification", 1).Return((*model.Notification)(nil), repository.ErrNoSuchNotification)
+
+	assert.ErrorIs(t, service.DeleteNotification(1), repository.ErrNoSuchNotification)
+	mockStorage.AssertNotCalled(t, "DeleteNotificationById", 1)
+}
+
+func TestService_EnforceRetention(t *testing.T) {
+	mockStorage := new(MockStorage)
+	mockCache := new(MockCache)
+	service := New(mockStorage, mockCache, new(MockQueue), new(MockSender), WithRetention(RetentionConfig{
+		BatchSize: 2,
+		Policies: []RetentionPolicy{
+			{PurgeAfter: 30 * 24 * time.Hour},
+			{Tenant: "acme", Statuses: []string{"completed"}, AnonymizeAfter: 7 * 24 * time.Hour},
+		},
+	}))
+
+	now := time.Now()
+	within := func(age time.Duration, before int) bool {
+		expected := now.Add(-age).UnixMilli()
+		return int64(before) >= expected && int64(before) < expected+int64(time.Minute/time.Millisecond)
+	}
+	purge := mock.MatchedBy(func(scope model.RetentionScope) bool {
+		return scope.Tenant == "" && assert.ObjectsAreEqual([]string{"acme"}, scope.Except) &&
+			assert.ObjectsAreEqual(model.FinishedStatuses, scope.Statuses) && within(30*24*time.Hour, scope.Before)
+	})
+	anonymize := mock.MatchedBy(func(scope model.RetentionScope) bool {
+		return scope.Tenant == "acme" && len(scope.Except) == 0 &&
+			assert.ObjectsAreEqual([]string{"completed"}, scope.Statuses) && within(7*24*time.Hour, scope.Before)
+	})
+
+	mockStorage.On("PurgeNotifications", purge, 2).Return([]int{1, 2}, nil).Once()
+	mockStorage.On("PurgeNotifications", purge, 2).Return([]int{3}, nil).Once()
+	mockStorage.On("PurgeNotifications", purge, 2).Return([]int{}, nil).Once()
+	mockStorage.On("AnonymizeNotifications", anonymize, 2).Return([]int{4}, nil).Once()
+	mockStorage.On("AnonymizeNotifications", anonymize, 2).Return([]int{}, nil).Once()
+	for _, id := range []int{1, 2, 3, 4} {
+		mockCache.On("DeleteNotification", id).Return(nil).Once()
+	}
+
+	purged, anonymized, err := service.enforceRetention(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Equal(t, 1, anonymized)
+	mockStorage.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
 }
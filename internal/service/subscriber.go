@@ -0,0 +1,30 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+)
+
+// RegisterSubscriber links handle to the chat. Starting the bot again also
+// means the user unblocked it, so the chat becomes reachable again.
+func (s *Service) RegisterSubscriber(handle string, telegramId int) error {
+	err := s.storage.SaveSubscriber(model.Subscriber{
+		Handle:     normalizeHandle(handle),
+		TelegramId: telegramId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.storage.MarkRecipientReachable(telegramId)
+}
+
+func (s *Service) GetSubscriber(handle string) (*model.Subscriber, error) {
+	return s.storage.GetSubscriberByHandle(normalizeHandle(handle))
+}
+
+// normalizeHandle makes "@Alice" and "alice" refer to the same subscriber.
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
@@ -1,13 +1,78 @@
 package service
 
+import (
+	"errors"
+	"time"
+
+	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+)
+
 func (s *Service) UpdateNotificationStatus(id int, newStatus string) error {
-	if err := s.cache.Set(id, newStatus); err != nil {
+	if err := s.storage.UpdateNotificationStatus(id, newStatus); err != nil {
 		return err
 	}
 
-	if err := s.storage.UpdateNotificationStatus(id, newStatus); err != nil {
+	s.changed(id, model.EventTypeForStatus(newStatus))
+	return nil
+}
+
+// CancelNotification cancels the notification on behalf of the telegram chat
+// it is addressed to. Notifications of other chats are reported as missing.
+func (s *Service) CancelNotification(telegramId, id int) error {
+	if _, err := s.getOwnNotification(telegramId, id); err != nil {
 		return err
 	}
 
-	return nil
+	return s.UpdateNotificationStatus(id, "canceled")
+}
+
+// SnoozeNotification reschedules an active or completed notification to be
+// sent again after delay. Canceled, failed and expired notifications stay
+// as they are, model.ErrNotSnoozable is returned.
+func (s *Service) SnoozeNotification(telegramId, id int, delay time.Duration) (*model.Notification, error) {
+	notification, err := s.getOwnNotification(telegramId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sendAt := int(time.Now().Add(delay).UnixMilli())
+	err = s.storage.RescheduleNotification(id, sendAt)
+	if errors.Is(err, repository.ErrNotActive) {
+		return nil, model.ErrNotSnoozable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.changed(id, model.EventRescheduled)
+
+	notification.SendAt = sendAt
+	notification.Status = "active"
+	notification.Version++
+	return notification, nil
+}
+
+func (s *Service) getOwnNotification(telegramId, id int) (*model.Notification, error) {
+	notification, err := s.storage.GetNotificationById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if notification.RecipientId != 0 {
+		own, err := s.isRecipientChat(notification.RecipientId, telegramId)
+		if err != nil {
+			return nil, err
+		}
+		if !own {
+			return nil, repository.ErrNoSuchNotification
+		}
+		return notification, nil
+	}
+
+	if notification.TelegramId != telegramId {
+		return nil, repository.ErrNoSuchNotification
+	}
+
+	return notification, nil
 }
@@ -2,29 +2,357 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/Komilov31/delayed-notifier/internal/model"
+	"github.com/Komilov31/delayed-notifier/internal/repository"
+	"github.com/Komilov31/delayed-notifier/internal/sender"
 	"github.com/wb-go/wbf/zlog"
 )
 
+// stats is published at /debug/vars as "notifications": the number of
+// notifications that moved to each status.
+var stats = expvar.NewMap("notifications")
+
+const (
+	sendAttempts = 3
+	// temporaryFailureDelay is how long a notification waits before the
+	// scheduler picks it up again after every send attempt failed.
+	temporaryFailureDelay = time.Minute
+)
+
+// handleMessage sends the notification through its delivery chain, see
+// deliveryChain. When a contact fails after its retries the next one is
+// tried, the error of the last contact decides what happens to the
+// notification. Every contact tried is recorded in the delivery history.
+// Group notifications are fanned out instead, see fanOut. Notifications the
+// recipient's preferences rule out are canceled without being sent, ones it
+// wants in digests are sent in one message with the others due within the
+// digest window, see collectDigest. Notifications past their expiry are
+// expired instead of being sent. A message is skipped if its notification
+// changed after it was published, see isCurrent.
 func (s *Service) handleMessage(msg []byte, notification model.Notification) error {
 	if err := json.Unmarshal(msg, &notification); err != nil {
 		return fmt.Errorf("could not unmarshal notification from queue: " + err.Error())
 	}
 
-	if err := s.sender.SendToTelegram(notification.TelegramId, notification.Text); err != nil {
-		return fmt.Errorf("could not send notification to Telegram: " + err.Error())
+	current, err := s.isCurrent(notification)
+	if err != nil {
+		return err
+	}
+	if !current {
+		return nil
 	}
 
-	if err := s.storage.UpdateNotificationStatus(notification.Id, "completed"); err != nil {
-		return fmt.Errorf("could not update notification  status in db: " + err.Error())
+	if notification.Expired(time.Now()) {
+		return s.expire(notification)
+	}
+
+	if notification.GroupId != 0 {
+		return s.fanOut(notification)
+	}
+
+	preferences, err := s.recipientPreferences(notification)
+	if err != nil {
+		return err
+	}
+	if reason := preferences.Blocks(notification); reason != "" {
+		return s.suppress(notification, reason)
+	}
+
+	batch := []model.Notification{notification}
+	if preferences.Digests(notification) {
+		batch, err = s.collectDigest(notification, preferences)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+	}
+
+	chain, optedOut, err := s.deliveryChain(notification, preferences)
+	if err != nil {
+		return err
+	}
+
+	if len(chain) == 0 {
+		if optedOut {
+			for _, member := range batch {
+				if err := s.suppress(member, "recipient opted out of every channel it can be reached through"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := s.setStatuses(notification.Id, batch, "failed"); err != nil {
+			return err
+		}
+		if notification.RecipientId != 0 {
+			return fmt.Errorf("notification %d failed: recipient %d has no reachable verified contact", notification.Id, notification.RecipientId)
+		}
+		return fmt.Errorf("notification %d failed: recipient %d is unreachable", notification.Id, notification.TelegramId)
+	}
+
+	notification.Text = s.digestText(notification.Options, batch)
+	notification.UnsubscribeURL = s.listUnsubscribe(batch)
+
+	for i, contact := range chain {
+		err = s.send(notification, contact)
+		for _, member := range batch {
+			s.recordAttempt(member.Id, contact, err)
+		}
+		if err == nil {
+			break
+		}
+
+		if contact.Channel == model.ChannelTelegram && errors.Is(err, sender.ErrRecipientBlocked) {
+			if err := s.storage.MarkRecipientUnreachable(contact.TelegramId(), err.Error()); err != nil {
+				return fmt.Errorf("could not mark recipient as unreachable: " + err.Error())
+			}
+		}
+
+		if i < len(chain)-1 {
+			zlog.Logger.Warn().Msgf("could not send notification %d via %s, falling back to %s: %s",
+				notification.Id, contact.Channel, chain[i+1].Channel, err.Error())
+		}
 	}
 
-	if err := s.cache.Set(notification.Id, "completed"); err != nil {
-		return fmt.Errorf("could not update notification  status in redis: " + err.Error())
+	if err != nil {
+		return s.handleSendError(notification, batch, err)
+	}
+
+	if err := s.setStatuses(notification.Id, batch, "completed"); err != nil {
+		return err
 	}
 
 	zlog.Logger.Info().Msg("succesfully handled message from queue")
 	return nil
 }
+
+// isCurrent reports whether the queued notification is still active and
+// unchanged in the storage. One canceled, collapsed, merged or rescheduled
+// after it was published is left alone: a changed one that is still due is
+// published again with its new version.
+func (s *Service) isCurrent(queued model.Notification) (bool, error) {
+	stored, err := s.storage.GetNotificationById(queued.Id)
+	if errors.Is(err, repository.ErrNoSuchNotification) {
+		zlog.Logger.Info().Msgf("skipping notification %d: it was deleted after it was queued", queued.Id)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get notification from db: " + err.Error())
+	}
+
+	if stored.Status != "active" || stored.Version != queued.Version {
+		zlog.Logger.Info().Msgf("skipping notification %d: version %d was queued, it is %s at version %d now",
+			queued.Id, queued.Version, stored.Status, stored.Version)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// deliveryChain returns the contacts to send the notification to, in
+// order. A notification for a telegram chat has that chat only. One for a
+// recipient has its verified contacts in preference order, or grouped in
+// the order of notification.Channels if it is set. Contacts without a
+// sender, of channels the recipient opted out of and chats known to be
+// unreachable are left out. optedOut reports whether a contact was left out
+// for the opt-out.
+func (s *Service) deliveryChain(notification model.Notification, preferences model.Preferences) (chain []model.Contact, optedOut bool, err error) {
+	contacts := []model.Contact{{
+		Channel:  model.ChannelTelegram,
+		Address:  strconv.Itoa(notification.TelegramId),
+		Verified: true,
+	}}
+
+	if notification.RecipientId != 0 {
+		recipient, err := s.storage.GetRecipient(notification.RecipientId)
+		if errors.Is(err, repository.ErrNoSuchRecipient) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("could not get recipient from db: " + err.Error())
+		}
+		contacts = orderByChannels(recipient.Contacts, notification.Channels)
+	}
+
+	for _, contact := range contacts {
+		if !contact.Verified || !s.canSend(contact.Channel) {
+			continue
+		}
+		if !preferences.AllowsChannel(contact.Channel) {
+			optedOut = true
+			continue
+		}
+
+		if contact.Channel == model.ChannelTelegram {
+			unreachable, err := s.storage.IsRecipientUnreachable(contact.TelegramId())
+			if err != nil {
+				return nil, false, fmt.Errorf("could not check recipient reachability: " + err.Error())
+			}
+			if unreachable {
+				continue
+			}
+		}
+
+		chain = append(chain, contact)
+	}
+
+	return chain, optedOut, nil
+}
+
+// orderByChannels returns the contacts of the channels in the given order,
+// each channel keeping the preference order of its contacts. No channels
+// means all contacts as they are.
+func orderByChannels(contacts []model.Contact, channels []string) []model.Contact {
+	if len(channels) == 0 {
+		return contacts
+	}
+
+	var ordered []model.Contact
+	for _, channel := range channels {
+		for _, contact := range contacts {
+			if contact.Channel == channel {
+				ordered = append(ordered, contact)
+			}
+		}
+	}
+	return ordered
+}
+
+func (s *Service) canSend(channel string) bool {
+	if channel == model.ChannelTelegram {
+		return true
+	}
+	_, ok := s.channels[channel]
+	return ok
+}
+
+// send retries temporary failures with exponential backoff, any other error
+// is returned right away.
+func (s *Service) send(notification model.Notification, contact model.Contact) error {
+	delay := s.sendRetryDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.deliver(notification, contact)
+
+		var temporary *sender.TemporaryError
+		if err == nil || !errors.As(err, &temporary) || attempt == sendAttempts {
+			return err
+		}
+
+		zlog.Logger.Warn().Msgf("attempt %d to send notification %d failed: %s", attempt, notification.Id, err.Error())
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// deliver makes a single attempt to send the notification to the contact.
+func (s *Service) deliver(notification model.Notification, contact model.Contact) error {
+	if contact.Channel == model.ChannelTelegram {
+		notification.TelegramId = contact.TelegramId()
+		return s.sender.SendToTelegram(notification)
+	}
+
+	return s.channels[contact.Channel].Send(contact.Address, notification)
+}
+
+// recordAttempt adds the outcome of sending to the contact to the delivery
+// history. The history is informational, failing to save it only logs.
+func (s *Service) recordAttempt(id int, contact model.Contact, sendErr error) {
+	attempt := model.DeliveryAttempt{
+		NotificationId: id,
+		Channel:        contact.Channel,
+		Address:        contact.Address,
+		Status:         model.DeliverySent,
+	}
+	if sendErr != nil {
+		attempt.Status = model.DeliveryFailed
+		attempt.Error = sendErr.Error()
+	}
+
+	if err := s.storage.AddDeliveryAttempt(attempt); err != nil {
+		zlog.Logger.Warn().Msgf("could not record delivery attempt of notification %d: %s", id, err.Error())
+	}
+}
+
+// handleSendError reschedules or fails the notification and the rest of its
+// digest batch depending on sendErr.
+func (s *Service) handleSendError(notification model.Notification, batch []model.Notification, sendErr error) error {
+	var retryAfter *sender.RetryAfterError
+
+	switch {
+	case errors.As(sendErr, &retryAfter):
+		for _, member := range batch {
+			if err := s.reschedule(member.Id, retryAfter.After); err != nil {
+				return err
+			}
+		}
+	case errors.Is(sendErr, sender.ErrRecipientBlocked),
+		errors.Is(sendErr, sender.ErrRecipientNotFound),
+		errors.Is(sendErr, sender.ErrRejected):
+		if err := s.setStatuses(notification.Id, batch, "failed"); err != nil {
+			return err
+		}
+	default:
+		for _, member := range batch {
+			if err := s.reschedule(member.Id, temporaryFailureDelay); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("could not send notification: %w", sendErr)
+}
+
+func (s *Service) reschedule(id int, delay time.Duration) error {
+	sendAt := int(time.Now().Add(delay).UnixMilli())
+	err := s.storage.RescheduleNotification(id, sendAt)
+	if errors.Is(err, repository.ErrNotActive) {
+		zlog.Logger.Warn().Msgf("notification %d is not rescheduled: it is not active anymore", id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not reschedule notification in db: " + err.Error())
+	}
+
+	s.changed(id, model.EventRescheduled)
+	return nil
+}
+
+// setStatus gives the notification a terminal status. One that got a
+// terminal status meanwhile, e.g. was canceled while it was being sent,
+// keeps it.
+func (s *Service) setStatus(id int, status string) error {
+	err := s.storage.UpdateNotificationStatus(id, status)
+	if errors.Is(err, repository.ErrNotActive) {
+		zlog.Logger.Warn().Msgf("notification %d is not set %s: it is not active anymore", id, status)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not update notification  status in db: " + err.Error())
+	}
+
+	stats.Add(status, 1)
+	s.changed(id, model.EventTypeForStatus(status))
+	return nil
+}
+
+// expire gives up on a notification that is too late to be sent.
+func (s *Service) expire(notification model.Notification) error {
+	if err := s.setStatus(notification.Id, "expired"); err != nil {
+		return err
+	}
+
+	late := time.Since(time.UnixMilli(int64(notification.SendAt))).Round(time.Second)
+	zlog.Logger.Warn().Msgf("notification %d expired %s after it was due", notification.Id, late)
+	return nil
+}
@@ -0,0 +1,39 @@
+package unsubscribe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+
+	for _, token := range []Token{{RecipientId: 7}, {RecipientId: 7, Category: "billing"}} {
+		verified, err := signer.Verify(signer.Sign(token))
+		require.NoError(t, err)
+		assert.Equal(t, token, verified)
+	}
+}
+
+func TestSigner_RejectsTampering(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	signed := signer.Sign(Token{RecipientId: 7, Category: "billing"})
+	payload, mac, _ := strings.Cut(signed, ".")
+
+	forged := encoding.EncodeToString([]byte("8:billing")) + "." + mac
+
+	for _, token := range []string{
+		"",
+		payload,
+		forged,
+		payload + "." + mac + "x",
+		payload + ".!!",
+		NewSigner([]byte("other")).Sign(Token{RecipientId: 7, Category: "billing"}),
+	} {
+		_, err := signer.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, token)
+	}
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS subscribers(
+    handle TEXT PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notifications_telegram_id_idx ON notifications(telegram_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_telegram_id_idx;
+DROP TABLE IF EXISTS subscribers;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('active', 'canceled', 'completed', 'failed'));
+
+CREATE TABLE IF NOT EXISTS unreachable_recipients(
+    telegram_id BIGINT PRIMARY KEY,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS unreachable_recipients;
+
+UPDATE notifications SET status = 'canceled' WHERE status = 'failed';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('active', 'canceled', 'completed'));
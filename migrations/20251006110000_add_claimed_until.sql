@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS claimed_until BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN IF EXISTS claimed_until;
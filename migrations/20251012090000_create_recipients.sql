@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS recipients(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS contacts(
+    id SERIAL PRIMARY KEY,
+    recipient_id INT NOT NULL REFERENCES recipients(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL CHECK (channel IN ('telegram', 'email', 'phone', 'webhook')),
+    address TEXT NOT NULL,
+    position INT NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verification_code TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recipient_id, channel, address)
+);
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS recipient_id INT;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN IF EXISTS recipient_id;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS recipients;
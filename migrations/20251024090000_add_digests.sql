@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE recipient_preferences ADD COLUMN IF NOT EXISTS digest_window INT NOT NULL DEFAULT 0;
+ALTER TABLE recipient_preferences ADD COLUMN IF NOT EXISTS digest_categories TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS digest_id INT;
+CREATE INDEX IF NOT EXISTS notifications_recipient_id_status_idx ON notifications(recipient_id, status);
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_recipient_id_status_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS digest_id;
+ALTER TABLE recipient_preferences DROP COLUMN IF EXISTS digest_categories;
+ALTER TABLE recipient_preferences DROP COLUMN IF EXISTS digest_window;
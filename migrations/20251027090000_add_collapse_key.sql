@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS collapse_key TEXT;
+CREATE INDEX IF NOT EXISTS notifications_collapse_key_idx ON notifications(collapse_key)
+    WHERE collapse_key IS NOT NULL AND status = 'active';
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_collapse_key_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS collapse_key;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS expires_at BIGINT;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('active', 'canceled', 'completed', 'failed', 'expired'));
+
+-- +goose Down
+UPDATE notifications SET status = 'failed' WHERE status = 'expired';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('active', 'canceled', 'completed', 'failed'));
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS follow_up_of INT REFERENCES notifications(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS notifications_follow_up_of_idx ON notifications(follow_up_of)
+    WHERE follow_up_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_follow_up_of_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS follow_up_of;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS notifications_status_send_at_idx ON notifications(status, send_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_status_send_at_idx;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS tenant TEXT;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN IF EXISTS tenant;
@@ -0,0 +1,6 @@
+// Package notifierpb is the gRPC client and server code generated from
+// api/notifier/v1/notifier.proto. Dial the server with grpc.NewClient and
+// wrap the connection with NewNotifierServiceClient.
+package notifierpb
+
+//go:generate protoc -I ../../api --go_out=../.. --go_opt=module=github.com/Komilov31/delayed-notifier --go-grpc_out=../.. --go-grpc_opt=module=github.com/Komilov31/delayed-notifier notifier/v1/notifier.proto
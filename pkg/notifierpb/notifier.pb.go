@@ -0,0 +1,773 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: notifier/v1/notifier.proto
+
+package notifierpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Notification struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Text  string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// One of "active", "canceled", "completed", "failed" or "expired".
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	TelegramId    int64                  `protobuf:"varint,4,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	SendAt        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=send_at,json=sendAt,proto3" json:"send_at,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Options       *TelegramOptions       `protobuf:"bytes,7,opt,name=options,proto3" json:"options,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Notification) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Notification) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Notification) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Notification) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *Notification) GetSendAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SendAt
+	}
+	return nil
+}
+
+func (x *Notification) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Notification) GetOptions() *TelegramOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *Notification) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type TelegramOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "MarkdownV2", "HTML" or empty for plain text.
+	ParseMode             string       `protobuf:"bytes,1,opt,name=parse_mode,json=parseMode,proto3" json:"parse_mode,omitempty"`
+	Buttons               []*ButtonRow `protobuf:"bytes,2,rep,name=buttons,proto3" json:"buttons,omitempty"`
+	DisableNotification   bool         `protobuf:"varint,3,opt,name=disable_notification,json=disableNotification,proto3" json:"disable_notification,omitempty"`
+	DisableWebPagePreview bool         `protobuf:"varint,4,opt,name=disable_web_page_preview,json=disableWebPagePreview,proto3" json:"disable_web_page_preview,omitempty"`
+	Attachment            *Attachment  `protobuf:"bytes,5,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *TelegramOptions) Reset() {
+	*x = TelegramOptions{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TelegramOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelegramOptions) ProtoMessage() {}
+
+func (x *TelegramOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelegramOptions.ProtoReflect.Descriptor instead.
+func (*TelegramOptions) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TelegramOptions) GetParseMode() string {
+	if x != nil {
+		return x.ParseMode
+	}
+	return ""
+}
+
+func (x *TelegramOptions) GetButtons() []*ButtonRow {
+	if x != nil {
+		return x.Buttons
+	}
+	return nil
+}
+
+func (x *TelegramOptions) GetDisableNotification() bool {
+	if x != nil {
+		return x.DisableNotification
+	}
+	return false
+}
+
+func (x *TelegramOptions) GetDisableWebPagePreview() bool {
+	if x != nil {
+		return x.DisableWebPagePreview
+	}
+	return false
+}
+
+func (x *TelegramOptions) GetAttachment() *Attachment {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+type ButtonRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buttons       []*Button              `protobuf:"bytes,1,rep,name=buttons,proto3" json:"buttons,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ButtonRow) Reset() {
+	*x = ButtonRow{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ButtonRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ButtonRow) ProtoMessage() {}
+
+func (x *ButtonRow) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ButtonRow.ProtoReflect.Descriptor instead.
+func (*ButtonRow) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ButtonRow) GetButtons() []*Button {
+	if x != nil {
+		return x.Buttons
+	}
+	return nil
+}
+
+// Button is an inline keyboard button. Exactly one of url and
+// callback_data must be set.
+type Button struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	CallbackData  string                 `protobuf:"bytes,3,opt,name=callback_data,json=callbackData,proto3" json:"callback_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Button) Reset() {
+	*x = Button{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Button) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Button) ProtoMessage() {}
+
+func (x *Button) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Button.ProtoReflect.Descriptor instead.
+func (*Button) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Button) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Button) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Button) GetCallbackData() string {
+	if x != nil {
+		return x.CallbackData
+	}
+	return ""
+}
+
+type Attachment struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "photo" or "document".
+	Type          string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Url           string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Attachment) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Attachment) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// CreateNotificationRequest needs either telegram_id or the username
+// registered with /start in the bot.
+type CreateNotificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	TelegramId    int64                  `protobuf:"varint,2,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	SendAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=send_at,json=sendAt,proto3" json:"send_at,omitempty"`
+	Options       *TelegramOptions       `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateNotificationRequest) Reset() {
+	*x = CreateNotificationRequest{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateNotificationRequest) ProtoMessage() {}
+
+func (x *CreateNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateNotificationRequest.ProtoReflect.Descriptor instead.
+func (*CreateNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateNotificationRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *CreateNotificationRequest) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *CreateNotificationRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *CreateNotificationRequest) GetSendAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SendAt
+	}
+	return nil
+}
+
+func (x *CreateNotificationRequest) GetOptions() *TelegramOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type GetNotificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNotificationRequest) Reset() {
+	*x = GetNotificationRequest{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNotificationRequest) ProtoMessage() {}
+
+func (x *GetNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNotificationRequest.ProtoReflect.Descriptor instead.
+func (*GetNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetNotificationRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// ListNotificationsRequest filters the listed notifications, zero fields
+// match everything.
+type ListNotificationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TelegramId    int64                  `protobuf:"varint,1,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNotificationsRequest) Reset() {
+	*x = ListNotificationsRequest{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNotificationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNotificationsRequest) ProtoMessage() {}
+
+func (x *ListNotificationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNotificationsRequest.ProtoReflect.Descriptor instead.
+func (*ListNotificationsRequest) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListNotificationsRequest) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *ListNotificationsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type CancelNotificationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelNotificationRequest) Reset() {
+	*x = CancelNotificationRequest{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelNotificationRequest) ProtoMessage() {}
+
+func (x *CancelNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelNotificationRequest.ProtoReflect.Descriptor instead.
+func (*CancelNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CancelNotificationRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// UpdateNotificationRequest can only cancel an active notification, like
+// CancelNotification. The other statuses are set by delivery and are
+// rejected with INVALID_ARGUMENT, a finished notification is rejected with
+// FAILED_PRECONDITION.
+type UpdateNotificationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Must be "canceled".
+	Status        string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNotificationRequest) Reset() {
+	*x = UpdateNotificationRequest{}
+	mi := &file_notifier_v1_notifier_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNotificationRequest) ProtoMessage() {}
+
+func (x *UpdateNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifier_v1_notifier_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNotificationRequest.ProtoReflect.Descriptor instead.
+func (*UpdateNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notifier_v1_notifier_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateNotificationRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateNotificationRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+var File_notifier_v1_notifier_proto protoreflect.FileDescriptor
+
+const file_notifier_v1_notifier_proto_rawDesc = "" +
+	"\n" +
+	"\x1anotifier/v1/notifier.proto\x12\vnotifier.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xad\x02\n" +
+	"\fNotification\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1f\n" +
+	"\vtelegram_id\x18\x04 \x01(\x03R\n" +
+	"telegramId\x123\n" +
+	"\asend_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x06sendAt\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x126\n" +
+	"\aoptions\x18\a \x01(\v2\x1c.notifier.v1.TelegramOptionsR\aoptions\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\"\x87\x02\n" +
+	"\x0fTelegramOptions\x12\x1d\n" +
+	"\n" +
+	"parse_mode\x18\x01 \x01(\tR\tparseMode\x120\n" +
+	"\abuttons\x18\x02 \x03(\v2\x16.notifier.v1.ButtonRowR\abuttons\x121\n" +
+	"\x14disable_notification\x18\x03 \x01(\bR\x13disableNotification\x127\n" +
+	"\x18disable_web_page_preview\x18\x04 \x01(\bR\x15disableWebPagePreview\x127\n" +
+	"\n" +
+	"attachment\x18\x05 \x01(\v2\x17.notifier.v1.AttachmentR\n" +
+	"attachment\":\n" +
+	"\tButtonRow\x12-\n" +
+	"\abuttons\x18\x01 \x03(\v2\x13.notifier.v1.ButtonR\abuttons\"S\n" +
+	"\x06Button\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12#\n" +
+	"\rcallback_data\x18\x03 \x01(\tR\fcallbackData\"2\n" +
+	"\n" +
+	"Attachment\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"\xd9\x01\n" +
+	"\x19CreateNotificationRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x1f\n" +
+	"\vtelegram_id\x18\x02 \x01(\x03R\n" +
+	"telegramId\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x123\n" +
+	"\asend_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sendAt\x126\n" +
+	"\aoptions\x18\x05 \x01(\v2\x1c.notifier.v1.TelegramOptionsR\aoptions\"(\n" +
+	"\x16GetNotificationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"S\n" +
+	"\x18ListNotificationsRequest\x12\x1f\n" +
+	"\vtelegram_id\x18\x01 \x01(\x03R\n" +
+	"telegramId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"+\n" +
+	"\x19CancelNotificationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"C\n" +
+	"\x19UpdateNotificationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status2\xc8\x03\n" +
+	"\x0fNotifierService\x12W\n" +
+	"\x12CreateNotification\x12&.notifier.v1.CreateNotificationRequest\x1a\x19.notifier.v1.Notification\x12Q\n" +
+	"\x0fGetNotification\x12#.notifier.v1.GetNotificationRequest\x1a\x19.notifier.v1.Notification\x12W\n" +
+	"\x11ListNotifications\x12%.notifier.v1.ListNotificationsRequest\x1a\x19.notifier.v1.Notification0\x01\x12W\n" +
+	"\x12CancelNotification\x12&.notifier.v1.CancelNotificationRequest\x1a\x19.notifier.v1.Notification\x12W\n" +
+	"\x12UpdateNotification\x12&.notifier.v1.UpdateNotificationRequest\x1a\x19.notifier.v1.NotificationBAZ?github.com/Komilov31/delayed-notifier/pkg/notifierpb;notifierpbb\x06proto3"
+
+var (
+	file_notifier_v1_notifier_proto_rawDescOnce sync.Once
+	file_notifier_v1_notifier_proto_rawDescData []byte
+)
+
+func file_notifier_v1_notifier_proto_rawDescGZIP() []byte {
+	file_notifier_v1_notifier_proto_rawDescOnce.Do(func() {
+		file_notifier_v1_notifier_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_notifier_v1_notifier_proto_rawDesc), len(file_notifier_v1_notifier_proto_rawDesc)))
+	})
+	return file_notifier_v1_notifier_proto_rawDescData
+}
+
+var file_notifier_v1_notifier_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_notifier_v1_notifier_proto_goTypes = []any{
+	(*Notification)(nil),              // 0: notifier.v1.Notification
+	(*TelegramOptions)(nil),           // 1: notifier.v1.TelegramOptions
+	(*ButtonRow)(nil),                 // 2: notifier.v1.ButtonRow
+	(*Button)(nil),                    // 3: notifier.v1.Button
+	(*Attachment)(nil),                // 4: notifier.v1.Attachment
+	(*CreateNotificationRequest)(nil), // 5: notifier.v1.CreateNotificationRequest
+	(*GetNotificationRequest)(nil),    // 6: notifier.v1.GetNotificationRequest
+	(*ListNotificationsRequest)(nil),  // 7: notifier.v1.ListNotificationsRequest
+	(*CancelNotificationRequest)(nil), // 8: notifier.v1.CancelNotificationRequest
+	(*UpdateNotificationRequest)(nil), // 9: notifier.v1.UpdateNotificationRequest
+	(*timestamppb.Timestamp)(nil),     // 10: google.protobuf.Timestamp
+}
+var file_notifier_v1_notifier_proto_depIdxs = []int32{
+	10, // 0: notifier.v1.Notification.send_at:type_name -> google.protobuf.Timestamp
+	10, // 1: notifier.v1.Notification.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: notifier.v1.Notification.options:type_name -> notifier.v1.TelegramOptions
+	2,  // 3: notifier.v1.TelegramOptions.buttons:type_name -> notifier.v1.ButtonRow
+	4,  // 4: notifier.v1.TelegramOptions.attachment:type_name -> notifier.v1.Attachment
+	3,  // 5: notifier.v1.ButtonRow.buttons:type_name -> notifier.v1.Button
+	10, // 6: notifier.v1.CreateNotificationRequest.send_at:type_name -> google.protobuf.Timestamp
+	1,  // 7: notifier.v1.CreateNotificationRequest.options:type_name -> notifier.v1.TelegramOptions
+	5,  // 8: notifier.v1.NotifierService.CreateNotification:input_type -> notifier.v1.CreateNotificationRequest
+	6,  // 9: notifier.v1.NotifierService.GetNotification:input_type -> notifier.v1.GetNotificationRequest
+	7,  // 10: notifier.v1.NotifierService.ListNotifications:input_type -> notifier.v1.ListNotificationsRequest
+	8,  // 11: notifier.v1.NotifierService.CancelNotification:input_type -> notifier.v1.CancelNotificationRequest
+	9,  // 12: notifier.v1.NotifierService.UpdateNotification:input_type -> notifier.v1.UpdateNotificationRequest
+	0,  // 13: notifier.v1.NotifierService.CreateNotification:output_type -> notifier.v1.Notification
+	0,  // 14: notifier.v1.NotifierService.GetNotification:output_type -> notifier.v1.Notification
+	0,  // 15: notifier.v1.NotifierService.ListNotifications:output_type -> notifier.v1.Notification
+	0,  // 16: notifier.v1.NotifierService.CancelNotification:output_type -> notifier.v1.Notification
+	0,  // 17: notifier.v1.NotifierService.UpdateNotification:output_type -> notifier.v1.Notification
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_notifier_v1_notifier_proto_init() }
+func file_notifier_v1_notifier_proto_init() {
+	if File_notifier_v1_notifier_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_notifier_v1_notifier_proto_rawDesc), len(file_notifier_v1_notifier_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_notifier_v1_notifier_proto_goTypes,
+		DependencyIndexes: file_notifier_v1_notifier_proto_depIdxs,
+		MessageInfos:      file_notifier_v1_notifier_proto_msgTypes,
+	}.Build()
+	File_notifier_v1_notifier_proto = out.File
+	file_notifier_v1_notifier_proto_goTypes = nil
+	file_notifier_v1_notifier_proto_depIdxs = nil
+}
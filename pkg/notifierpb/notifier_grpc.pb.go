@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: notifier/v1/notifier.proto
+
+package notifierpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	NotifierService_CreateNotification_FullMethodName = "/notifier.v1.NotifierService/CreateNotification"
+	NotifierService_GetNotification_FullMethodName    = "/notifier.v1.NotifierService/GetNotification"
+	NotifierService_ListNotifications_FullMethodName  = "/notifier.v1.NotifierService/ListNotifications"
+	NotifierService_CancelNotification_FullMethodName = "/notifier.v1.NotifierService/CancelNotification"
+	NotifierService_UpdateNotification_FullMethodName = "/notifier.v1.NotifierService/UpdateNotification"
+)
+
+// NotifierServiceClient is the client API for NotifierService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// NotifierService manages delayed notifications. It mirrors the /notify
+// HTTP API.
+type NotifierServiceClient interface {
+	CreateNotification(ctx context.Context, in *CreateNotificationRequest, opts ...grpc.CallOption) (*Notification, error)
+	GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*Notification, error)
+	// ListNotifications streams notifications ordered by id.
+	ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Notification], error)
+	CancelNotification(ctx context.Context, in *CancelNotificationRequest, opts ...grpc.CallOption) (*Notification, error)
+	UpdateNotification(ctx context.Context, in *UpdateNotificationRequest, opts ...grpc.CallOption) (*Notification, error)
+}
+
+type notifierServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNotifierServiceClient(cc grpc.ClientConnInterface) NotifierServiceClient {
+	return &notifierServiceClient{cc}
+}
+
+func (c *notifierServiceClient) CreateNotification(ctx context.Context, in *CreateNotificationRequest, opts ...grpc.CallOption) (*Notification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Notification)
+	err := c.cc.Invoke(ctx, NotifierService_CreateNotification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notifierServiceClient) GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*Notification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Notification)
+	err := c.cc.Invoke(ctx, NotifierService_GetNotification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notifierServiceClient) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Notification], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NotifierService_ServiceDesc.Streams[0], NotifierService_ListNotifications_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListNotificationsRequest, Notification]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NotifierService_ListNotificationsClient = grpc.ServerStreamingClient[Notification]
+
+func (c *notifierServiceClient) CancelNotification(ctx context.Context, in *CancelNotificationRequest, opts ...grpc.CallOption) (*Notification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Notification)
+	err := c.cc.Invoke(ctx, NotifierService_CancelNotification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notifierServiceClient) UpdateNotification(ctx context.Context, in *UpdateNotificationRequest, opts ...grpc.CallOption) (*Notification, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Notification)
+	err := c.cc.Invoke(ctx, NotifierService_UpdateNotification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NotifierServiceServer is the server API for NotifierService service.
+// All implementations must embed UnimplementedNotifierServiceServer
+// for forward compatibility.
+//
+// NotifierService manages delayed notifications. It mirrors the /notify
+// HTTP API.
+type NotifierServiceServer interface {
+	CreateNotification(context.Context, *CreateNotificationRequest) (*Notification, error)
+	GetNotification(context.Context, *GetNotificationRequest) (*Notification, error)
+	// ListNotifications streams notifications ordered by id.
+	ListNotifications(*ListNotificationsRequest, grpc.ServerStreamingServer[Notification]) error
+	CancelNotification(context.Context, *CancelNotificationRequest) (*Notification, error)
+	UpdateNotification(context.Context, *UpdateNotificationRequest) (*Notification, error)
+	mustEmbedUnimplementedNotifierServiceServer()
+}
+
+// UnimplementedNotifierServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNotifierServiceServer struct{}
+
+func (UnimplementedNotifierServiceServer) CreateNotification(context.Context, *CreateNotificationRequest) (*Notification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateNotification not implemented")
+}
+func (UnimplementedNotifierServiceServer) GetNotification(context.Context, *GetNotificationRequest) (*Notification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotification not implemented")
+}
+func (UnimplementedNotifierServiceServer) ListNotifications(*ListNotificationsRequest, grpc.ServerStreamingServer[Notification]) error {
+	return status.Errorf(codes.Unimplemented, "method ListNotifications not implemented")
+}
+func (UnimplementedNotifierServiceServer) CancelNotification(context.Context, *CancelNotificationRequest) (*Notification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelNotification not implemented")
+}
+func (UnimplementedNotifierServiceServer) UpdateNotification(context.Context, *UpdateNotificationRequest) (*Notification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNotification not implemented")
+}
+func (UnimplementedNotifierServiceServer) mustEmbedUnimplementedNotifierServiceServer() {}
+func (UnimplementedNotifierServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeNotifierServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NotifierServiceServer will
+// result in compilation errors.
+type UnsafeNotifierServiceServer interface {
+	mustEmbedUnimplementedNotifierServiceServer()
+}
+
+func RegisterNotifierServiceServer(s grpc.ServiceRegistrar, srv NotifierServiceServer) {
+	// If the following call pancis, it indicates UnimplementedNotifierServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&NotifierService_ServiceDesc, srv)
+}
+
+func _NotifierService_CreateNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotifierServiceServer).CreateNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotifierService_CreateNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotifierServiceServer).CreateNotification(ctx, req.(*CreateNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotifierService_GetNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotifierServiceServer).GetNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotifierService_GetNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotifierServiceServer).GetNotification(ctx, req.(*GetNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotifierService_ListNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListNotificationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NotifierServiceServer).ListNotifications(m, &grpc.GenericServerStream[ListNotificationsRequest, Notification]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NotifierService_ListNotificationsServer = grpc.ServerStreamingServer[Notification]
+
+func _NotifierService_CancelNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotifierServiceServer).CancelNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotifierService_CancelNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotifierServiceServer).CancelNotification(ctx, req.(*CancelNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotifierService_UpdateNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotifierServiceServer).UpdateNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotifierService_UpdateNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotifierServiceServer).UpdateNotification(ctx, req.(*UpdateNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NotifierService_ServiceDesc is the grpc.ServiceDesc for NotifierService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NotifierService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "notifier.v1.NotifierService",
+	HandlerType: (*NotifierServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateNotification",
+			Handler:    _NotifierService_CreateNotification_Handler,
+		},
+		{
+			MethodName: "GetNotification",
+			Handler:    _NotifierService_GetNotification_Handler,
+		},
+		{
+			MethodName: "CancelNotification",
+			Handler:    _NotifierService_CancelNotification_Handler,
+		},
+		{
+			MethodName: "UpdateNotification",
+			Handler:    _NotifierService_UpdateNotification_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListNotifications",
+			Handler:       _NotifierService_ListNotifications_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "notifier/v1/notifier.proto",
+}
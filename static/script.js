@@ -20,7 +20,7 @@ document.getElementById('notificationForm').addEventListener('submit', async fun
     };
 
     try {
-        const response = await fetch('/notify', {
+        const response = await fetch('/api/v1/notifications', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -34,7 +34,7 @@ document.getElementById('notificationForm').addEventListener('submit', async fun
             this.reset();
         } else {
             const errorData = await response.json();
-            displayResponse('Error: ' + (errorData.error || 'Unknown error'), true);
+            displayResponse('Error: ' + problemMessage(errorData), true);
         }
     } catch (error) {
         displayResponse('Network error: ' + error.message, true);
@@ -53,7 +53,7 @@ document.getElementById('loadNotificationsBtn').addEventListener('click', async
     container.style.color = 'black';
 
     try {
-        const response = await fetch('/notify');
+        const response = await fetch('/api/v1/notifications');
         if (response.ok) {
             const notifications = await response.json();
             if (notifications.length === 0) {
@@ -70,7 +70,7 @@ document.getElementById('loadNotificationsBtn').addEventListener('click', async
             item.classList.add('notification-card');
 
             const text = document.createElement('p');
-            const sendAtDate = new Date(parseInt(notif.send_at)).toLocaleString();
+            const sendAtDate = new Date(notif.send_at).toLocaleString();
             text.innerHTML = `ID: ${notif.id}<br>Text: ${notif.text}<br>Telegram ID: ${notif.telegram_id}<br>Send At: ${sendAtDate}<br>Status: ${notif.status}`;
             text.classList.add('notification-text');
 
@@ -80,9 +80,10 @@ document.getElementById('loadNotificationsBtn').addEventListener('click', async
 
             container.innerHTML = '';
             container.appendChild(list);
+            watchNotifications();
         } else {
             const errorData = await response.json();
-            container.textContent = 'Error: ' + (errorData.error || 'Unknown error');
+            container.textContent = 'Error: ' + problemMessage(errorData);
             container.style.color = 'red';
         }
     } catch (error) {
@@ -91,6 +92,22 @@ document.getElementById('loadNotificationsBtn').addEventListener('click', async
     }
 });
 
+// Once the list is shown, reload it whenever a notification changes.
+let notificationStream = null;
+
+function watchNotifications() {
+    if (notificationStream) {
+        return;
+    }
+
+    notificationStream = new EventSource('/api/v1/notifications/stream');
+    ['created', 'sent', 'failed', 'canceled', 'expired', 'rescheduled', 'updated'].forEach(type => {
+        notificationStream.addEventListener(type, () => {
+            document.getElementById('loadNotificationsBtn').click();
+        });
+    });
+}
+
 document.getElementById('cancelNotificationForm').addEventListener('submit', async function(event) {
     event.preventDefault();
 
@@ -104,7 +121,7 @@ document.getElementById('cancelNotificationForm').addEventListener('submit', asy
     }
 
     try {
-        const response = await fetch('/notify/' + cancelId, {
+        const response = await fetch('/api/v1/notifications/' + cancelId, {
             method: 'DELETE'
         });
 
@@ -115,7 +132,7 @@ document.getElementById('cancelNotificationForm').addEventListener('submit', asy
             this.reset();
         } else {
             const errorData = await response.json();
-            cancelResponseDiv.textContent = 'Error: ' + (errorData.error || 'Unknown error');
+            cancelResponseDiv.textContent = 'Error: ' + problemMessage(errorData);
             cancelResponseDiv.style.color = 'red';
         }
     } catch (error) {
@@ -123,3 +140,15 @@ document.getElementById('cancelNotificationForm').addEventListener('submit', asy
         cancelResponseDiv.style.color = 'red';
     }
 });
+
+// problemMessage formats a problem+json error response.
+function problemMessage(problem) {
+    let message = problem.detail || problem.title || 'Unknown error';
+    if (problem.errors) {
+        message += ': ' + problem.errors.map(e => e.field + ' ' + e.message).join('; ');
+    }
+    if (problem.request_id) {
+        message += ' (request ' + problem.request_id + ')';
+    }
+    return message;
+}